@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"strconv"
+	"text/tabwriter"
+)
+
+const feesUsage = `usage:
+  simplebank fees list
+  simplebank fees set CURRENCY FLAT_FEE PERCENT_BPS MAX_FEE FEE_ACCOUNT_ID`
+
+// RunFeesCommand executes the fees subcommand described by args and writes
+// its report to w.
+func RunFeesCommand(ctx context.Context, store db.Store, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(feesUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return errors.New(feesUsage)
+		}
+		rules, err := store.ListFeeRules(ctx)
+		if err != nil {
+			return err
+		}
+		return printFeeRules(rules, w)
+
+	case "set":
+		if len(args) != 6 {
+			return errors.New(feesUsage)
+		}
+		if !util.IsSupportedCurrency(args[1]) {
+			return fmt.Errorf("unsupported currency %q\n%s", args[1], feesUsage)
+		}
+		nums, err := parseInts(args[2:], []string{"flat fee", "percent bps", "max fee", "fee account id"})
+		if err != nil {
+			return fmt.Errorf("%v\n%s", err, feesUsage)
+		}
+
+		rule, err := store.SetFeeRule(ctx, db.UpsertFeeRuleParams{
+			Currency:     args[1],
+			FlatFee:      nums[0],
+			PercentBps:   nums[1],
+			MaxFee:       nums[2],
+			FeeAccountID: nums[3],
+		})
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				return fmt.Errorf("account %d not found", nums[3])
+			}
+			return err
+		}
+		return printFeeRules([]db.FeeRule{rule}, w)
+	}
+
+	return fmt.Errorf("unknown fees command %q\n%s", args[0], feesUsage)
+}
+
+func printFeeRules(rules []db.FeeRule, w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CURRENCY\tFLAT FEE\tPERCENT BPS\tMAX FEE\tFEE ACCOUNT")
+	for _, r := range rules {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", r.Currency, r.FlatFee, r.PercentBps, r.MaxFee, r.FeeAccountID)
+	}
+	return tw.Flush()
+}
+
+// parseInts parses args as non-negative integers, naming the bad one after
+// names when one does not parse.
+func parseInts(args, names []string) ([]int64, error) {
+	nums := make([]int64, len(args))
+	for i, arg := range args {
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s %q", names[i], arg)
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunFeesCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rule := db.FeeRule{Currency: util.USD, FlatFee: 25, PercentBps: 100, MaxFee: 500, FeeAccountID: 7}
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListFeeRules(gomock.Any()).
+		Times(1).
+		Return([]db.FeeRule{rule}, nil)
+	store.EXPECT().
+		SetFeeRule(gomock.Any(), gomock.Eq(db.UpsertFeeRuleParams{
+			Currency:     util.USD,
+			FlatFee:      25,
+			PercentBps:   100,
+			MaxFee:       500,
+			FeeAccountID: 7,
+		})).
+		Times(1).
+		Return(rule, nil)
+	store.EXPECT().
+		SetFeeRule(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.FeeRule{}, db.ErrFeeAccountCurrencyMismatch)
+
+	var out bytes.Buffer
+	require.NoError(t, RunFeesCommand(context.Background(), store, []string{"list"}, &out))
+	require.Contains(t, out.String(), "USD       25        100          500      7")
+
+	out.Reset()
+	require.NoError(t, RunFeesCommand(context.Background(), store, []string{"set", "USD", "25", "100", "500", "7"}, &out))
+	require.Contains(t, out.String(), "USD")
+
+	err := RunFeesCommand(context.Background(), store, []string{"set", "NGN", "25", "100", "500", "7"}, &out)
+	require.ErrorIs(t, err, db.ErrFeeAccountCurrencyMismatch)
+
+	require.Error(t, RunFeesCommand(context.Background(), store, []string{"set", "XYZ", "25", "100", "500", "7"}, &out))
+	require.Error(t, RunFeesCommand(context.Background(), store, []string{"set", "USD", "-1", "100", "500", "7"}, &out))
+	require.Error(t, RunFeesCommand(context.Background(), store, []string{"list", "extra"}, &out))
+	require.Error(t, RunFeesCommand(context.Background(), store, nil, &out))
+}
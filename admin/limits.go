@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const limitsUsage = `usage:
+  simplebank limits list
+  simplebank limits add global CURRENCY MAX_AMOUNT DAILY_AMOUNT DAILY_COUNT
+  simplebank limits add owner USERNAME CURRENCY MAX_AMOUNT DAILY_AMOUNT DAILY_COUNT
+  simplebank limits add account ACCOUNT_ID MAX_AMOUNT DAILY_AMOUNT DAILY_COUNT
+  simplebank limits delete ID
+
+An amount or count of 0 means no limit. An account's limit is in the
+account's currency.`
+
+var limitNames = []string{"max amount", "daily amount", "daily count"}
+
+// RunLimitsCommand executes the limits subcommand described by args and
+// writes its report to w.
+func RunLimitsCommand(ctx context.Context, store db.Store, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(limitsUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return errors.New(limitsUsage)
+		}
+		limits, err := store.ListTransferLimits(ctx)
+		if err != nil {
+			return err
+		}
+		return printTransferLimits(limits, w)
+
+	case "add":
+		arg, err := transferLimitParams(ctx, store, args[1:])
+		if err != nil {
+			return err
+		}
+		limit, err := store.CreateTransferLimit(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return printTransferLimits([]db.TransferLimit{limit}, w)
+
+	case "delete":
+		if len(args) != 2 {
+			return errors.New(limitsUsage)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || id < 1 {
+			return fmt.Errorf("invalid limit id %q\n%s", args[1], limitsUsage)
+		}
+		if _, err := store.GetTransferLimit(ctx, id); err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				return fmt.Errorf("limit %d not found", id)
+			}
+			return err
+		}
+		return store.DeleteTransferLimit(ctx, id)
+	}
+
+	return fmt.Errorf("unknown limits command %q\n%s", args[0], limitsUsage)
+}
+
+// transferLimitParams builds the limit described by the arguments of add,
+// checking that the user or account it is tied to exists.
+func transferLimitParams(ctx context.Context, store db.Store, args []string) (db.CreateTransferLimitParams, error) {
+	var arg db.CreateTransferLimitParams
+	if len(args) == 0 {
+		return arg, errors.New(limitsUsage)
+	}
+
+	switch args[0] {
+	case "global":
+		if len(args) != 5 {
+			return arg, errors.New(limitsUsage)
+		}
+		arg.Currency = args[1]
+
+	case "owner":
+		if len(args) != 6 {
+			return arg, errors.New(limitsUsage)
+		}
+		if _, err := store.GetUser(ctx, args[1]); err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				return arg, fmt.Errorf("user %q not found", args[1])
+			}
+			return arg, err
+		}
+		arg.Owner = pgtype.Text{String: args[1], Valid: true}
+		arg.Currency = args[2]
+
+	case "account":
+		if len(args) != 5 {
+			return arg, errors.New(limitsUsage)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || id < 1 {
+			return arg, fmt.Errorf("invalid account id %q\n%s", args[1], limitsUsage)
+		}
+		account, err := store.GetAccount(ctx, id)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				return arg, fmt.Errorf("account %d not found", id)
+			}
+			return arg, err
+		}
+		arg.AccountID = pgtype.Int8{Int64: account.ID, Valid: true}
+		arg.Currency = account.Currency
+
+	default:
+		return arg, fmt.Errorf("unknown limit scope %q\n%s", args[0], limitsUsage)
+	}
+
+	if !util.IsSupportedCurrency(arg.Currency) {
+		return arg, fmt.Errorf("unsupported currency %q\n%s", arg.Currency, limitsUsage)
+	}
+	nums, err := parseInts(args[len(args)-3:], limitNames)
+	if err != nil {
+		return arg, fmt.Errorf("%v\n%s", err, limitsUsage)
+	}
+	arg.MaxAmount, arg.DailyAmount, arg.DailyCount = nums[0], nums[1], nums[2]
+	return arg, nil
+}
+
+func printTransferLimits(limits []db.TransferLimit, w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tOWNER\tACCOUNT\tCURRENCY\tMAX AMOUNT\tDAILY AMOUNT\tDAILY COUNT")
+	for _, l := range limits {
+		owner, account := "*", "*"
+		if l.Owner.Valid {
+			owner = l.Owner.String
+		}
+		if l.AccountID.Valid {
+			account = strconv.FormatInt(l.AccountID.Int64, 10)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%d\t%d\n", l.ID, owner, account, l.Currency, l.MaxAmount, l.DailyAmount, l.DailyCount)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunLimitsCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	account := db.Account{ID: 9, Owner: "alice", Currency: util.NGN}
+	ownerLimit := db.TransferLimit{
+		ID:          1,
+		Owner:       pgtype.Text{String: "alice", Valid: true},
+		Currency:    util.USD,
+		DailyAmount: 1000,
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListTransferLimits(gomock.Any()).
+		Times(1).
+		Return([]db.TransferLimit{ownerLimit}, nil)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq("alice")).
+		Times(1).
+		Return(db.User{Username: "alice"}, nil)
+	store.EXPECT().
+		CreateTransferLimit(gomock.Any(), gomock.Eq(db.CreateTransferLimitParams{
+			Owner:       ownerLimit.Owner,
+			Currency:    util.USD,
+			DailyAmount: 1000,
+		})).
+		Times(1).
+		Return(ownerLimit, nil)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
+	store.EXPECT().
+		CreateTransferLimit(gomock.Any(), gomock.Eq(db.CreateTransferLimitParams{
+			AccountID: pgtype.Int8{Int64: account.ID, Valid: true},
+			Currency:  util.NGN,
+			MaxAmount: 50,
+		})).
+		Times(1).
+		Return(db.TransferLimit{ID: 2}, nil)
+	store.EXPECT().
+		GetTransferLimit(gomock.Any(), gomock.Eq(int64(1))).
+		Times(1).
+		Return(ownerLimit, nil)
+	store.EXPECT().
+		DeleteTransferLimit(gomock.Any(), gomock.Eq(int64(1))).
+		Times(1).
+		Return(nil)
+	store.EXPECT().
+		GetTransferLimit(gomock.Any(), gomock.Eq(int64(3))).
+		Times(1).
+		Return(db.TransferLimit{}, db.ErrRecordNotFound)
+
+	var out bytes.Buffer
+	require.NoError(t, RunLimitsCommand(context.Background(), store, []string{"list"}, &out))
+	require.Contains(t, out.String(), "alice")
+	require.Contains(t, out.String(), "USD")
+
+	require.NoError(t, RunLimitsCommand(context.Background(), store, []string{"add", "owner", "alice", "USD", "0", "1000", "0"}, &out))
+	require.NoError(t, RunLimitsCommand(context.Background(), store, []string{"add", "account", "9", "50", "0", "0"}, &out))
+	require.NoError(t, RunLimitsCommand(context.Background(), store, []string{"delete", "1"}, &out))
+	require.Error(t, RunLimitsCommand(context.Background(), store, []string{"delete", "3"}, &out))
+
+	// A limit always has a currency, since amounts in different currencies
+	// do not add up.
+	require.Error(t, RunLimitsCommand(context.Background(), store, []string{"add", "global", "0", "1000", "0"}, &out))
+	require.Error(t, RunLimitsCommand(context.Background(), store, []string{"add", "global", "XYZ", "0", "1000", "0"}, &out))
+	require.Error(t, RunLimitsCommand(context.Background(), store, []string{"add", "global", "USD", "0", "-1", "0"}, &out))
+	require.Error(t, RunLimitsCommand(context.Background(), store, []string{"add", "team", "USD", "0", "1", "0"}, &out))
+	require.Error(t, RunLimitsCommand(context.Background(), store, []string{"list", "extra"}, &out))
+	require.Error(t, RunLimitsCommand(context.Background(), store, nil, &out))
+}
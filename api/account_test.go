@@ -26,6 +26,10 @@ func TestCreateAccountAPI(t *testing.T) {
 		Balance:  0,
 		Currency: acc.Currency,
 	}
+	txArg := db.CreateAccountTxParams{
+		CreateAccountParams: arg,
+		Audit:               testAudit(user.Username),
+	}
 
 	testSuite := []struct {
 		name          string
@@ -42,7 +46,11 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(arg)).
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Eq(txArg)).
 					Times(1).
 					Return(acc, nil)
 			},
@@ -73,7 +81,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(w *httptest.ResponseRecorder) {
@@ -88,7 +96,11 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(arg)).
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Eq(txArg)).
 					Times(1).
 					Return(db.Account{}, sql.ErrConnDone)
 			},
@@ -96,6 +108,28 @@ func TestCreateAccountAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, w.Code)
 			},
 		},
+		{
+			name: "EmailNotVerified",
+			arg:  arg,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				unverified := user
+				unverified.IsEmailVerified = false
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(unverified, nil)
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+			},
+		},
 	}
 
 	for _, tc := range testSuite {
@@ -106,6 +140,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			server := newTestServer(t, store)
 
+			allowAuth(store)
 			tc.buildStubs(store)
 
 			createAccountReq := createAccountReq{
@@ -116,6 +151,7 @@ func TestCreateAccountAPI(t *testing.T) {
 
 			w := httptest.NewRecorder()
 			req, _ := http.NewRequest("POST", "/accounts", bytes.NewBuffer(reqVal))
+			req.Header.Set(requestIDHeaderKey, testRequestID)
 
 			tc.setupAuth(t, req, server.tokenMaker)
 			server.router.ServeHTTP(w, req)
@@ -180,7 +216,7 @@ func TestGetAccountAPI(t *testing.T) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(acc.ID)).
 					Times(1).
-					Return(db.Account{}, sql.ErrNoRows)
+					Return(db.Account{}, db.ErrRecordNotFound)
 			},
 			checkResponse: func(w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, w.Code)
@@ -212,6 +248,7 @@ func TestGetAccountAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			server := newTestServer(t, store)
 
+			allowAuth(store)
 			tc.buildStubs(store)
 
 			url := fmt.Sprintf("/accounts/%v", tc.accountID)
@@ -299,6 +336,7 @@ func TestListAccountsAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			server := newTestServer(t, store)
 
+			allowAuth(store)
 			tc.buildStubs(store)
 
 			listAccountReq := listAccountsReq{
@@ -326,6 +364,7 @@ func createRandomAccount(username string) db.Account {
 		Owner:    username,
 		Currency: util.RandomCurrency(),
 		Balance:  int64(util.RandomAmount()),
+		Number:   util.RandomAccountNumber(),
 	}
 }
 
@@ -1,16 +1,17 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/metrics"
 	"simplebank/token"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 )
 
+var errEmailNotVerified = errors.New("email address is not verified")
+
 type createAccountReq struct {
 	Currency string `json:"currency" binding:"required,currency"`
 }
@@ -24,24 +25,41 @@ func (server *Server) createAccount(c *gin.Context) {
 	}
 
 	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
-	arg := db.CreateAccountParams{
-		Owner:    payload.Username,
-		Balance:  0,
-		Currency: req.Currency,
+
+	user, err := server.store.GetUser(c, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !user.IsEmailVerified {
+		c.JSON(http.StatusForbidden, errorResponse(errEmailNotVerified))
+		return
 	}
 
-	acc, err := server.store.CreateAccount(c, arg)
+	arg := db.CreateAccountTxParams{
+		CreateAccountParams: db.CreateAccountParams{
+			Owner:    payload.Username,
+			Balance:  0,
+			Currency: req.Currency,
+		},
+		Audit: auditContext(c),
+	}
+
+	acc, err := server.store.CreateAccountTx(c, arg)
 	if err != nil {
-		if pgErr, ok := err.(*pq.Error); ok {
-			switch pgErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				c.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
+		switch db.ErrorCode(err) {
+		case db.ForeignKeyViolation, db.UniqueViolation:
+			c.JSON(http.StatusForbidden, errorResponse(err))
+			return
 		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	metrics.AccountsCreated.WithLabelValues(acc.Currency).Inc()
 
 	c.JSON(http.StatusOK, acc)
 }
@@ -60,7 +78,7 @@ func (server *Server) getAccount(c *gin.Context) {
 
 	acc, err := server.store.GetAccount(c, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
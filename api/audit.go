@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var errNotBanker = errors.New("only bankers may read the audit log")
+
+// auditContext describes the request for the audit log, with the
+// authenticated user, if any, as the actor.
+func auditContext(c *gin.Context) db.AuditContext {
+	audit := db.AuditContext{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString(requestIDKey),
+	}
+	if payload, ok := c.Get(authorizationPayloadKey); ok {
+		audit.Actor = payload.(*token.Payload).Username
+	}
+	return audit
+}
+
+// auditContextFor is auditContext for requests made on behalf of username
+// before there is a token, such as sign-ups and logins.
+func auditContextFor(c *gin.Context, username string) db.AuditContext {
+	audit := auditContext(c)
+	audit.Actor = username
+	return audit
+}
+
+// requireBanker answers 403 unless the authenticated user is a banker.
+func (server *Server) requireBanker(c *gin.Context) bool {
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(c, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusForbidden, errorResponse(errNotBanker))
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if user.Role != util.BankerRole {
+		c.JSON(http.StatusForbidden, errorResponse(errNotBanker))
+		return false
+	}
+	return true
+}
+
+type listAuditLogsReq struct {
+	AfterID  int64  `form:"after_id" binding:"min=0"`
+	PageSize int32  `form:"page_size" binding:"required,min=1,max=100"`
+	Actor    string `form:"actor"`
+	Action   string `form:"action"`
+	Resource string `form:"resource"`
+}
+
+// listAuditLogs pages through the log in ID order. Pass the last ID seen
+// as after_id to get the next page.
+func (server *Server) listAuditLogs(c *gin.Context) {
+	var req listAuditLogsReq
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.requireBanker(c) {
+		return
+	}
+
+	arg := db.ListAuditLogsParams{
+		AfterID:  req.AfterID,
+		Actor:    pgtype.Text{String: req.Actor, Valid: req.Actor != ""},
+		Action:   pgtype.Text{String: req.Action, Valid: req.Action != ""},
+		Resource: pgtype.Text{String: req.Resource, Valid: req.Resource != ""},
+		PageSize: req.PageSize,
+	}
+
+	logs, err := server.store.ListAuditLogs(c, arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
+func (server *Server) verifyAuditLog(c *gin.Context) {
+	if !server.requireBanker(c) {
+		return
+	}
+
+	result, err := server.store.VerifyAuditLog(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// testRequestID is sent as X-Request-ID by tests that match the audit
+// context of a call exactly.
+const testRequestID = "test-request"
+
+// testAudit is the audit context of a test request sent with testRequestID
+// by actor. httptest requests carry no remote address or user agent.
+func testAudit(actor string) db.AuditContext {
+	return db.AuditContext{Actor: actor, RequestID: testRequestID}
+}
+
+// allowAudit accepts any audit event written outside of a store
+// transaction, such as a login.
+func allowAudit(store *mockdb.MockStore) {
+	store.EXPECT().
+		AppendAuditTx(gomock.Any(), gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.AuditLog{}, nil)
+}
+
+// expectAudit requires one audit event with the given action.
+func expectAudit(store *mockdb.MockStore, actor, action string) {
+	store.EXPECT().
+		AppendAuditTx(gomock.Any(), auditActorMatcher(actor), auditActionMatcher(action)).
+		Times(1).
+		Return(db.AuditLog{}, nil)
+}
+
+type auditActorMatcher string
+
+func (m auditActorMatcher) Matches(x interface{}) bool {
+	audit, ok := x.(db.AuditContext)
+	return ok && audit.Actor == string(m)
+}
+
+func (m auditActorMatcher) String() string {
+	return fmt.Sprintf("has actor %q", string(m))
+}
+
+type auditActionMatcher string
+
+func (m auditActionMatcher) Matches(x interface{}) bool {
+	event, ok := x.(db.AuditEvent)
+	return ok && event.Action == string(m)
+}
+
+func (m auditActionMatcher) String() string {
+	return fmt.Sprintf("has action %q", string(m))
+}
+
+func TestListAuditLogsAPI(t *testing.T) {
+	banker, _ := createRandomUser(t)
+	banker.Role = util.BankerRole
+	depositor, _ := createRandomUser(t)
+	depositor.Role = util.DepositorRole
+
+	logs := []db.AuditLog{
+		{ID: 1, Action: db.AuditUserCreated, Actor: depositor.Username, Resource: db.UserResource(depositor.Username)},
+		{ID: 2, Action: db.AuditLoginSucceeded, Actor: depositor.Username, Resource: db.UserResource(depositor.Username)},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: banker.Username,
+			query:    fmt.Sprintf("page_size=10&actor=%s", depositor.Username),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				arg := db.ListAuditLogsParams{
+					Actor:    pgtype.Text{String: depositor.Username, Valid: true},
+					PageSize: 10,
+				}
+				store.EXPECT().
+					ListAuditLogs(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(logs, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+				var got []db.AuditLog
+				require.NoError(t, json.Unmarshal(data, &got))
+				require.Len(t, got, len(logs))
+				require.Equal(t, logs[1].Action, got[1].Action)
+			},
+		},
+		{
+			name:     "NextPage",
+			username: banker.Username,
+			query:    "page_size=10&after_id=2&action=login.failed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				arg := db.ListAuditLogsParams{
+					AfterID:  2,
+					Action:   pgtype.Text{String: db.AuditLoginFailed, Valid: true},
+					PageSize: 10,
+				}
+				store.EXPECT().
+					ListAuditLogs(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.AuditLog{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotBanker",
+			username: depositor.Username,
+			query:    "page_size=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(depositor.Username)).
+					Times(1).
+					Return(depositor, nil)
+				store.EXPECT().
+					ListAuditLogs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidPageSize",
+			username: banker.Username,
+			query:    "page_size=1000",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditLogs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: banker.Username,
+			query:    "page_size=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				store.EXPECT().
+					ListAuditLogs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/audit_logs?"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVerifyAuditLogAPI(t *testing.T) {
+	banker, _ := createRandomUser(t)
+	banker.Role = util.BankerRole
+	depositor, _ := createRandomUser(t)
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Broken",
+			username: banker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				store.EXPECT().
+					VerifyAuditLog(gomock.Any()).
+					Times(1).
+					Return(db.AuditVerification{Checked: 41, BrokenAt: 42}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.AuditVerification
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.False(t, got.Valid)
+				require.Equal(t, int64(42), got.BrokenAt)
+			},
+		},
+		{
+			name:     "NotBanker",
+			username: depositor.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(depositor.Username)).
+					Times(1).
+					Return(depositor, nil)
+				store.EXPECT().
+					VerifyAuditLog(gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/audit_logs/verify", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
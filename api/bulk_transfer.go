@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBulkTransferItems must match the max of bulkTransferReq.Items.
+const maxBulkTransferItems = 500
+
+type bulkTransferItemReq struct {
+	ToAccountID int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	Reference   string `json:"reference" binding:"max=140"`
+}
+
+type bulkTransferReq struct {
+	FromAccountID int64                 `json:"from_account_id" binding:"required,min=1"`
+	Currency      string                `json:"currency" binding:"required,currency"`
+	Mode          string                `json:"mode" binding:"required,oneof=atomic per_item"`
+	Items         []bulkTransferItemReq `json:"items" binding:"required,min=1,max=500,dive"`
+}
+
+var errBulkTotalOverflow = errors.New("total amount of the items is too large")
+
+func (server *Server) createBulkTransfer(c *gin.Context) {
+	var req bulkTransferReq
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(c, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != payload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	var total int64
+	for _, item := range req.Items {
+		total += item.Amount
+		if total < 0 {
+			c.JSON(http.StatusBadRequest, errorResponse(errBulkTotalOverflow))
+			return
+		}
+	}
+
+	if !server.checkStepUp(c, payload, req.Currency, total) {
+		return
+	}
+
+	arg := db.BulkTransferTxParams{
+		FromAccountID: req.FromAccountID,
+		Mode:          req.Mode,
+		Items:         make([]db.BulkTransferItem, len(req.Items)),
+		Audit:         auditContext(c),
+	}
+	for i, item := range req.Items {
+		quote, ok := server.quoteFee(c, req.Currency, item.Amount)
+		if !ok {
+			return
+		}
+		arg.Items[i] = db.BulkTransferItem{
+			ToAccountID: item.ToAccountID,
+			Amount:      item.Amount,
+			Reference:   item.Reference,
+			Quote:       quote,
+		}
+	}
+
+	result, err := server.store.BulkTransferTx(c, arg)
+	if err != nil {
+		var itemErr *db.BulkTransferItemError
+		if errors.As(err, &itemErr) {
+			c.JSON(bulkItemErrorStatus(itemErr.Err), gin.H{
+				"error": itemErr.Error(),
+				"index": itemErr.Index,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func bulkItemErrorStatus(err error) int {
+	var limitErr *db.TransferLimitError
+	switch {
+	case errors.As(err, &limitErr), errors.Is(err, db.ErrInsufficientFunds):
+		return http.StatusForbidden
+	case errors.Is(err, db.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, db.ErrCurrencyMismatch), errors.Is(err, db.ErrSameAccount):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
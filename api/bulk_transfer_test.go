@@ -0,0 +1,261 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBulkTransferAPI(t *testing.T) {
+	user1, _ := createRandomUser(t)
+	acc := createRandomAccount(user1.Username)
+	acc.Currency = util.USD
+	user2, _ := createRandomUser(t)
+
+	items := []bulkTransferItemReq{
+		{ToAccountID: acc.ID + 1, Amount: 100, Reference: "salary march"},
+		{ToAccountID: acc.ID + 2, Amount: 250, Reference: "salary march"},
+	}
+	quote := db.FeeQuote{Currency: util.USD}
+	arg := db.BulkTransferTxParams{
+		FromAccountID: acc.ID,
+		Mode:          db.BulkModePerItem,
+		Items: []db.BulkTransferItem{
+			{ToAccountID: items[0].ToAccountID, Amount: items[0].Amount, Reference: items[0].Reference, Quote: quote},
+			{ToAccountID: items[1].ToAccountID, Amount: items[1].Amount, Reference: items[1].Reference, Quote: quote},
+		},
+		Audit: testAudit(user1.Username),
+	}
+
+	testSuite := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "StatusOK",
+			body: gin.H{
+				"from_account_id": acc.ID,
+				"currency":        util.USD,
+				"mode":            db.BulkModePerItem,
+				"items":           items,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(acc.ID)).
+					Times(1).
+					Return(acc, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Eq(util.USD), gomock.Any()).
+					Times(2).
+					Return(quote, nil)
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.BulkTransferTxResult{
+						Items: []db.BulkTransferItemResult{
+							{Index: 0, Status: db.BulkItemSucceeded},
+							{Index: 1, Status: db.BulkItemFailed, Error: db.ErrCurrencyMismatch.Error()},
+						},
+					}, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var result db.BulkTransferTxResult
+				err := json.Unmarshal(w.Body.Bytes(), &result)
+				require.NoError(t, err)
+				require.Len(t, result.Items, 2)
+				require.Equal(t, db.BulkItemFailed, result.Items[1].Status)
+			},
+		},
+		{
+			name: "AtomicItemFailed",
+			body: gin.H{
+				"from_account_id": acc.ID,
+				"currency":        util.USD,
+				"mode":            db.BulkModeAtomic,
+				"items":           items,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(acc.ID)).
+					Times(1).
+					Return(acc, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(quote, nil)
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BulkTransferTxResult{}, &db.BulkTransferItemError{Index: 1, Err: db.ErrRecordNotFound})
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+
+				var body gin.H
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				require.NoError(t, err)
+				require.Equal(t, float64(1), body["index"])
+			},
+		},
+		{
+			name: "StepUpOnTotal",
+			body: gin.H{
+				"from_account_id": acc.ID,
+				"currency":        util.USD,
+				"mode":            db.BulkModeAtomic,
+				"items":           items,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(acc.ID)).
+					Times(1).
+					Return(acc, nil)
+				store.EXPECT().
+					GetStepUpRule(gomock.Any(), gomock.Eq(util.USD)).
+					Times(1).
+					Return(db.StepUpRule{Currency: util.USD, Threshold: 300}, nil)
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"from_account_id": acc.ID,
+				"currency":        util.USD,
+				"mode":            db.BulkModeAtomic,
+				"items":           items,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(acc.ID)).
+					Times(1).
+					Return(acc, nil)
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name: "InvalidMode",
+			body: gin.H{
+				"from_account_id": acc.ID,
+				"currency":        util.USD,
+				"mode":            "some",
+				"items":           items,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "InvalidItemAmount",
+			body: gin.H{
+				"from_account_id": acc.ID,
+				"currency":        util.USD,
+				"mode":            db.BulkModeAtomic,
+				"items":           []bulkTransferItemReq{{ToAccountID: acc.ID + 1, Amount: -1}},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "TooManyItems",
+			body: gin.H{
+				"from_account_id": acc.ID,
+				"currency":        util.USD,
+				"mode":            db.BulkModeAtomic,
+				"items":           make([]bulkTransferItemReq, maxBulkTransferItems+1),
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range testSuite {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			allowAuth(store)
+			tc.buildStubs(store)
+			noStepUpRule(store)
+
+			reqVal, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/transfers/bulk", bytes.NewBuffer(reqVal))
+			req.Header.Set(requestIDHeaderKey, testRequestID)
+
+			tc.setupAuth(t, req, server.tokenMaker)
+			server.router.ServeHTTP(w, req)
+
+			tc.checkResponse(w)
+		})
+	}
+}
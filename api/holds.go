@@ -0,0 +1,176 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type createHoldReq struct {
+	FromAccountID    int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID      int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount           int64  `json:"amount" binding:"required,gt=0"`
+	Currency         string `json:"currency" binding:"required,currency"`
+	ExpiresInSeconds int64  `json:"expires_in_seconds" binding:"omitempty,min=1"`
+}
+
+func (server *Server) createHold(c *gin.Context) {
+	var req createHoldReq
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(c, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != payload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	_, valid = server.validAccount(c, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	if !server.checkStepUp(c, payload, req.Currency, req.Amount) {
+		return
+	}
+
+	duration := server.config.HoldDuration
+	if req.ExpiresInSeconds > 0 {
+		duration = time.Duration(req.ExpiresInSeconds) * time.Second
+	}
+
+	result, err := server.store.CreateHoldTx(c, db.CreateHoldTxParams{
+		AccountID:   req.FromAccountID,
+		ToAccountID: req.ToAccountID,
+		Amount:      req.Amount,
+		Duration:    duration,
+	})
+	if err != nil {
+		server.holdErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+type holdReq struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// captureHold is only open to the recipient: the sender already agreed to the
+// payment when placing the hold.
+func (server *Server) captureHold(c *gin.Context) {
+	hold, fromAccount, valid := server.validHold(c, false)
+	if !valid {
+		return
+	}
+
+	quote, ok := server.quoteFee(c, fromAccount.Currency, hold.Amount)
+	if !ok {
+		return
+	}
+
+	result, err := server.store.CaptureHoldTx(c, db.CaptureHoldTxParams{
+		HoldID: hold.ID,
+		Quote:  quote,
+		Audit:  auditContext(c),
+	})
+	if err != nil {
+		server.holdErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (server *Server) releaseHold(c *gin.Context) {
+	hold, _, valid := server.validHold(c, true)
+	if !valid {
+		return
+	}
+
+	result, err := server.store.ReleaseHoldTx(c, hold.ID)
+	if err != nil {
+		server.holdErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// validHold loads the hold named in the URI and makes sure the authenticated
+// user owns its destination account, or with allowSender either side of it.
+func (server *Server) validHold(c *gin.Context, allowSender bool) (db.Hold, db.Account, bool) {
+	var req holdReq
+	var acc db.Account
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Hold{}, acc, false
+	}
+
+	hold, err := server.store.GetHold(c, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return hold, acc, false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, acc, false
+	}
+
+	acc, err = server.store.GetAccount(c, hold.AccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, acc, false
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if allowSender && acc.Owner == payload.Username {
+		return hold, acc, true
+	}
+
+	toAccount, err := server.store.GetAccount(c, hold.ToAccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, acc, false
+	}
+	if toAccount.Owner != payload.Username {
+		err := errors.New("hold doesn't belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return hold, acc, false
+	}
+
+	return hold, acc, true
+}
+
+func (server *Server) holdErrorResponse(c *gin.Context, err error) {
+	var limitErr *db.TransferLimitError
+	switch {
+	case errors.As(err, &limitErr):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":     limitErr.Error(),
+			"limit":     limitErr.Limit,
+			"allowance": limitErr.Allowance,
+		})
+	case errors.Is(err, db.ErrInsufficientFunds):
+		c.JSON(http.StatusForbidden, errorResponse(err))
+	case errors.Is(err, db.ErrHoldNotPending), errors.Is(err, db.ErrHoldExpired):
+		c.JSON(http.StatusConflict, errorResponse(err))
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
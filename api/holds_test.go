@@ -0,0 +1,443 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateHoldAPI(t *testing.T) {
+	user1, _ := createRandomUser(t)
+	acc1 := createRandomAccount(user1.Username)
+	acc1.Currency = util.USD
+	user2, _ := createRandomUser(t)
+	acc2 := createRandomAccount(user2.Username)
+	acc2.Currency = util.USD
+	amount := int64(util.RandomAmount())
+
+	testSuite := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "StatusOK",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				arg := db.CreateHoldTxParams{
+					AccountID:   acc1.ID,
+					ToAccountID: acc2.ID,
+					Amount:      amount,
+					Duration:    time.Hour,
+				}
+				store.EXPECT().
+					CreateHoldTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.HoldTxResult{}, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "CustomExpiry",
+			body: gin.H{
+				"from_account_id":    acc1.ID,
+				"to_account_id":      acc2.ID,
+				"amount":             amount,
+				"currency":           util.USD,
+				"expires_in_seconds": 60,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				arg := db.CreateHoldTxParams{
+					AccountID:   acc1.ID,
+					ToAccountID: acc2.ID,
+					Amount:      amount,
+					Duration:    time.Minute,
+				}
+				store.EXPECT().
+					CreateHoldTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.HoldTxResult{}, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "StepUpRequired",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().
+					GetStepUpRule(gomock.Any(), gomock.Eq(util.USD)).
+					Times(1).
+					Return(db.StepUpRule{Currency: util.USD, Threshold: amount - 1}, nil)
+				store.EXPECT().CreateHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+
+				var body gin.H
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				require.NoError(t, err)
+				require.Equal(t, true, body["step_up_required"])
+			},
+		},
+		{
+			name: "SteppedUp",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				stepUpToken, err := tokenMaker.CreateStepUpToken(user1.Username, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+stepUpToken)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().
+					GetStepUpRule(gomock.Any(), gomock.Eq(util.USD)).
+					Times(1).
+					Return(db.StepUpRule{Currency: util.USD, Threshold: amount - 1}, nil)
+				store.EXPECT().CreateHoldTx(gomock.Any(), gomock.Any()).Times(1).Return(db.HoldTxResult{}, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "StatusUnauthorized",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().CreateHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name: "StatusForbidden Insufficient Funds",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().
+					CreateHoldTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.HoldTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+			},
+		},
+		{
+			name: "StatusBadRequest",
+			body: gin.H{
+				"from_account_id": acc1.ID,
+				"to_account_id":   acc2.ID,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range testSuite {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			allowAuth(store)
+			tc.buildStubs(store)
+			noStepUpRule(store)
+
+			reqVal, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/holds", bytes.NewBuffer(reqVal))
+
+			tc.setupAuth(t, req, server.tokenMaker)
+			server.router.ServeHTTP(w, req)
+
+			tc.checkResponse(w)
+		})
+	}
+}
+
+func TestCaptureHoldAPI(t *testing.T) {
+	user1, _ := createRandomUser(t)
+	acc1 := createRandomAccount(user1.Username)
+	user2, _ := createRandomUser(t)
+	acc2 := createRandomAccount(user2.Username)
+	user3, _ := createRandomUser(t)
+	hold := createRandomHold(acc1, acc2)
+	quote := db.FeeQuote{Currency: acc1.Currency, Amount: hold.Amount, Total: hold.Amount}
+
+	testSuite := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(w *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "StatusUnauthorized Sender",
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name:     "StatusOK Recipient",
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Eq(acc1.Currency), gomock.Eq(hold.Amount)).
+					Times(1).
+					Return(quote, nil)
+				store.EXPECT().
+					CaptureHoldTx(gomock.Any(), gomock.Eq(db.CaptureHoldTxParams{
+						HoldID: hold.ID,
+						Quote:  quote,
+						Audit:  testAudit(user2.Username),
+					})).
+					Times(1).
+					Return(db.CaptureHoldTxResult{}, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:     "StatusUnauthorized",
+			username: user3.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name:     "StatusNotFound",
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(db.Hold{}, db.ErrRecordNotFound)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			name:     "StatusConflict",
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc2.ID)).Times(1).Return(acc2, nil)
+				store.EXPECT().QuoteFee(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(quote, nil)
+				store.EXPECT().
+					CaptureHoldTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CaptureHoldTxResult{}, db.ErrHoldExpired)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range testSuite {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			w := httptest.NewRecorder()
+			url := fmt.Sprintf("/holds/%d/capture", hold.ID)
+			req, _ := http.NewRequest("POST", url, nil)
+			req.Header.Set(requestIDHeaderKey, testRequestID)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(w, req)
+
+			tc.checkResponse(w)
+		})
+	}
+}
+
+func TestReleaseHoldAPI(t *testing.T) {
+	user1, _ := createRandomUser(t)
+	acc1 := createRandomAccount(user1.Username)
+	user2, _ := createRandomUser(t)
+	acc2 := createRandomAccount(user2.Username)
+	hold := createRandomHold(acc1, acc2)
+
+	testSuite := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "StatusOK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().
+					ReleaseHoldTx(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(db.HoldTxResult{}, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "StatusConflict",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc1.ID)).Times(1).Return(acc1, nil)
+				store.EXPECT().
+					ReleaseHoldTx(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(db.HoldTxResult{}, db.ErrHoldNotPending)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, w.Code)
+			},
+		},
+		{
+			name: "StatusInternalServerError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(db.Hold{}, sql.ErrConnDone)
+				store.EXPECT().ReleaseHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range testSuite {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			w := httptest.NewRecorder()
+			url := fmt.Sprintf("/holds/%d/release", hold.ID)
+			req, _ := http.NewRequest("POST", url, nil)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			server.router.ServeHTTP(w, req)
+
+			tc.checkResponse(w)
+		})
+	}
+}
+
+func createRandomHold(from, to db.Account) db.Hold {
+	return db.Hold{
+		ID:          int64(util.RandomInt(1, 1000)),
+		AccountID:   from.ID,
+		ToAccountID: to.ID,
+		Amount:      int64(util.RandomAmount()),
+		Status:      db.HoldStatusPending,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+}
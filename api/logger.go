@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"simplebank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeaderKey = "X-Request-ID"
+	requestIDKey       = "request_id"
+	maxRequestIDLength = 128
+)
+
+// requestID reuses the X-Request-ID sent by the client, or a proxy in front
+// of it, and generates one otherwise. The ID is echoed in the response.
+func requestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(requestIDHeaderKey)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = uuid.NewString()
+		}
+
+		ctx.Set(requestIDKey, id)
+		ctx.Header(requestIDHeaderKey, id)
+		ctx.Next()
+	}
+}
+
+// requestLogger writes one structured line per request. It only records
+// request metadata: bodies and headers, which carry passwords and tokens,
+// are never logged.
+func requestLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+		duration := time.Since(start)
+
+		statusCode := ctx.Writer.Status()
+		var event *zerolog.Event
+		switch {
+		case statusCode >= 500:
+			event = log.Error()
+		case statusCode >= 400:
+			event = log.Warn()
+		default:
+			event = log.Info()
+		}
+
+		path := ctx.FullPath()
+		if path == "" {
+			path = ctx.Request.URL.Path
+		}
+
+		event = event.
+			Str("protocol", "http").
+			Str("request_id", ctx.GetString(requestIDKey)).
+			Str("method", ctx.Request.Method).
+			Str("path", path).
+			Int("status_code", statusCode).
+			Str("status_text", http.StatusText(statusCode)).
+			Dur("duration", duration).
+			Str("client_ip", ctx.ClientIP())
+
+		if sc := trace.SpanContextFromContext(ctx.Request.Context()); sc.HasTraceID() {
+			event = event.Str("trace_id", sc.TraceID().String())
+		}
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			event = event.Str("username", payload.(*token.Payload).Username)
+		}
+		if len(ctx.Errors) > 0 {
+			event = event.Str("error", ctx.Errors.String())
+		}
+
+		event.Msg("received an HTTP request")
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		name        string
+		header      string
+		checkHeader func(t *testing.T, requestID string)
+	}{
+		{
+			name:   "Propagated",
+			header: "client-request-id",
+			checkHeader: func(t *testing.T, requestID string) {
+				require.Equal(t, "client-request-id", requestID)
+			},
+		},
+		{
+			name: "Generated",
+			checkHeader: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:   "TooLong",
+			header: strings.Repeat("a", maxRequestIDLength+1),
+			checkHeader: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			path := "/request-id"
+
+			var handlerRequestID string
+			server.router.GET(path, func(ctx *gin.Context) {
+				handlerRequestID = ctx.GetString(requestIDKey)
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			if tc.header != "" {
+				request.Header.Set(requestIDHeaderKey, tc.header)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			requestID := recorder.Header().Get(requestIDHeaderKey)
+			require.Equal(t, handlerRequestID, requestID)
+			tc.checkHeader(t, requestID)
+		})
+	}
+}
@@ -3,22 +3,29 @@ package api
 import (
 	"os"
 	db "simplebank/db/sqlc"
+	"simplebank/health"
 	"simplebank/util"
+	"simplebank/watch"
+	"simplebank/worker"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
-		TokenKey:      util.RandomString(32),
-		TokenDuration: time.Minute,
+		TokenKey:               util.RandomString(32),
+		TokenDuration:          time.Minute,
+		MFATokenDuration:       time.Minute,
+		MFAEncryptionKey:       util.RandomString(32),
+		StepUpMaxAge:           5 * time.Minute,
+		HoldDuration:           time.Hour,
+		PaymentRequestDuration: time.Hour,
 	}
 
-	server, err := NewServer(config, store)
+	server, err := NewServer(config, store, health.NewChecker(store, nil), worker.NewTaskDistributor(store), watch.NewHub())
 	require.NoError(t, err)
 
 	return server
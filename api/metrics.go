@@ -0,0 +1,28 @@
+package api
+
+import (
+	"simplebank/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestMetrics records the rate, errors and duration of every request,
+// labelled by route template so that path parameters do not blow up the
+// number of series.
+func requestMetrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := strconv.Itoa(ctx.Writer.Status())
+		metrics.HTTPRequests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+		metrics.HTTPDuration.WithLabelValues(ctx.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"simplebank/metrics"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/users/unknown-route", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	// The metrics are served by their own server, not the public API.
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	metrics.NewServer("").Handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	require.Contains(t, body, `simplebank_http_requests_total{method="GET",route="unmatched",status="404"}`)
+	require.Contains(t, body, "simplebank_http_request_duration_seconds")
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/metrics"
+	"simplebank/mfa"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const backupCodeCount = 10
+
+var (
+	errTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	errTOTPNotEnrolled    = errors.New("two-factor authentication has not been enrolled")
+	errInvalidMFACode     = errors.New("invalid two-factor authentication code")
+)
+
+type enrollTOTPResponse struct {
+	ProvisioningURI string   `json:"provisioning_uri"`
+	QRCode          string   `json:"qr_code"`
+	BackupCodes     []string `json:"backup_codes"`
+}
+
+// enrollTOTP starts enrollment. The secret stays inactive until the user
+// proves their authenticator works through enableTOTP. The backup codes are
+// only ever shown here.
+func (server *Server) enrollTOTP(c *gin.Context) {
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	enrollment, err := mfa.NewEnrollment(payload.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	sealedSecret, err := server.mfaBox.Seal(enrollment.Secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	backupCodes, err := mfa.GenerateBackupCodes(backupCodeCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	codeHashes := make([]string, len(backupCodes))
+	for i, code := range backupCodes {
+		codeHashes[i] = util.HashToken(mfa.NormalizeBackupCode(code))
+	}
+
+	_, err = server.store.EnrollTOTPTx(c, db.EnrollTOTPTxParams{
+		Username:         payload.Username,
+		TOTPSecret:       sealedSecret,
+		BackupCodeHashes: codeHashes,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusConflict, errorResponse(errTOTPAlreadyEnabled))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollTOTPResponse{
+		ProvisioningURI: enrollment.ProvisioningURI,
+		QRCode:          enrollment.QRCode,
+		BackupCodes:     backupCodes,
+	})
+}
+
+type enableTOTPRequest struct {
+	Code string `json:"code" binding:"required,numeric,len=6"`
+}
+
+func (server *Server) enableTOTP(c *gin.Context) {
+	var req enableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUser(c, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.TotpEnabled {
+		c.JSON(http.StatusConflict, errorResponse(errTOTPAlreadyEnabled))
+		return
+	}
+	if user.TotpSecret == "" {
+		c.JSON(http.StatusBadRequest, errorResponse(errTOTPNotEnrolled))
+		return
+	}
+
+	secret, err := server.mfaBox.Open(user.TotpSecret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	step, ok := mfa.ValidateCode(secret, req.Code)
+	if !ok {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidMFACode))
+		return
+	}
+
+	user, err = server.store.EnableUserTOTP(c, db.EnableUserTOTPParams{
+		Username:     user.Username,
+		TotpLastStep: step,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type mfaChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+// startMFAChallenge answers a correct password for an enrolled user. The
+// MFA token cannot be used as an access token; it is traded in at
+// loginMFA together with a code.
+func (server *Server) startMFAChallenge(c *gin.Context, user db.User) {
+	mfaToken, err := server.tokenMaker.CreateMFAToken(user.Username, server.config.MFATokenDuration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	metrics.Logins.WithLabelValues(metrics.LoginMFARequired).Inc()
+	c.JSON(http.StatusOK, mfaChallengeResponse{MFARequired: true, MFAToken: mfaToken})
+}
+
+type loginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// loginMFA accepts either a TOTP code or an unused backup code. Wrong codes
+// count towards the same lockout as wrong passwords.
+func (server *Server) loginMFA(c *gin.Context) {
+	var req loginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := server.tokenMaker.VerifyMFAToken(req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if !server.allowLogin(c, "ip:"+c.ClientIP(), server.loginIPLimiter) ||
+		!server.allowLogin(c, "user:"+payload.Username, server.loginUsernameLimiter) {
+		return
+	}
+
+	user, err := server.store.GetUser(c, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.mfaFailed(c)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !user.TotpEnabled ||
+		user.LockedUntil.After(time.Now()) ||
+		payload.IssuedAt.Before(user.PasswordChangedAt) {
+		server.mfaFailed(c)
+		return
+	}
+
+	ok, err := server.checkSecondFactor(c, user, req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !ok {
+		if err := server.recordFailedLogin(c, user.Username, db.AuditMFAFailed); err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		server.mfaFailed(c)
+		return
+	}
+
+	server.completeLogin(c, user)
+}
+
+func (server *Server) checkSecondFactor(c *gin.Context, user db.User, code string) (bool, error) {
+	secret, err := server.mfaBox.Open(user.TotpSecret)
+	if err != nil {
+		return false, err
+	}
+	if step, ok := mfa.ValidateCode(secret, code); ok {
+		// A code is only good once, even while it is still current.
+		_, err = server.store.UseTOTPStep(c, db.UseTOTPStepParams{
+			Username: user.Username,
+			Step:     step,
+		})
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+
+	_, err = server.store.UseBackupCode(c, db.UseBackupCodeParams{
+		Username: user.Username,
+		CodeHash: util.HashToken(mfa.NormalizeBackupCode(code)),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (server *Server) mfaFailed(c *gin.Context) {
+	metrics.Logins.WithLabelValues(metrics.LoginFailed).Inc()
+	c.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+}
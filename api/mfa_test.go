@@ -0,0 +1,441 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/mfa"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrollTOTPAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	box, err := mfa.NewSecretBox(util.RandomString(32))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker)
+		buildStubs    func(store *mockdb.MockStore, arg *db.EnrollTOTPTxParams)
+		checkResponse func(recorder *httptest.ResponseRecorder, arg db.EnrollTOTPTxParams)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, arg *db.EnrollTOTPTxParams) {
+				store.EXPECT().
+					EnrollTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, got db.EnrollTOTPTxParams) (db.EnrollTOTPTxResult, error) {
+						*arg = got
+						return db.EnrollTOTPTxResult{User: user}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, arg db.EnrollTOTPTxParams) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res enrollTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				require.NoError(t, err)
+				require.NotEmpty(t, res.QRCode)
+				require.Len(t, res.BackupCodes, backupCodeCount)
+
+				// The stored secret is sealed, and opens to the one in the URI.
+				uri, err := url.Parse(res.ProvisioningURI)
+				require.NoError(t, err)
+				secret := uri.Query().Get("secret")
+				require.NotContains(t, arg.TOTPSecret, secret)
+				opened, err := box.Open(arg.TOTPSecret)
+				require.NoError(t, err)
+				require.Equal(t, secret, opened)
+
+				// Only hashes of the backup codes are stored.
+				require.Equal(t, user.Username, arg.Username)
+				require.Len(t, arg.BackupCodeHashes, backupCodeCount)
+				for i, code := range res.BackupCodes {
+					require.Equal(t, util.HashToken(mfa.NormalizeBackupCode(code)), arg.BackupCodeHashes[i])
+				}
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, arg *db.EnrollTOTPTxParams) {
+				store.EXPECT().
+					EnrollTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EnrollTOTPTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, arg db.EnrollTOTPTxParams) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+			},
+			buildStubs: func(store *mockdb.MockStore, arg *db.EnrollTOTPTxParams) {
+				store.EXPECT().
+					EnrollTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, arg db.EnrollTOTPTxParams) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, arg *db.EnrollTOTPTxParams) {
+				store.EXPECT().
+					EnrollTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EnrollTOTPTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, arg db.EnrollTOTPTxParams) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var arg db.EnrollTOTPTxParams
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store, &arg)
+
+			server := newTestServer(t, store)
+			server.mfaBox = box
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users/mfa/totp", nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder, arg)
+		})
+	}
+}
+
+func TestEnableTOTPAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	box, err := mfa.NewSecretBox(util.RandomString(32))
+	require.NoError(t, err)
+
+	enrollment, err := mfa.NewEnrollment(user.Username)
+	require.NoError(t, err)
+	user.TotpSecret, err = box.Seal(enrollment.Secret)
+	require.NoError(t, err)
+
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+	wrongCode := "000000"
+	if wrongCode == code {
+		wrongCode = "111111"
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				enabled := user
+				enabled.TotpEnabled = true
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					EnableUserTOTP(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.EnableUserTOTPParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.InDelta(t, time.Now().Unix()/30, arg.TotpLastStep, 1)
+						return enabled, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res userResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				require.NoError(t, err)
+				require.True(t, res.MFAEnabled)
+			},
+		},
+		{
+			name: "WrongCode",
+			body: gin.H{"code": wrongCode},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().EnableUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotEnrolled",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				notEnrolled := user
+				notEnrolled.TotpSecret = ""
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(notEnrolled, nil)
+				store.EXPECT().EnableUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				enabled := user
+				enabled.TotpEnabled = true
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(enabled, nil)
+				store.EXPECT().EnableUserTOTP(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCodeFormat",
+			body: gin.H{"code": "abc"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.mfaBox = box
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/mfa/totp/enable", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestLoginMFAAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	box, err := mfa.NewSecretBox(util.RandomString(32))
+	require.NoError(t, err)
+
+	enrollment, err := mfa.NewEnrollment(user.Username)
+	require.NoError(t, err)
+	user.TotpSecret, err = box.Seal(enrollment.Secret)
+	require.NoError(t, err)
+	user.TotpEnabled = true
+
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+	backupCode := "abcde-23456"
+
+	mfaBody := func(code string) func(t *testing.T, tokenMaker token.TokenMaker) gin.H {
+		return func(t *testing.T, tokenMaker token.TokenMaker) gin.H {
+			mfaToken, err := tokenMaker.CreateMFAToken(user.Username, time.Minute)
+			require.NoError(t, err)
+			return gin.H{"mfa_token": mfaToken, "code": code}
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		buildBody     func(t *testing.T, tokenMaker token.TokenMaker) gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			buildBody: mfaBody(code),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UseTOTPStepParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.InDelta(t, time.Now().Unix()/30, arg.Step, 1)
+						return user, nil
+					})
+				store.EXPECT().UseBackupCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				require.NoError(t, err)
+				require.NotEmpty(t, res.AccessToken)
+				require.Equal(t, user.Username, res.User.Username)
+			},
+		},
+		{
+			name:      "BackupCode",
+			buildBody: mfaBody("ABCDE23456"),
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UseBackupCodeParams{
+					Username: user.Username,
+					CodeHash: util.HashToken(mfa.NormalizeBackupCode(backupCode)),
+				}
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().UseBackupCode(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.MfaBackupCode{IsUsed: true}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "ReplayedCode",
+			buildBody: mfaBody(code),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().UseBackupCode(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RecordFailedLogin(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(int32(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "WrongCode",
+			buildBody: mfaBody("123"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().UseBackupCode(gomock.Any(), gomock.Any()).Times(1).Return(db.MfaBackupCode{}, db.ErrRecordNotFound)
+				store.EXPECT().RecordFailedLogin(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(int32(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "AccessTokenInsteadOfMFAToken",
+			buildBody: func(t *testing.T, tokenMaker token.TokenMaker) gin.H {
+				accessToken, err := tokenMaker.CreateToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				return gin.H{"mfa_token": accessToken, "code": code}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "Locked",
+			buildBody: mfaBody(code),
+			buildStubs: func(store *mockdb.MockStore) {
+				locked := user
+				locked.LockedUntil = time.Now().Add(time.Minute)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(locked, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "PasswordChangedSinceChallenge",
+			buildBody: mfaBody(code),
+			buildStubs: func(store *mockdb.MockStore) {
+				changed := user
+				changed.PasswordChangedAt = time.Now().Add(time.Second)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(changed, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "MissingCode",
+			buildBody: mfaBody(""),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			allowAudit(store)
+
+			server := newTestServer(t, store)
+			server.mfaBox = box
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.buildBody(t, server.tokenMaker))
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	db "simplebank/db/sqlc"
 	"simplebank/token"
 	"strings"
 
@@ -16,7 +17,9 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
-func authMiddleware(tokenMaker token.TokenMaker) gin.HandlerFunc {
+var errTokenRevoked = errors.New("token was issued before the last password change")
+
+func authMiddleware(tokenMaker token.TokenMaker, store db.Store) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 
@@ -47,6 +50,20 @@ func authMiddleware(tokenMaker token.TokenMaker) gin.HandlerFunc {
 			return
 		}
 
+		passwordChangedAt, err := store.GetUserPasswordChangedAt(ctx, payload.Username)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if payload.IssuedAt.Before(passwordChangedAt) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errTokenRevoked))
+			return
+		}
+
 		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
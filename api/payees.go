@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// payeeResponse shows the payee's account by number, never by its id.
+type payeeResponse struct {
+	ID            int64     `json:"id"`
+	Nickname      string    `json:"nickname"`
+	AccountNumber string    `json:"account_number"`
+	AccountOwner  string    `json:"account_owner"`
+	Currency      string    `json:"currency"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type createPayeeReq struct {
+	Nickname      string `json:"nickname" binding:"required,max=64"`
+	AccountNumber string `json:"account_number" binding:"required_without=Username,excluded_with=Username,omitempty,account_number"`
+	Username      string `json:"username" binding:"omitempty,alphanum"`
+	Currency      string `json:"currency" binding:"required_with=Username,omitempty,currency"`
+}
+
+func (server *Server) createPayee(c *gin.Context) {
+	var req createPayeeReq
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var acc db.Account
+	var err error
+	if req.AccountNumber != "" {
+		acc, err = server.store.GetAccountByNumber(c, req.AccountNumber)
+	} else {
+		acc, err = server.store.GetAccountByOwnerAndCurrency(c, db.GetAccountByOwnerAndCurrencyParams{
+			Owner:    req.Username,
+			Currency: req.Currency,
+		})
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	payee, err := server.store.CreatePayee(c, db.CreatePayeeParams{
+		Owner:     payload.Username,
+		AccountID: acc.ID,
+		Nickname:  req.Nickname,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			c.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, payeeResponse{
+		ID:            payee.ID,
+		Nickname:      payee.Nickname,
+		AccountNumber: acc.Number,
+		AccountOwner:  acc.Owner,
+		Currency:      acc.Currency,
+		CreatedAt:     payee.CreatedAt,
+	})
+}
+
+type listPayeesReq struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+func (server *Server) listPayees(c *gin.Context) {
+	var req listPayeesReq
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	payees, err := server.store.ListPayees(c, db.ListPayeesParams{
+		Owner:  payload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]payeeResponse, len(payees))
+	for i, p := range payees {
+		rsp[i] = payeeResponse(p)
+	}
+	c.JSON(http.StatusOK, rsp)
+}
+
+type payeeReq struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deletePayee(c *gin.Context) {
+	var req payeeReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	n, err := server.store.DeletePayee(c, db.DeletePayeeParams{
+		ID:    req.ID,
+		Owner: payload.Username,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if n == 0 {
+		c.JSON(http.StatusNotFound, errorResponse(db.ErrRecordNotFound))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePayeeAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	other, _ := createRandomUser(t)
+	account := createRandomAccount(other.Username)
+	payee := db.Payee{
+		ID:        int64(util.RandomInt(1, 1000)),
+		Owner:     user.Username,
+		AccountID: account.ID,
+		Nickname:  "landlord",
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "ByAccountNumber",
+			body: gin.H{"nickname": payee.Nickname, "account_number": account.Number},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.Number)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreatePayee(gomock.Any(), gomock.Eq(db.CreatePayeeParams{
+						Owner:     user.Username,
+						AccountID: account.ID,
+						Nickname:  payee.Nickname,
+					})).
+					Times(1).
+					Return(payee, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var got payeeResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				require.Equal(t, payee.ID, got.ID)
+				require.Equal(t, account.Number, got.AccountNumber)
+				require.Equal(t, account.Owner, got.AccountOwner)
+				require.NotContains(t, w.Body.String(), "account_id")
+			},
+		},
+		{
+			name: "ByUsername",
+			body: gin.H{"nickname": payee.Nickname, "username": other.Username, "currency": account.Currency},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    other.Username,
+						Currency: account.Currency,
+					})).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(1).Return(payee, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			body: gin.H{"nickname": payee.Nickname, "account_number": account.Number},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			name: "DuplicateNickname",
+			body: gin.H{"nickname": payee.Nickname, "account_number": account.Number},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(1).Return(account, nil)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(1).Return(db.Payee{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+			},
+		},
+		{
+			name: "BadCheckDigits",
+			body: gin.H{"nickname": payee.Nickname, "account_number": account.Number[:10] + "00"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "NumberAndUsername",
+			body: gin.H{
+				"nickname":       payee.Nickname,
+				"account_number": account.Number,
+				"username":       other.Username,
+				"currency":       account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "UsernameWithoutCurrency",
+			body: gin.H{"nickname": payee.Nickname, "username": other.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/payees", bytes.NewReader(body))
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(w)
+		})
+	}
+}
+
+func TestListPayeesAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	allowAuth(store)
+	store.EXPECT().
+		ListPayees(gomock.Any(), gomock.Eq(db.ListPayeesParams{
+			Owner:  user.Username,
+			Limit:  5,
+			Offset: 5,
+		})).
+		Times(1).
+		Return([]db.ListPayeesRow{{ID: 1, Nickname: "landlord", AccountNumber: util.RandomAccountNumber()}}, nil)
+
+	server := newTestServer(t, store)
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/payees?page_id=2&page_size=5", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var got []payeeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	require.Len(t, got, 1)
+	require.Equal(t, "landlord", got[0].Nickname)
+}
+
+func TestDeletePayeeAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	testCases := []struct {
+		name string
+		rows int64
+		code int
+	}{
+		{name: "OK", rows: 1, code: http.StatusNoContent},
+		{name: "NotFound", rows: 0, code: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			store.EXPECT().
+				DeletePayee(gomock.Any(), gomock.Eq(db.DeletePayeeParams{ID: 7, Owner: user.Username})).
+				Times(1).
+				Return(tc.rows, nil)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/payees/%d", 7), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.code, w.Code)
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	paymentRequestRequester = "requester"
+	paymentRequestPayer     = "payer"
+)
+
+type createPaymentRequestReq struct {
+	AccountID        int64  `json:"account_id" binding:"required,min=1"`
+	Payer            string `json:"payer" binding:"omitempty,alphanum"`
+	Amount           int64  `json:"amount" binding:"required,gt=0"`
+	Currency         string `json:"currency" binding:"required,currency"`
+	Note             string `json:"note" binding:"max=140"`
+	ExpiresInSeconds int64  `json:"expires_in_seconds" binding:"omitempty,min=1"`
+}
+
+// createPaymentRequestRes carries the request's link. Only the token's hash
+// is stored, so this is the one time it is shown.
+type createPaymentRequestRes struct {
+	db.PaymentRequest
+	LinkToken string `json:"link_token"`
+	Link      string `json:"link"`
+}
+
+// createPaymentRequest asks payer for money, or anyone given the link when
+// payer is left out.
+func (server *Server) createPaymentRequest(c *gin.Context) {
+	var req createPaymentRequestReq
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.validAccount(c, req.AccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != payload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if req.Payer == payload.Username {
+		err := errors.New("cannot request money from yourself")
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Payer != "" {
+		if _, err := server.store.GetUser(c, req.Payer); err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, errorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	linkToken, err := util.RandomSecret(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	duration := server.config.PaymentRequestDuration
+	if req.ExpiresInSeconds > 0 {
+		duration = time.Duration(req.ExpiresInSeconds) * time.Second
+	}
+
+	request, err := server.store.CreatePaymentRequest(c, db.CreatePaymentRequestParams{
+		RequesterAccountID: req.AccountID,
+		Payer:              pgtype.Text{String: req.Payer, Valid: req.Payer != ""},
+		Amount:             req.Amount,
+		Currency:           req.Currency,
+		Note:               req.Note,
+		ExpiresAt:          time.Now().Add(duration),
+		LinkTokenHash:      util.HashToken(linkToken),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, createPaymentRequestRes{
+		PaymentRequest: request,
+		LinkToken:      linkToken,
+		Link:           fmt.Sprintf("%s/payment_requests/links/%s", server.config.AppBaseURL, linkToken),
+	})
+}
+
+type listPaymentRequestsReq struct {
+	Role     string `form:"role" binding:"omitempty,oneof=requester payer"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+// listPaymentRequests lists the requests the user sent and received, or only
+// one side of them when role is given.
+func (server *Server) listPaymentRequests(c *gin.Context) {
+	var req listPaymentRequestsReq
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	requests, err := server.store.ListPaymentRequests(c, db.ListPaymentRequestsParams{
+		Username:    payload.Username,
+		AsRequester: req.Role != paymentRequestPayer,
+		AsPayer:     req.Role != paymentRequestRequester,
+		PageSize:    req.PageSize,
+		PageOffset:  (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+func (server *Server) getPaymentRequest(c *gin.Context) {
+	request, _, valid := server.validPaymentRequest(c)
+	if !valid {
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+type acceptPaymentRequestReq struct {
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+}
+
+func (server *Server) acceptPaymentRequest(c *gin.Context) {
+	request, role, valid := server.validPaymentRequest(c)
+	if !valid {
+		return
+	}
+	server.payPaymentRequest(c, request, role)
+}
+
+func (server *Server) getPaymentRequestByLink(c *gin.Context) {
+	request, _, valid := server.validPaymentRequestLink(c)
+	if !valid {
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+func (server *Server) acceptPaymentRequestByLink(c *gin.Context) {
+	request, role, valid := server.validPaymentRequestLink(c)
+	if !valid {
+		return
+	}
+	server.payPaymentRequest(c, request, role)
+}
+
+func (server *Server) payPaymentRequest(c *gin.Context, request db.PaymentRequest, role string) {
+	if role != paymentRequestPayer {
+		err := errors.New("only the payer can accept a payment request")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	var req acceptPaymentRequestReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(c, req.FromAccountID, request.Currency)
+	if !valid {
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != payload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if !server.checkStepUp(c, payload, request.Currency, request.Amount) {
+		return
+	}
+
+	quote, ok := server.quoteFee(c, request.Currency, request.Amount)
+	if !ok {
+		return
+	}
+
+	result, err := server.store.AcceptPaymentRequestTx(c, db.AcceptPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: req.FromAccountID,
+		Payer:         payload.Username,
+		Quote:         quote,
+		Audit:         auditContext(c),
+	})
+	if err != nil {
+		paymentRequestErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, acceptPaymentRequestResponse{
+		PaymentRequest:       result.PaymentRequest,
+		sentTransferResponse: newSentTransferResponse(result.TransferTxResult),
+	})
+}
+
+// acceptPaymentRequestResponse shows the payer the requester's account the
+// way any other sender sees the account they paid.
+type acceptPaymentRequestResponse struct {
+	PaymentRequest db.PaymentRequest `json:"payment_request"`
+	sentTransferResponse
+}
+
+func (server *Server) declinePaymentRequest(c *gin.Context) {
+	server.closePaymentRequest(c, paymentRequestPayer, "decline", db.PaymentRequestDeclined)
+}
+
+func (server *Server) cancelPaymentRequest(c *gin.Context) {
+	server.closePaymentRequest(c, paymentRequestRequester, "cancel", db.PaymentRequestCancelled)
+}
+
+func (server *Server) closePaymentRequest(c *gin.Context, allowedRole, action, status string) {
+	request, role, valid := server.validPaymentRequest(c)
+	if !valid {
+		return
+	}
+	if role != allowedRole {
+		err := fmt.Errorf("only the %s can %s a payment request", allowedRole, action)
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ClosePaymentRequestTx(c, db.ClosePaymentRequestTxParams{
+		ID:     request.ID,
+		Status: status,
+	})
+	if err != nil {
+		paymentRequestErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+type paymentRequestReq struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// validPaymentRequest loads the request named in the URI and makes sure the
+// authenticated user is either side of it. It returns which side.
+func (server *Server) validPaymentRequest(c *gin.Context) (db.PaymentRequest, string, bool) {
+	var req paymentRequestReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.PaymentRequest{}, "", false
+	}
+
+	request, err := server.store.GetPaymentRequest(c, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return request, "", false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return request, "", false
+	}
+
+	role, valid := server.paymentRequestRole(c, request, false)
+	return request, role, valid
+}
+
+type paymentRequestLinkReq struct {
+	Token string `uri:"token" binding:"required"`
+}
+
+// validPaymentRequestLink loads the request a link points to. Besides its
+// two sides, anyone holding the link of an open request may see and pay it.
+func (server *Server) validPaymentRequestLink(c *gin.Context) (db.PaymentRequest, string, bool) {
+	var req paymentRequestLinkReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.PaymentRequest{}, "", false
+	}
+
+	request, err := server.store.GetPaymentRequestByLinkToken(c, util.HashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return request, "", false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return request, "", false
+	}
+
+	role, valid := server.paymentRequestRole(c, request, true)
+	return request, role, valid
+}
+
+// paymentRequestRole tells which side of request the authenticated user is.
+func (server *Server) paymentRequestRole(c *gin.Context, request db.PaymentRequest, viaLink bool) (string, bool) {
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if request.Payer.Valid && request.Payer.String == payload.Username {
+		return paymentRequestPayer, true
+	}
+
+	account, err := server.store.GetAccount(c, request.RequesterAccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return "", false
+	}
+	if account.Owner == payload.Username {
+		return paymentRequestRequester, true
+	}
+	if viaLink && !request.Payer.Valid {
+		return paymentRequestPayer, true
+	}
+
+	err = errors.New("payment request doesn't belong to the authenticated user")
+	c.JSON(http.StatusUnauthorized, errorResponse(err))
+	return "", false
+}
+
+func paymentRequestErrorResponse(c *gin.Context, err error) {
+	var limitErr *db.TransferLimitError
+	switch {
+	case errors.As(err, &limitErr):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":     limitErr.Error(),
+			"limit":     limitErr.Limit,
+			"allowance": limitErr.Allowance,
+		})
+	case errors.Is(err, db.ErrInsufficientFunds):
+		c.JSON(http.StatusForbidden, errorResponse(err))
+	case errors.Is(err, db.ErrPaymentRequestNotPending), errors.Is(err, db.ErrPaymentRequestExpired):
+		c.JSON(http.StatusConflict, errorResponse(err))
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
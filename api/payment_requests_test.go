@@ -0,0 +1,613 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func randomPaymentRequest(requester db.Account, payer string) db.PaymentRequest {
+	return db.PaymentRequest{
+		ID:                 int64(util.RandomInt(1, 1000)),
+		RequesterAccountID: requester.ID,
+		Payer:              pgtype.Text{String: payer, Valid: payer != ""},
+		Amount:             int64(util.RandomInt(1, 100)),
+		Currency:           requester.Currency,
+		Note:               "dinner",
+		Status:             db.PaymentRequestPending,
+		ExpiresAt:          time.Now().Add(time.Hour),
+	}
+}
+
+func TestCreatePaymentRequestAPI(t *testing.T) {
+	requester, _ := createRandomUser(t)
+	payer, _ := createRandomUser(t)
+	account := createRandomAccount(requester.Username)
+	request := randomPaymentRequest(account, payer.Username)
+	var linkTokenHash string
+
+	requireLink := func(t *testing.T, w *httptest.ResponseRecorder) {
+		var res createPaymentRequestRes
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Equal(t, request.ID, res.ID)
+		require.Len(t, res.LinkToken, 64)
+		require.Equal(t, linkTokenHash, util.HashToken(res.LinkToken))
+		require.Contains(t, res.Link, "/payment_requests/links/"+res.LinkToken)
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(w *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: requester.Username,
+			body: gin.H{
+				"account_id": account.ID,
+				"payer":      payer.Username,
+				"amount":     request.Amount,
+				"currency":   account.Currency,
+				"note":       request.Note,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(payer.Username)).Times(1).Return(payer, nil)
+				store.EXPECT().
+					CreatePaymentRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreatePaymentRequestParams) (db.PaymentRequest, error) {
+						require.Equal(t, account.ID, arg.RequesterAccountID)
+						require.Equal(t, pgtype.Text{String: payer.Username, Valid: true}, arg.Payer)
+						require.Equal(t, request.Amount, arg.Amount)
+						require.Equal(t, account.Currency, arg.Currency)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt, time.Second)
+						linkTokenHash = arg.LinkTokenHash
+						return request, nil
+					})
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				requireLink(t, w)
+			},
+		},
+		{
+			name:     "OpenRequest",
+			username: requester.Username,
+			body: gin.H{
+				"account_id": account.ID,
+				"amount":     request.Amount,
+				"currency":   account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					CreatePaymentRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreatePaymentRequestParams) (db.PaymentRequest, error) {
+						require.False(t, arg.Payer.Valid)
+						linkTokenHash = arg.LinkTokenHash
+						return request, nil
+					})
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				requireLink(t, w)
+			},
+		},
+		{
+			name:     "FromSelf",
+			username: requester.Username,
+			body: gin.H{
+				"account_id": account.ID,
+				"payer":      requester.Username,
+				"amount":     request.Amount,
+				"currency":   account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name:     "PayerNotFound",
+			username: requester.Username,
+			body: gin.H{
+				"account_id": account.ID,
+				"payer":      payer.Username,
+				"amount":     request.Amount,
+				"currency":   account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(payer.Username)).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			name:     "NotAccountOwner",
+			username: payer.Username,
+			body: gin.H{
+				"account_id": account.ID,
+				"payer":      requester.Username,
+				"amount":     request.Amount,
+				"currency":   account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name:     "InvalidAmount",
+			username: requester.Username,
+			body: gin.H{
+				"account_id": account.ID,
+				"payer":      payer.Username,
+				"amount":     0,
+				"currency":   account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/payment_requests", bytes.NewReader(body))
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(w)
+		})
+	}
+}
+
+func TestListPaymentRequestsAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	testCases := []struct {
+		name        string
+		query       string
+		asRequester bool
+		asPayer     bool
+	}{
+		{name: "Both", query: "page_id=1&page_size=5", asRequester: true, asPayer: true},
+		{name: "Requester", query: "role=requester&page_id=1&page_size=5", asRequester: true},
+		{name: "Payer", query: "role=payer&page_id=2&page_size=5", asPayer: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			store.EXPECT().
+				ListPaymentRequests(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ interface{}, arg db.ListPaymentRequestsParams) ([]db.PaymentRequest, error) {
+					require.Equal(t, user.Username, arg.Username)
+					require.Equal(t, tc.asRequester, arg.AsRequester)
+					require.Equal(t, tc.asPayer, arg.AsPayer)
+					require.Equal(t, int32(5), arg.PageSize)
+					return []db.PaymentRequest{}, nil
+				})
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/payment_requests?"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestAcceptPaymentRequestAPI(t *testing.T) {
+	requester, _ := createRandomUser(t)
+	payer, _ := createRandomUser(t)
+	requesterAccount := createRandomAccount(requester.Username)
+	requesterAccount.Currency = util.USD
+	payerAccount := createRandomAccount(payer.Username)
+	payerAccount.Currency = util.USD
+	request := randomPaymentRequest(requesterAccount, payer.Username)
+	quote := db.FeeQuote{Currency: util.USD, Amount: request.Amount, Total: request.Amount}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(w *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payerAccount.ID)).Times(1).Return(payerAccount, nil)
+				store.EXPECT().QuoteFee(gomock.Any(), gomock.Eq(util.USD), gomock.Eq(request.Amount)).Times(1).Return(quote, nil)
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), gomock.Eq(db.AcceptPaymentRequestTxParams{
+						ID:            request.ID,
+						FromAccountID: payerAccount.ID,
+						Payer:         payer.Username,
+						Quote:         quote,
+						Audit:         testAudit(payer.Username),
+					})).
+					Times(1).
+					Return(db.AcceptPaymentRequestTxResult{}, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:     "NotPayer",
+			username: requester.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(requesterAccount.ID)).Times(1).Return(requesterAccount, nil)
+				store.EXPECT().AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name:     "Expired",
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payerAccount.ID)).Times(1).Return(payerAccount, nil)
+				store.EXPECT().QuoteFee(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(quote, nil)
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AcceptPaymentRequestTxResult{}, db.ErrPaymentRequestExpired)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, w.Code)
+			},
+		},
+		{
+			name:     "StepUpRequired",
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payerAccount.ID)).Times(1).Return(payerAccount, nil)
+				store.EXPECT().
+					GetStepUpRule(gomock.Any(), gomock.Eq(util.USD)).
+					Times(1).
+					Return(db.StepUpRule{Currency: util.USD, Threshold: request.Amount - 1}, nil)
+				store.EXPECT().AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+			},
+		},
+		{
+			name:     "Stranger",
+			username: util.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(requesterAccount.ID)).Times(1).Return(requesterAccount, nil)
+				store.EXPECT().AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+			noStepUpRule(store)
+
+			server := newTestServer(t, store)
+			body, err := json.Marshal(gin.H{"from_account_id": payerAccount.ID})
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			url := fmt.Sprintf("/payment_requests/%d/accept", request.ID)
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(requestIDHeaderKey, testRequestID)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(w)
+		})
+	}
+}
+
+func TestPaymentRequestLinkAPI(t *testing.T) {
+	requester, _ := createRandomUser(t)
+	payer, _ := createRandomUser(t)
+	requesterAccount := createRandomAccount(requester.Username)
+	requesterAccount.Currency = util.USD
+	payerAccount := createRandomAccount(payer.Username)
+	payerAccount.Currency = util.USD
+	open := randomPaymentRequest(requesterAccount, "")
+	designated := randomPaymentRequest(requesterAccount, util.RandomOwner())
+	linkToken := util.RandomString(64)
+	quote := db.FeeQuote{Currency: util.USD, Amount: open.Amount, Total: open.Amount}
+
+	testCases := []struct {
+		name          string
+		username      string
+		method        string
+		path          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(w *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "GetOpen",
+			username: payer.Username,
+			method:   http.MethodGet,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPaymentRequestByLinkToken(gomock.Any(), gomock.Eq(util.HashToken(linkToken))).
+					Times(1).
+					Return(open, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(requesterAccount.ID)).Times(1).Return(requesterAccount, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res db.PaymentRequest
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, open.ID, res.ID)
+			},
+		},
+		{
+			name:     "AcceptOpen",
+			username: payer.Username,
+			method:   http.MethodPost,
+			path:     "/accept",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequestByLinkToken(gomock.Any(), gomock.Any()).Times(1).Return(open, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(requesterAccount.ID)).Times(1).Return(requesterAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(payerAccount.ID)).Times(1).Return(payerAccount, nil)
+				store.EXPECT().QuoteFee(gomock.Any(), gomock.Eq(util.USD), gomock.Eq(open.Amount)).Times(1).Return(quote, nil)
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), gomock.Eq(db.AcceptPaymentRequestTxParams{
+						ID:            open.ID,
+						FromAccountID: payerAccount.ID,
+						Payer:         payer.Username,
+						Quote:         quote,
+						Audit:         testAudit(payer.Username),
+					})).
+					Times(1).
+					Return(db.AcceptPaymentRequestTxResult{
+						PaymentRequest: open,
+						TransferTxResult: db.TransferTxResult{
+							FromAccount: payerAccount,
+							ToAccount:   requesterAccount,
+						},
+					}, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				requireRecipientHidden(t, w, requesterAccount)
+			},
+		},
+		{
+			name:     "RequesterAcceptsOwn",
+			username: requester.Username,
+			method:   http.MethodPost,
+			path:     "/accept",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequestByLinkToken(gomock.Any(), gomock.Any()).Times(1).Return(open, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(requesterAccount.ID)).Times(1).Return(requesterAccount, nil)
+				store.EXPECT().AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name:     "OtherPayersRequest",
+			username: payer.Username,
+			method:   http.MethodPost,
+			path:     "/accept",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequestByLinkToken(gomock.Any(), gomock.Any()).Times(1).Return(designated, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(requesterAccount.ID)).Times(1).Return(requesterAccount, nil)
+				store.EXPECT().AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name:     "UnknownLink",
+			username: payer.Username,
+			method:   http.MethodGet,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPaymentRequestByLinkToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentRequest{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+			noStepUpRule(store)
+
+			server := newTestServer(t, store)
+			body, err := json.Marshal(gin.H{"from_account_id": payerAccount.ID})
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			url := fmt.Sprintf("/payment_requests/links/%s%s", linkToken, tc.path)
+			req, err := http.NewRequest(tc.method, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set(requestIDHeaderKey, testRequestID)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(w)
+		})
+	}
+}
+
+func TestClosePaymentRequestAPI(t *testing.T) {
+	requester, _ := createRandomUser(t)
+	payer, _ := createRandomUser(t)
+	account := createRandomAccount(requester.Username)
+	request := randomPaymentRequest(account, payer.Username)
+
+	testCases := []struct {
+		name          string
+		action        string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(w *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "PayerDeclines",
+			action:   "decline",
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClosePaymentRequestTx(gomock.Any(), gomock.Eq(db.ClosePaymentRequestTxParams{
+						ID:     request.ID,
+						Status: db.PaymentRequestDeclined,
+					})).
+					Times(1).
+					Return(request, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:     "RequesterCancels",
+			action:   "cancel",
+			username: requester.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ClosePaymentRequestTx(gomock.Any(), gomock.Eq(db.ClosePaymentRequestTxParams{
+						ID:     request.ID,
+						Status: db.PaymentRequestCancelled,
+					})).
+					Times(1).
+					Return(request, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:     "RequesterCannotDecline",
+			action:   "decline",
+			username: requester.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ClosePaymentRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name:     "NotPending",
+			action:   "decline",
+			username: payer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClosePaymentRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentRequest{}, db.ErrPaymentRequestNotPending)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(request.ID)).Times(1).Return(request, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			url := fmt.Sprintf("/payment_requests/%d/%s", request.ID, tc.action)
+			req, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(w)
+		})
+	}
+}
@@ -1,60 +1,194 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/health"
+	"simplebank/mfa"
+	"simplebank/ratelimit"
 	"simplebank/token"
 	"simplebank/util"
+	"simplebank/watch"
+	"simplebank/webhook"
+	"simplebank/worker"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Server struct {
-	store      db.Store
-	router     *gin.Engine
-	tokenMaker token.TokenMaker
-	config     util.Config
+	store       db.Store
+	health      *health.Checker
+	distributor worker.TaskDistributor
+	hub         *watch.Hub
+	router      *gin.Engine
+	httpServer  *http.Server
+	tokenMaker  token.TokenMaker
+	mfaBox      *mfa.SecretBox
+	config      util.Config
+
+	passwordHasher util.PasswordHasher
+	passwordPolicy util.PasswordPolicy
+	dummyHashOnce  sync.Once
+	dummyHash      string
+
+	loginIPLimiter       ratelimit.Limiter
+	loginUsernameLimiter ratelimit.Limiter
+	verifyEmailLimiter   ratelimit.Limiter
+	resetIPLimiter       ratelimit.Limiter
+	resetEmailLimiter    ratelimit.Limiter
+
+	webhookResolver webhook.Resolver
 }
 
-func NewServer(config util.Config, st db.Store) (*Server, error) {
+func NewServer(config util.Config, st db.Store, checker *health.Checker, distributor worker.TaskDistributor, hub *watch.Hub) (*Server, error) {
 	tokenMaker, err := token.NewPasetoMaker(config.TokenKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %v", err)
 	}
 
+	mfaBox, err := mfa.NewSecretBox(config.MFAEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create MFA secret box: %v", err)
+	}
+
 	server := Server{
-		store:      st,
-		tokenMaker: tokenMaker,
-		config:     config,
+		store:       st,
+		health:      checker,
+		distributor: distributor,
+		hub:         hub,
+		tokenMaker:  tokenMaker,
+		mfaBox:      mfaBox,
+		config:      config,
+
+		passwordHasher: util.NewPasswordHasher(config),
+		passwordPolicy: util.NewPasswordPolicy(config),
+
+		loginIPLimiter:       ratelimit.NewTokenBucket(config.LoginIPLimit, config.LoginLimitWindow),
+		loginUsernameLimiter: ratelimit.NewTokenBucket(config.LoginUsernameLimit, config.LoginLimitWindow),
+		verifyEmailLimiter:   ratelimit.NewTokenBucket(config.VerifyEmailResendLimit, config.VerifyEmailResendWindow),
+		resetIPLimiter:       ratelimit.NewTokenBucket(config.PasswordResetIPLimit, config.PasswordResetLimitWindow),
+		resetEmailLimiter:    ratelimit.NewTokenBucket(config.PasswordResetEmailLimit, config.PasswordResetLimitWindow),
+
+		webhookResolver: net.DefaultResolver,
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("webhook_event", validWebhookEvent)
+		v.RegisterValidation("account_number", validAccountNumber)
 	}
 
-	server.setupRouter()
+	if err := server.setupRouter(); err != nil {
+		return nil, err
+	}
 	return &server, nil
 }
 
-func (s *Server) setupRouter() {
-	router := gin.Default()
+func (s *Server) setupRouter() error {
+	router := gin.New()
+	// ClientIP keys the login rate limit, so forwarding headers are only
+	// believed when they come from a configured proxy.
+	if err := router.SetTrustedProxies(s.config.TrustedProxies); err != nil {
+		return fmt.Errorf("cannot set trusted proxies: %w", err)
+	}
+	router.Use(
+		otelgin.Middleware("simplebank-http", otelgin.WithFilter(func(r *http.Request) bool {
+			switch r.URL.Path {
+			case "/healthz", "/readyz":
+				return false
+			}
+			return true
+		})),
+		requestID(),
+		requestLogger(),
+		requestMetrics(),
+		gin.Recovery(),
+	)
+	router.GET("/healthz", s.healthz)
+	router.GET("/readyz", s.readyz)
+
 	router.POST("/users", s.createUser)
 	router.POST("/users/login", s.loginUser)
+	router.POST("/users/login/mfa", s.loginMFA)
+	router.GET("/users/verify_email", s.verifyEmail)
+	router.POST("/users/forgot_password", s.forgotPassword)
+	router.GET("/users/reset_password", s.checkResetPassword)
+	router.POST("/users/reset_password", s.resetPassword)
+
+	authRoutes := router.Group("/").Use(authMiddleware(s.tokenMaker, s.store))
+	authRoutes.PATCH("/users/:username", s.updateUser)
+	authRoutes.POST("/users/verify_email/resend", s.resendVerifyEmail)
+	authRoutes.POST("/users/mfa/totp", s.enrollTOTP)
+	authRoutes.POST("/users/mfa/totp/enable", s.enableTOTP)
+	authRoutes.POST("/users/step_up", s.stepUp)
 
-	authRoutes := router.Group("/").Use(authMiddleware(s.tokenMaker))
 	authRoutes.POST("/accounts", s.createAccount)
 	authRoutes.GET("/accounts/:id", s.getAccount)
 	authRoutes.GET("/accounts", s.listAccounts)
+	authRoutes.GET("/accounts/:id/watch", s.watchAccount)
 
 	authRoutes.POST("/transfers", s.createTransfer)
+	authRoutes.POST("/transfers/bulk", s.createBulkTransfer)
+	authRoutes.GET("/transfers/fee", s.quoteTransferFee)
+
+	authRoutes.POST("/payees", s.createPayee)
+	authRoutes.GET("/payees", s.listPayees)
+	authRoutes.DELETE("/payees/:id", s.deletePayee)
+
+	authRoutes.POST("/holds", s.createHold)
+	authRoutes.POST("/holds/:id/capture", s.captureHold)
+	authRoutes.POST("/holds/:id/release", s.releaseHold)
+
+	authRoutes.POST("/payment_requests", s.createPaymentRequest)
+	authRoutes.GET("/payment_requests", s.listPaymentRequests)
+	authRoutes.GET("/payment_requests/:id", s.getPaymentRequest)
+	authRoutes.POST("/payment_requests/:id/accept", s.acceptPaymentRequest)
+	authRoutes.POST("/payment_requests/:id/decline", s.declinePaymentRequest)
+	authRoutes.POST("/payment_requests/:id/cancel", s.cancelPaymentRequest)
+	authRoutes.GET("/payment_requests/links/:token", s.getPaymentRequestByLink)
+	authRoutes.POST("/payment_requests/links/:token/accept", s.acceptPaymentRequestByLink)
+
+	authRoutes.GET("/audit_logs", s.listAuditLogs)
+	authRoutes.GET("/audit_logs/verify", s.verifyAuditLog)
+
+	authRoutes.POST("/webhooks", s.createWebhookEndpoint)
+	authRoutes.GET("/webhooks", s.listWebhookEndpoints)
+	authRoutes.DELETE("/webhooks/:id", s.deleteWebhookEndpoint)
+	authRoutes.GET("/webhooks/:id/deliveries", s.listWebhookDeliveries)
+	authRoutes.POST("/webhook_deliveries/:id/replay", s.replayWebhookDelivery)
 
 	s.router = router
+	return nil
 }
 
+// Start serves HTTP until Shutdown is called, after which it returns nil.
 func (s *Server) Start(add string) error {
-	return s.router.Run(add)
+	s.httpServer = &http.Server{
+		Addr:    add,
+		Handler: s.router,
+	}
+
+	err := s.httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Shutdown(ctx)
 }
 
 func errorResponse(err error) gin.H {
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errStepUpRequired = errors.New("this transfer needs a recent second factor, call /users/step_up first")
+
+type stepUpRequest struct {
+	Password string `json:"password" binding:"required_without=Code,omitempty,min=6"`
+	Code     string `json:"code" binding:"required_without=Password"`
+}
+
+type stepUpResponse struct {
+	AccessToken     string    `json:"access_token"`
+	StepUpExpiresAt time.Time `json:"step_up_expires_at"`
+}
+
+// stepUp re-checks a factor and swaps the caller's token for one carrying a
+// step-up proof. Users with TOTP enabled must give a code; everyone else
+// re-enters their password. Failures count towards the login lockout.
+func (server *Server) stepUp(c *gin.Context) {
+	var req stepUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.allowLogin(c, "user:"+payload.Username, server.loginUsernameLimiter) {
+		return
+	}
+
+	user, err := server.store.GetUser(c, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.LockedUntil.After(time.Now()) {
+		server.mfaFailed(c)
+		return
+	}
+
+	var ok bool
+	switch {
+	case user.TotpEnabled && req.Code != "":
+		ok, err = server.checkSecondFactor(c, user, req.Code)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	case !user.TotpEnabled && req.Password != "":
+		ok = util.CompareHashAndPassword(user.HashedPassword, req.Password) == nil
+	}
+	if !ok {
+		if err := server.recordFailedLogin(c, user.Username, db.AuditStepUpFailed); err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		server.mfaFailed(c)
+		return
+	}
+
+	accessToken, err := server.tokenMaker.CreateStepUpToken(user.Username, server.config.TokenDuration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, stepUpResponse{
+		AccessToken:     accessToken,
+		StepUpExpiresAt: time.Now().Add(server.config.StepUpMaxAge),
+	})
+}
+
+// checkStepUp lets a transfer through when it is at or under its
+// currency's threshold, or when the token carries a fresh step-up proof.
+// Currencies without a rule never need one.
+func (server *Server) checkStepUp(c *gin.Context, payload *token.Payload, currency string, amount int64) bool {
+	rule, err := server.store.GetStepUpRule(c, currency)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return true
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if amount <= rule.Threshold || payload.SteppedUpWithin(server.config.StepUpMaxAge) {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":            errStepUpRequired.Error(),
+		"step_up_required": true,
+		"threshold":        rule.Threshold,
+	})
+	return false
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/mfa"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepUpAPI(t *testing.T) {
+	user, password := createRandomUser(t)
+	box, err := mfa.NewSecretBox(util.RandomString(32))
+	require.NoError(t, err)
+
+	enrollment, err := mfa.NewEnrollment(user.Username)
+	require.NoError(t, err)
+	totpUser := user
+	totpUser.TotpEnabled = true
+	totpUser.TotpSecret, err = box.Seal(enrollment.Secret)
+	require.NoError(t, err)
+
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+
+	requireSteppedUp := func(t *testing.T, tokenMaker token.TokenMaker, recorder *httptest.ResponseRecorder) {
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var res stepUpResponse
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(5*time.Minute), res.StepUpExpiresAt, time.Second)
+
+		payload, err := tokenMaker.VerifyToken(res.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, user.Username, payload.Username)
+		require.True(t, payload.SteppedUpWithin(time.Minute))
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, tokenMaker token.TokenMaker, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Password",
+			body: gin.H{"password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: requireSteppedUp,
+		},
+		{
+			name: "WrongPassword",
+			body: gin.H{"password": "incorrect"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().RecordFailedLogin(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(int32(1), nil)
+			},
+			checkResponse: func(t *testing.T, tokenMaker token.TokenMaker, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "TOTPCode",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(totpUser, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(totpUser, nil)
+			},
+			checkResponse: requireSteppedUp,
+		},
+		{
+			name: "TOTPUserWithPassword",
+			body: gin.H{"password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(totpUser, nil)
+				store.EXPECT().RecordFailedLogin(gomock.Any(), gomock.Any()).Times(1).Return(int32(1), nil)
+			},
+			checkResponse: func(t *testing.T, tokenMaker token.TokenMaker, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Locked",
+			body: gin.H{"password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				locked := user
+				locked.LockedUntil = time.Now().Add(time.Minute)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(locked, nil)
+			},
+			checkResponse: func(t *testing.T, tokenMaker token.TokenMaker, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NoFactor",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, tokenMaker token.TokenMaker, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UserNotFound",
+			body: gin.H{"password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, tokenMaker token.TokenMaker, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+			allowAudit(store)
+
+			server := newTestServer(t, store)
+			server.mfaBox = box
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/step_up", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server.tokenMaker, recorder)
+		})
+	}
+}
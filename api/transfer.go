@@ -1,21 +1,34 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 	db "simplebank/db/sqlc"
 	"simplebank/token"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
+// recipient names the destination of a transfer in any of the ways a user
+// may know it. Exactly one of the fields must be set.
+type recipient struct {
+	ToAccountID     int64  `json:"to_account_id" binding:"omitempty,min=1"`
+	ToAccountNumber string `json:"to_account_number" binding:"omitempty,account_number"`
+	ToUsername      string `json:"to_username" binding:"omitempty,alphanum"`
+	ToPayee         string `json:"to_payee" binding:"omitempty,max=64"`
+}
+
+var errRecipient = errors.New("exactly one of to_account_id, to_account_number, to_username or to_payee is required")
+
 type transferReq struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+	recipient
+	Amount    int64  `json:"amount" binding:"required,gt=0"`
+	Currency  string `json:"currency" binding:"required,currency"`
+	Reference string `json:"reference" binding:"max=140"`
 }
 
 func (server *Server) createTransfer(c *gin.Context) {
@@ -38,30 +51,187 @@ func (server *Server) createTransfer(c *gin.Context) {
 		return
 	}
 
-	_, valid = server.validAccount(c, req.ToAccountID, req.Currency)
+	toAccount, valid := server.recipientAccount(c, payload, req.recipient, req.Currency)
 	if !valid {
 		return
 	}
 
-	arg := db.CreateTransferParams{
+	if !server.checkStepUp(c, payload, req.Currency, req.Amount) {
+		return
+	}
+
+	quote, ok := server.quoteFee(c, req.Currency, req.Amount)
+	if !ok {
+		return
+	}
+
+	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
+		ToAccountID:   toAccount.ID,
 		Amount:        req.Amount,
+		Quote:         quote,
+		Reference:     req.Reference,
+		Audit:         auditContext(c),
 	}
 
 	result, err := server.store.TransferTx(c, arg)
 	if err != nil {
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":     limitErr.Error(),
+				"limit":     limitErr.Limit,
+				"allowance": limitErr.Allowance,
+			})
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			c.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, newSentTransferResponse(result))
+}
+
+// recipientResponse is what a sender learns about the account they paid:
+// enough to recognise it, but neither its id nor its balance.
+type recipientResponse struct {
+	Number   string `json:"number"`
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+// transferResponse is a transfer as its sender sees it, without the id of
+// the account it went to.
+type transferResponse struct {
+	ID            int64     `json:"id"`
+	FromAccountID int64     `json:"from_account_id"`
+	Amount        int64     `json:"amount"`
+	Fee           int64     `json:"fee"`
+	Reference     string    `json:"reference"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type sentTransferResponse struct {
+	Transfer    transferResponse     `json:"transfer"`
+	Recipient   recipientResponse    `json:"recipient"`
+	FromAccount db.Account           `json:"from_account"`
+	FromEntry   db.Entry             `json:"from_entry"`
+	FeeEntry    *db.Entry            `json:"fee_entry,omitempty"`
+	Allowance   db.TransferAllowance `json:"allowance"`
+}
+
+func newSentTransferResponse(result db.TransferTxResult) sentTransferResponse {
+	return sentTransferResponse{
+		Transfer: transferResponse{
+			ID:            result.Transfer.ID,
+			FromAccountID: result.Transfer.FromAccountID,
+			Amount:        result.Transfer.Amount,
+			Fee:           result.Transfer.Fee,
+			Reference:     result.Transfer.Reference,
+			CreatedAt:     result.Transfer.CreatedAt,
+		},
+		Recipient: recipientResponse{
+			Number:   result.ToAccount.Number,
+			Owner:    result.ToAccount.Owner,
+			Currency: result.ToAccount.Currency,
+		},
+		FromAccount: result.FromAccount,
+		FromEntry:   result.FromEntry,
+		FeeEntry:    result.FeeEntry,
+		Allowance:   result.Allowance,
+	}
+}
+
+type transferFeeReq struct {
+	Amount   int64  `form:"amount" binding:"required,gt=0"`
+	Currency string `form:"currency" binding:"required,currency"`
+}
+
+func (server *Server) quoteTransferFee(c *gin.Context) {
+	var req transferFeeReq
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	quote, ok := server.quoteFee(c, req.Currency, req.Amount)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// quoteFee prices a transfer. A fee rule crediting an account in another
+// currency is the bank's mistake, so it is logged for the operators.
+func (server *Server) quoteFee(c *gin.Context, currency string, amount int64) (db.FeeQuote, bool) {
+	quote, err := server.store.QuoteFee(c, currency, amount)
+	if err != nil {
+		if errors.Is(err, db.ErrFeeAccountCurrencyMismatch) {
+			log.Error().Err(err).Str("currency", currency).Msg("fee rule is misconfigured")
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return quote, false
+	}
+	return quote, true
+}
+
+// recipientAccount looks up the account r names. Usernames are resolved to
+// the user's account in currency, payees to the caller's saved payee of that
+// nickname.
+func (server *Server) recipientAccount(c *gin.Context, payload *token.Payload, r recipient, currency string) (db.Account, bool) {
+	set := 0
+	for _, ok := range []bool{r.ToAccountID != 0, r.ToAccountNumber != "", r.ToUsername != "", r.ToPayee != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		c.JSON(http.StatusBadRequest, errorResponse(errRecipient))
+		return db.Account{}, false
+	}
+
+	var acc db.Account
+	var err error
+	var name string
+	switch {
+	case r.ToAccountID != 0:
+		return server.validAccount(c, r.ToAccountID, currency)
+	case r.ToAccountNumber != "":
+		name = r.ToAccountNumber
+		acc, err = server.store.GetAccountByNumber(c, r.ToAccountNumber)
+	case r.ToUsername != "":
+		name = r.ToUsername
+		acc, err = server.store.GetAccountByOwnerAndCurrency(c, db.GetAccountByOwnerAndCurrencyParams{
+			Owner:    r.ToUsername,
+			Currency: currency,
+		})
+	default:
+		name = r.ToPayee
+		acc, err = server.store.GetPayeeAccount(c, db.GetPayeeAccountParams{
+			Owner:    payload.Username,
+			Nickname: r.ToPayee,
+		})
+	}
+	return checkAccount(c, name, acc, err, currency)
 }
 
 func (server *Server) validAccount(c *gin.Context, accountID int64, currency string) (db.Account, bool) {
 	acc, err := server.store.GetAccount(c, accountID)
+	return checkAccount(c, fmt.Sprint(accountID), acc, err, currency)
+}
+
+// checkAccount turns the result of an account lookup into a response when
+// it failed or the account is in another currency. name is how the caller
+// referred to the account.
+func checkAccount(c *gin.Context, name string, acc db.Account, err error, currency string) (db.Account, bool) {
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, errorResponse(err))
 			return acc, false
 		}
@@ -70,7 +240,7 @@ func (server *Server) validAccount(c *gin.Context, accountID int64, currency str
 	}
 
 	if acc.Currency != currency {
-		err = fmt.Errorf("account {%v} currency mismatch: %v vs %v", accountID, acc.Currency, currency)
+		err = fmt.Errorf("account {%v} currency mismatch: %v vs %v", name, acc.Currency, currency)
 		c.JSON(http.StatusBadRequest, errorResponse(err))
 		return acc, false
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	mockdb "simplebank/db/mock"
@@ -13,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
@@ -25,15 +27,25 @@ func TestCreateTransferAPI(t *testing.T) {
 	acc2 := createRandomAccount(user2.Username)
 	acc2.Currency = util.NGN
 	transfer := createRandomTransfer()
-	arg := db.CreateTransferParams{
+	toAcc := acc
+	toAcc.ID = transfer.ToAccountID
+	quote := db.FeeQuote{
+		Currency: util.USD,
+		Amount:   transfer.Amount,
+		Fee:      10,
+		Total:    transfer.Amount + 10,
+	}
+	arg := db.TransferTxParams{
 		FromAccountID: transfer.FromAccountID,
 		ToAccountID:   transfer.ToAccountID,
 		Amount:        transfer.Amount,
+		Quote:         quote,
+		Audit:         testAudit(user1.Username),
 	}
 
 	testSuite := []struct {
 		name          string
-		arg           db.CreateTransferParams
+		arg           db.TransferTxParams
 		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(w *httptest.ResponseRecorder)
@@ -52,7 +64,109 @@ func TestCreateTransferAPI(t *testing.T) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(arg.ToAccountID)).
 					Times(1).
+					Return(toAcc, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Eq(util.USD), gomock.Eq(arg.Amount)).
+					Times(1).
+					Return(quote, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "StepUpRequired",
+			arg:  arg,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.FromAccountID)).
+					Times(1).
 					Return(acc, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.ToAccountID)).
+					Times(1).
+					Return(toAcc, nil)
+				store.EXPECT().
+					GetStepUpRule(gomock.Any(), gomock.Eq(util.USD)).
+					Times(1).
+					Return(db.StepUpRule{Currency: util.USD, Threshold: arg.Amount - 1}, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+
+				var body gin.H
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				require.NoError(t, err)
+				require.Equal(t, true, body["step_up_required"])
+			},
+		},
+		{
+			name: "SteppedUp",
+			arg:  arg,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				stepUpToken, err := tokenMaker.CreateStepUpToken(user1.Username, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+stepUpToken)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.FromAccountID)).
+					Times(1).
+					Return(acc, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.ToAccountID)).
+					Times(1).
+					Return(toAcc, nil)
+				store.EXPECT().
+					GetStepUpRule(gomock.Any(), gomock.Eq(util.USD)).
+					Times(1).
+					Return(db.StepUpRule{Currency: util.USD, Threshold: arg.Amount - 1}, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(quote, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "AtStepUpThreshold",
+			arg:  arg,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.FromAccountID)).
+					Times(1).
+					Return(acc, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.ToAccountID)).
+					Times(1).
+					Return(toAcc, nil)
+				store.EXPECT().
+					GetStepUpRule(gomock.Any(), gomock.Eq(util.USD)).
+					Times(1).
+					Return(db.StepUpRule{Currency: util.USD, Threshold: arg.Amount}, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(quote, nil)
 				store.EXPECT().
 					TransferTx(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
@@ -64,7 +178,7 @@ func TestCreateTransferAPI(t *testing.T) {
 		},
 		{
 			name: "StatusBadRequest",
-			arg:  db.CreateTransferParams{},
+			arg:  db.TransferTxParams{},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
 			},
@@ -107,7 +221,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(arg.FromAccountID)).
 					Times(1).
-					Return(acc, sql.ErrNoRows)
+					Return(acc, db.ErrRecordNotFound)
 			},
 			checkResponse: func(w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, w.Code)
@@ -127,7 +241,11 @@ func TestCreateTransferAPI(t *testing.T) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(arg.ToAccountID)).
 					Times(1).
-					Return(acc, nil)
+					Return(toAcc, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Eq(util.USD), gomock.Eq(arg.Amount)).
+					Times(1).
+					Return(quote, nil)
 				store.EXPECT().
 					TransferTx(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
@@ -137,6 +255,123 @@ func TestCreateTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, w.Code)
 			},
 		},
+		{
+			name: "StatusForbidden Transfer Limit",
+			arg:  arg,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.FromAccountID)).
+					Times(1).
+					Return(acc, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.ToAccountID)).
+					Times(1).
+					Return(toAcc, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Eq(util.USD), gomock.Eq(arg.Amount)).
+					Times(1).
+					Return(quote, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.TransferLimitError{Limit: db.LimitDailyAmount})
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+
+				var body struct {
+					Limit string `json:"limit"`
+				}
+				err := json.Unmarshal(w.Body.Bytes(), &body)
+				require.NoError(t, err)
+				require.Equal(t, db.LimitDailyAmount, body.Limit)
+			},
+		},
+		{
+			name: "StatusForbidden Insufficient Funds",
+			arg:  arg,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.FromAccountID)).
+					Times(1).
+					Return(acc, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.ToAccountID)).
+					Times(1).
+					Return(toAcc, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Eq(util.USD), gomock.Eq(arg.Amount)).
+					Times(1).
+					Return(quote, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+			},
+		},
+		{
+			name: "StatusInternalServerError Quote Fee",
+			arg:  arg,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.FromAccountID)).
+					Times(1).
+					Return(acc, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.ToAccountID)).
+					Times(1).
+					Return(toAcc, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.FeeQuote{}, sql.ErrConnDone)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+		{
+			name: "StatusInternalServerError Fee Rule Misconfigured",
+			arg:  arg,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.FromAccountID)).
+					Times(1).
+					Return(acc, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(arg.ToAccountID)).
+					Times(1).
+					Return(toAcc, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.FeeQuote{}, db.ErrFeeAccountCurrencyMismatch)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
 		{
 			name: "StatusInternalServerError Invalid Account 1",
 			arg:  arg,
@@ -183,11 +418,13 @@ func TestCreateTransferAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			server := newTestServer(t, store)
 
+			allowAuth(store)
 			tc.buildStubs(store)
+			noStepUpRule(store)
 
 			transferReq := transferReq{
 				FromAccountID: tc.arg.FromAccountID,
-				ToAccountID:   tc.arg.ToAccountID,
+				recipient:     recipient{ToAccountID: tc.arg.ToAccountID},
 				Amount:        tc.arg.Amount,
 				Currency:      util.USD,
 			}
@@ -196,6 +433,7 @@ func TestCreateTransferAPI(t *testing.T) {
 
 			w := httptest.NewRecorder()
 			req, _ := http.NewRequest("POST", "/transfers", bytes.NewBuffer(reqVal))
+			req.Header.Set(requestIDHeaderKey, testRequestID)
 
 			tc.setupAuth(t, req, server.tokenMaker)
 			server.router.ServeHTTP(w, req)
@@ -207,6 +445,98 @@ func TestCreateTransferAPI(t *testing.T) {
 
 }
 
+// noStepUpRule leaves every currency without a step-up threshold unless
+// the test case set one up first.
+func noStepUpRule(store *mockdb.MockStore) {
+	store.EXPECT().
+		GetStepUpRule(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.StepUpRule{}, db.ErrRecordNotFound)
+}
+
+func TestQuoteTransferFeeAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	amount := int64(util.RandomAmount())
+	quote := db.FeeQuote{
+		Currency: util.USD,
+		Amount:   amount,
+		Fee:      25,
+		Total:    amount + 25,
+	}
+
+	testSuite := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(w *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "StatusOK",
+			query: fmt.Sprintf("amount=%d&currency=%s", amount, util.USD),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Eq(util.USD), gomock.Eq(amount)).
+					Times(1).
+					Return(quote, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var got db.FeeQuote
+				err := json.Unmarshal(w.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, quote, got)
+			},
+		},
+		{
+			name:  "StatusBadRequest",
+			query: fmt.Sprintf("amount=%d&currency=%s", amount, "EUR"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name:  "StatusInternalServerError",
+			query: fmt.Sprintf("amount=%d&currency=%s", amount, util.USD),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.FeeQuote{}, sql.ErrConnDone)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range testSuite {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/transfers/fee?"+tc.query, nil)
+
+			addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(w, req)
+
+			tc.checkResponse(w)
+		})
+	}
+}
+
 func createRandomTransfer() db.Transfer {
 	return db.Transfer{
 		ID:            int64(util.RandomInt(1, 1000)),
@@ -215,3 +545,185 @@ func createRandomTransfer() db.Transfer {
 		Amount:        int64(util.RandomAmount()),
 	}
 }
+
+func TestCreateTransferRecipientAPI(t *testing.T) {
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+	from := createRandomAccount(user1.Username)
+	from.Currency = util.USD
+	to := createRandomAccount(user2.Username)
+	to.Currency = util.USD
+	quote := db.FeeQuote{Currency: util.USD, Amount: 10, Total: 10}
+
+	testCases := []struct {
+		name          string
+		recipient     gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(w *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "AccountNumber",
+			recipient: gin.H{"to_account_number": to.Number},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByNumber(gomock.Any(), gomock.Eq(to.Number)).
+					Times(1).
+					Return(to, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:      "Username",
+			recipient: gin.H{"to_username": user2.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    user2.Username,
+						Currency: util.USD,
+					})).
+					Times(1).
+					Return(to, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:      "Payee",
+			recipient: gin.H{"to_payee": "landlord"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPayeeAccount(gomock.Any(), gomock.Eq(db.GetPayeeAccountParams{
+						Owner:    user1.Username,
+						Nickname: "landlord",
+					})).
+					Times(1).
+					Return(to, nil)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:      "UnknownPayee",
+			recipient: gin.H{"to_payee": "landlord"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPayeeAccount(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			name:      "MistypedAccountNumber",
+			recipient: gin.H{"to_account_number": to.Number[:11] + string('0'+(to.Number[11]-'0'+1)%10)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByNumber(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name:       "NoRecipient",
+			recipient:  gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name:       "TwoRecipients",
+			recipient:  gin.H{"to_account_id": to.ID, "to_username": user2.Username},
+			buildStubs: func(store *mockdb.MockStore) {},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			noStepUpRule(store)
+			store.EXPECT().
+				GetAccount(gomock.Any(), gomock.Eq(from.ID)).
+				AnyTimes().
+				Return(from, nil)
+			store.EXPECT().
+				QuoteFee(gomock.Any(), gomock.Any(), gomock.Any()).
+				AnyTimes().
+				Return(quote, nil)
+			store.EXPECT().
+				TransferTx(gomock.Any(), gomock.Any()).
+				AnyTimes().
+				DoAndReturn(func(_ interface{}, arg db.TransferTxParams) (db.TransferTxResult, error) {
+					require.Equal(t, to.ID, arg.ToAccountID)
+					return db.TransferTxResult{
+						Transfer:    db.Transfer{ID: 1, FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10},
+						FromAccount: from,
+						ToAccount:   to,
+						FromEntry:   db.Entry{AccountID: from.ID, Amount: -10},
+						ToEntry:     db.Entry{AccountID: to.ID, Amount: 10},
+					}, nil
+				})
+			tc.buildStubs(store)
+
+			body := gin.H{
+				"from_account_id": from.ID,
+				"amount":          10,
+				"currency":        util.USD,
+			}
+			for k, v := range tc.recipient {
+				body[k] = v
+			}
+			reqVal, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/transfers", bytes.NewBuffer(reqVal))
+
+			addAuthorization(t, req, server.tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			server.router.ServeHTTP(w, req)
+
+			tc.checkResponse(w)
+			if w.Code == http.StatusOK {
+				requireRecipientHidden(t, w, to)
+			}
+		})
+	}
+}
+
+// requireRecipientHidden checks that a sender sees the account they paid
+// by its number only.
+func requireRecipientHidden(t *testing.T, w *httptest.ResponseRecorder, to db.Account) {
+	var res map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.NotContains(t, res, "to_account")
+	require.NotContains(t, res, "to_account_id")
+	require.NotContains(t, res, "to_entry")
+
+	var transfer map[string]interface{}
+	require.NoError(t, json.Unmarshal(res["transfer"], &transfer))
+	require.NotContains(t, transfer, "to_account_id")
+
+	var recipient map[string]interface{}
+	require.NoError(t, json.Unmarshal(res["recipient"], &recipient))
+	require.Equal(t, map[string]interface{}{
+		"number":   to.Number,
+		"owner":    to.Owner,
+		"currency": to.Currency,
+	}, recipient)
+}
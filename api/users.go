@@ -1,19 +1,27 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
+	"math"
 	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/metrics"
+	"simplebank/ratelimit"
+	"simplebank/token"
 	"simplebank/util"
+	"simplebank/worker"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
 )
 
 type createUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 	Fullname string `json:"fullname" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 }
@@ -22,6 +30,7 @@ type userResponse struct {
 	Username          string    `json:"username"`
 	Fullname          string    `json:"fullname"`
 	Email             string    `json:"email"`
+	MFAEnabled        bool      `json:"mfa_enabled"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -31,6 +40,7 @@ func newUserResponse(user db.User) userResponse {
 		Username:          user.Username,
 		Fullname:          user.Fullname,
 		Email:             user.Email,
+		MFAEnabled:        user.TotpEnabled,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
@@ -44,37 +54,414 @@ func (server *Server) createUser(c *gin.Context) {
 		return
 	}
 
-	hashedPassord, err := util.HashPassword(req.Password)
+	if err := server.passwordPolicy.Validate(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassord, err := server.passwordHasher.Hash(req.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	arg := db.CreateUserParams{
-		Username:       req.Username,
-		HashedPassword: hashedPassord,
-		Email:          req.Email,
-		Fullname:       req.Fullname,
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.Username,
+			HashedPassword: hashedPassord,
+			Email:          req.Email,
+			Fullname:       req.Fullname,
+		},
+		Audit: auditContextFor(c, req.Username),
+		AfterCreate: func(q db.Querier, user db.User) error {
+			payload := &worker.PayloadSendVerifyEmail{Username: user.Username}
+			return server.distributor.DistributeTaskSendVerifyEmail(
+				c, q, payload,
+				worker.Queue(worker.QueueCritical),
+				worker.MaxAttempts(10),
+			)
+		},
 	}
 
-	user, err := server.store.CreateUser(c, arg)
+	result, err := server.store.CreateUserTx(c, arg)
 	if err != nil {
-		if pgErr, ok := err.(*pq.Error); ok {
-			switch pgErr.Code.Name() {
-			case "unique_violation":
-				c.JSON(http.StatusForbidden, errorResponse(err))
-				return
+		if db.ErrorCode(err) == db.UniqueViolation {
+			c.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	res := newUserResponse(result.User)
+
+	c.JSON(http.StatusOK, res)
+}
+
+type updateUserURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type updateUserRequest struct {
+	Fullname        *string `json:"fullname" binding:"omitempty,min=1"`
+	Email           *string `json:"email" binding:"omitempty,email"`
+	Password        *string `json:"password"`
+	CurrentPassword *string `json:"current_password"`
+}
+
+// updateUserResponse carries a new access token after a password change,
+// which revokes every token issued before it, the caller's included.
+type updateUserResponse struct {
+	userResponse
+	AccessToken string `json:"access_token,omitempty"`
+}
+
+var (
+	errCurrentPasswordRequired = errors.New("changing the password or email needs current_password or a recent step-up")
+	errInvalidCurrentPassword  = errors.New("current password is incorrect")
+)
+
+func (server *Server) updateUser(c *gin.Context) {
+	var uri updateUserURI
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if payload.Username != uri.Username {
+		err := errors.New("cannot update another user's profile")
+		c.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	if req.Password != nil {
+		if err := server.passwordPolicy.Validate(*req.Password); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+	// A new email address can reset the password, so it needs the same
+	// proof as changing the password directly.
+	if req.Password != nil || req.Email != nil {
+		if !server.checkCurrentPassword(c, payload, req.CurrentPassword) {
+			return
+		}
+	}
+
+	arg := db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{Username: uri.Username},
+		Audit:            auditContext(c),
+		AfterUpdate: func(q db.Querier, user db.User) error {
+			// Only a new address loses its verified flag.
+			if req.Email == nil || user.IsEmailVerified {
+				return nil
 			}
+			payload := &worker.PayloadSendVerifyEmail{Username: user.Username}
+			return server.distributor.DistributeTaskSendVerifyEmail(
+				c, q, payload,
+				worker.Queue(worker.QueueCritical),
+				worker.MaxAttempts(10),
+			)
+		},
+	}
+
+	if req.Fullname != nil {
+		arg.Fullname = pgtype.Text{String: *req.Fullname, Valid: true}
+	}
+	if req.Email != nil {
+		arg.Email = pgtype.Text{String: *req.Email, Valid: true}
+	}
+	if req.Password != nil {
+		hashedPassword, err := server.passwordHasher.Hash(*req.Password)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		arg.HashedPassword = pgtype.Text{String: hashedPassword, Valid: true}
+		arg.PasswordChangedAt = pgtype.Timestamp{Time: time.Now().UTC(), Valid: true}
+	}
+
+	result, err := server.store.UpdateUserTx(c, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if db.ErrorCode(err) == db.UniqueViolation {
+			c.JSON(http.StatusForbidden, errorResponse(err))
+			return
 		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	res := newUserResponse(user)
+	res := updateUserResponse{userResponse: newUserResponse(result.User)}
+	if arg.PasswordChangedAt.Valid {
+		res.AccessToken, err = server.tokenMaker.CreateToken(result.User.Username, server.config.TokenDuration)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
 
 	c.JSON(http.StatusOK, res)
 }
 
+// checkCurrentPassword lets a password or email change through when the token
+// carries a fresh step-up proof or the current password is given. Wrong
+// passwords count towards the login lockout like a failed step-up.
+func (server *Server) checkCurrentPassword(c *gin.Context, payload *token.Payload, currentPassword *string) bool {
+	if payload.SteppedUpWithin(server.config.StepUpMaxAge) {
+		return true
+	}
+	if currentPassword == nil || *currentPassword == "" {
+		c.JSON(http.StatusForbidden, errorResponse(errCurrentPasswordRequired))
+		return false
+	}
+	if !server.allowLogin(c, "user:"+payload.Username, server.loginUsernameLimiter) {
+		return false
+	}
+
+	user, err := server.store.GetUser(c, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if user.LockedUntil.After(time.Now()) {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCurrentPassword))
+		return false
+	}
+	if util.CompareHashAndPassword(user.HashedPassword, *currentPassword) != nil {
+		if err := server.recordFailedLogin(c, user.Username, db.AuditStepUpFailed); err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCurrentPassword))
+		return false
+	}
+	return true
+}
+
+type verifyEmailRequest struct {
+	EmailID    int64  `form:"email_id" binding:"required,min=1"`
+	SecretCode string `form:"secret_code" binding:"required"`
+}
+
+type verifyEmailResponse struct {
+	IsVerified bool `json:"is_verified"`
+}
+
+var errInvalidVerifyEmail = errors.New("invalid or expired verification code")
+
+func (server *Server) verifyEmail(c *gin.Context) {
+	var req verifyEmailRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.VerifyEmailTx(c, db.VerifyEmailTxParams{
+		EmailID:    req.EmailID,
+		SecretCode: req.SecretCode,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidVerifyEmail))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, verifyEmailResponse{IsVerified: result.User.IsEmailVerified})
+}
+
+var errEmailAlreadyVerified = errors.New("email address is already verified")
+
+func (server *Server) resendVerifyEmail(c *gin.Context) {
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	ok, retryAfter := server.verifyEmailLimiter.Allow("user:" + payload.Username)
+	if !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, errorResponse(errors.New("too many verification emails requested")))
+		return
+	}
+
+	user, err := server.store.GetUser(c, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsEmailVerified {
+		c.JSON(http.StatusConflict, errorResponse(errEmailAlreadyVerified))
+		return
+	}
+
+	err = server.distributor.DistributeTaskSendVerifyEmail(
+		c, nil, &worker.PayloadSendVerifyEmail{Username: user.Username},
+		worker.Queue(worker.QueueCritical),
+		worker.MaxAttempts(10),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+func (server *Server) forgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Every request mails someone, so limit both who asks and whose inbox
+	// it lands in.
+	if !allowResetRequest(c, "ip:"+c.ClientIP(), server.resetIPLimiter) ||
+		!allowResetRequest(c, "email:"+strings.ToLower(req.Email), server.resetEmailLimiter) {
+		return
+	}
+
+	user, err := server.store.GetUserByEmail(c, req.Email)
+	if err != nil {
+		// Answer the same for unknown addresses so that the endpoint does
+		// not reveal who has an account.
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.Status(http.StatusAccepted)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	payload := &worker.PayloadSendPasswordReset{Username: user.Username}
+	err = server.distributor.DistributeTaskSendPasswordReset(
+		c, nil, payload,
+		worker.Queue(worker.QueueCritical),
+		worker.MaxAttempts(10),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func allowResetRequest(c *gin.Context, key string, limiter ratelimit.Limiter) bool {
+	ok, retryAfter := limiter.Allow(key)
+	if !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, errorResponse(errors.New("too many password resets requested")))
+	}
+	return ok
+}
+
+type checkResetPasswordRequest struct {
+	Token string `form:"token" binding:"required"`
+}
+
+type checkResetPasswordResponse struct {
+	Username  string    `json:"username"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// checkResetPassword is where the emailed reset link lands. It tells the
+// client whether the token can still be used before it asks for a new
+// password to POST back with it.
+func (server *Server) checkResetPassword(c *gin.Context) {
+	var req checkResetPasswordRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	reset, err := server.store.GetPasswordReset(c, util.HashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, checkResetPasswordResponse{
+		Username:  reset.Username,
+		ExpiredAt: reset.ExpiredAt,
+	})
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+var errInvalidResetToken = errors.New("invalid or expired reset token")
+
+func (server *Server) resetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := server.passwordPolicy.Validate(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassword, err := server.passwordHasher.Hash(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ResetPasswordTx(c, db.ResetPasswordTxParams{
+		TokenHash:      util.HashToken(req.Token),
+		HashedPassword: hashedPassword,
+		Audit:          auditContext(c),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserResponse(result.User))
+}
+
 type loginUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
 	Password string `json:"password" binding:"required,min=6"`
@@ -93,24 +480,72 @@ func (server *Server) loginUser(c *gin.Context) {
 		return
 	}
 
+	if !server.allowLogin(c, "ip:"+c.ClientIP(), server.loginIPLimiter) ||
+		!server.allowLogin(c, "user:"+req.Username, server.loginUsernameLimiter) {
+		return
+	}
+
 	user, err := server.store.GetUser(c, req.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, errorResponse(err))
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// Spend as long as a real check so that response times do not
+			// reveal which usernames exist.
+			util.CompareHashAndPassword(server.dummyPasswordHash(), req.Password)
+			server.loginFailed(c)
 			return
 		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	if user.LockedUntil.After(time.Now()) {
+		util.CompareHashAndPassword(server.dummyPasswordHash(), req.Password)
+		server.loginFailed(c)
+		return
+	}
+
 	err = util.CompareHashAndPassword(user.HashedPassword, req.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		if err := server.recordFailedLogin(c, user.Username, db.AuditLoginFailed); err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		server.loginFailed(c)
+		return
+	}
+
+	if server.passwordHasher.NeedsRehash(user.HashedPassword) {
+		server.upgradePasswordHash(c, user.Username, req.Password)
+	}
+
+	if user.TotpEnabled {
+		server.startMFAChallenge(c, user)
+		return
+	}
+
+	server.completeLogin(c, user)
+}
+
+// completeLogin issues the access token once every factor has been checked.
+func (server *Server) completeLogin(c *gin.Context, user db.User) {
+	if user.FailedLoginAttempts > 0 {
+		if err := server.store.ResetFailedLogins(c, user.Username); err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	_, err := server.store.AppendAuditTx(c, auditContextFor(c, user.Username), db.AuditEvent{
+		Action:   db.AuditLoginSucceeded,
+		Resource: db.UserResource(user.Username),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
 	accessToken, err := server.tokenMaker.CreateToken(
-		req.Username,
+		user.Username,
 		server.config.TokenDuration,
 	)
 	if err != nil {
@@ -118,6 +553,8 @@ func (server *Server) loginUser(c *gin.Context) {
 		return
 	}
 
+	metrics.Logins.WithLabelValues(metrics.LoginSucceeded).Inc()
+
 	res := loginUserResponse{
 		AccessToken: accessToken,
 		User:        newUserResponse(user),
@@ -125,3 +562,83 @@ func (server *Server) loginUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, res)
 }
+
+var errInvalidCredentials = errors.New("invalid username or password")
+
+// dummyPasswordHash is compared against when there is no usable user, so
+// that every failed login costs the same.
+func (server *Server) dummyPasswordHash() string {
+	server.dummyHashOnce.Do(func() {
+		server.dummyHash, _ = server.passwordHasher.Hash(util.RandomString(16))
+	})
+	return server.dummyHash
+}
+
+// upgradePasswordHash re-hashes a just verified password with the current
+// parameters. The password itself is unchanged, so PasswordChangedAt is
+// left alone and existing tokens stay valid. Failures only cost the upgrade.
+func (server *Server) upgradePasswordHash(c *gin.Context, username, password string) {
+	hashedPassword, err := server.passwordHasher.Hash(password)
+	if err == nil {
+		_, err = server.store.UpdateUser(c, db.UpdateUserParams{
+			Username:       username,
+			HashedPassword: pgtype.Text{String: hashedPassword, Valid: true},
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Str("username", username).Msg("cannot upgrade password hash")
+	}
+}
+
+func (server *Server) allowLogin(c *gin.Context, key string, limiter ratelimit.Limiter) bool {
+	ok, retryAfter := limiter.Allow(key)
+	if !ok {
+		metrics.Logins.WithLabelValues(metrics.LoginRateLimited).Inc()
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, errorResponse(errors.New("too many login attempts")))
+	}
+	return ok
+}
+
+// loginFailed answers every rejected login the same way, whether the user
+// is unknown, locked or gave a wrong password.
+func (server *Server) loginFailed(c *gin.Context) {
+	metrics.Logins.WithLabelValues(metrics.LoginFailed).Inc()
+	c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+}
+
+func (server *Server) recordFailedLogin(c *gin.Context, username, action string) error {
+	failures, err := server.store.RecordFailedLogin(c, username)
+	if err != nil {
+		return err
+	}
+
+	lockout := util.LockoutDuration(
+		failures,
+		server.config.LoginLockoutThreshold,
+		server.config.LoginLockoutDuration,
+		server.config.LoginLockoutMaxDuration,
+	)
+
+	var lockedUntil time.Time
+	if lockout > 0 {
+		lockedUntil = time.Now().Add(lockout)
+		err = server.store.LockUser(c, db.LockUserParams{
+			Username:    username,
+			LockedUntil: lockedUntil,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = server.store.AppendAuditTx(c, auditContextFor(c, username), db.AuditEvent{
+		Action:   action,
+		Resource: db.UserResource(username),
+		After: gin.H{
+			"failed_login_attempts": failures,
+			"locked_until":          lockedUntil,
+		},
+	})
+	return err
+}
@@ -2,21 +2,33 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"regexp"
 	mockdb "simplebank/db/mock"
 	db "simplebank/db/sqlc"
+	"simplebank/mail"
+	"simplebank/ratelimit"
+	"simplebank/token"
 	"simplebank/util"
+	"simplebank/worker"
+	mockwk "simplebank/worker/mock"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
-	"github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type ArgMatcher struct {
@@ -25,10 +37,11 @@ type ArgMatcher struct {
 }
 
 func (a ArgMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreateUserParams)
-	if !ok {
+	txArg, ok := x.(db.CreateUserTxParams)
+	if !ok || txArg.AfterCreate == nil {
 		return false
 	}
+	arg := txArg.CreateUserParams
 
 	err := util.CompareHashAndPassword(arg.HashedPassword, a.password)
 	if err != nil {
@@ -49,12 +62,14 @@ func EqArgMatcher(arg db.CreateUserParams, password string) gomock.Matcher {
 
 func TestCreateUserAPI(t *testing.T) {
 	user, password := createRandomUser(t)
+	user.IsEmailVerified = false
 
 	testSuite := []struct {
-		name          string
-		arg           gin.H
-		buildStubs    func(store *mockdb.MockStore)
-		checkResponse func(w *httptest.ResponseRecorder)
+		name             string
+		arg              gin.H
+		buildStubs       func(store *mockdb.MockStore)
+		buildDistributor func(distributor *mockwk.MockTaskDistributor)
+		checkResponse    func(w *httptest.ResponseRecorder)
 	}{
 		{
 			name: "StatusOK",
@@ -72,9 +87,18 @@ func TestCreateUserAPI(t *testing.T) {
 					Fullname:       user.Fullname,
 				}
 				store.EXPECT().
-					CreateUser(gomock.Any(), EqArgMatcher(arg, password)).
+					CreateUserTx(gomock.Any(), EqArgMatcher(arg, password)).
 					Times(1).
-					Return(user, nil)
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						return db.CreateUserTxResult{User: user}, arg.AfterCreate(store, user)
+					})
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {
+				payload := &worker.PayloadSendVerifyEmail{Username: user.Username}
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Eq(payload), gomock.Any()).
+					Times(1).
+					Return(nil)
 			},
 			checkResponse: func(w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, w.Code)
@@ -90,7 +114,24 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "CommonPassword",
+			arg: gin.H{
+				"username": user.Username,
+				"password": "Password1",
+				"fullname": user.Fullname,
+				"email":    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(w *httptest.ResponseRecorder) {
@@ -107,9 +148,9 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, sql.ErrConnDone)
+					Return(db.CreateUserTxResult{}, sql.ErrConnDone)
 			},
 			checkResponse: func(w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, w.Code)
@@ -125,14 +166,40 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, &pq.Error{Code: "23505"})
+					Return(db.CreateUserTxResult{}, db.ErrUniqueViolation)
 			},
 			checkResponse: func(w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusForbidden, w.Code)
 			},
 		},
+		{
+			name: "DistributeError",
+			arg: gin.H{
+				"username": user.Username,
+				"password": password,
+				"fullname": user.Fullname,
+				"email":    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						return db.CreateUserTxResult{}, arg.AfterCreate(store, user)
+					})
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("cannot enqueue task"))
+			},
+			checkResponse: func(w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
 	}
 
 	for _, tc := range testSuite {
@@ -141,9 +208,14 @@ func TestCreateUserAPI(t *testing.T) {
 			defer ctrl.Finish()
 
 			store := mockdb.NewMockStore(ctrl)
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
 			server := newTestServer(t, store)
+			server.distributor = distributor
 
 			tc.buildStubs(store)
+			if tc.buildDistributor != nil {
+				tc.buildDistributor(distributor)
+			}
 
 			reqVal, err := json.Marshal(tc.arg)
 			require.NoError(t, err)
@@ -165,6 +237,7 @@ func TestLoginUserAPI(t *testing.T) {
 	testCases := []struct {
 		name          string
 		body          gin.H
+		setupServer   func(server *Server)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recoder *httptest.ResponseRecorder)
 	}{
@@ -179,9 +252,115 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				expectAudit(store, user.Username, db.AuditLoginSucceeded)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AuditError",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					AppendAuditTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AuditLog{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "access_token")
+			},
+		},
+		{
+			name: "UpgradesPasswordHash",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			setupServer: func(server *Server) {
+				server.passwordHasher = util.PasswordHasher{Algorithm: util.HashArgon2id}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.True(t, strings.HasPrefix(arg.HashedPassword.String, "$argon2id$"))
+						require.NoError(t, util.CompareHashAndPassword(arg.HashedPassword.String, password))
+						// Not a password change, so tokens must stay valid.
+						require.False(t, arg.PasswordChangedAt.Valid)
+						return user, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "PasswordHashUpgradeFails",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			setupServer: func(server *Server) {
+				server.passwordHasher = util.PasswordHasher{BcryptCost: bcrypt.MinCost}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "MFARequired",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				enrolled := user
+				enrolled.TotpEnabled = true
+				enrolled.FailedLoginAttempts = 2
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(enrolled, nil)
+				// Failures only reset once the second factor is checked too.
+				store.EXPECT().
+					ResetFailedLogins(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var body gin.H
+				err := json.Unmarshal(recorder.Body.Bytes(), &body)
+				require.NoError(t, err)
+				require.Equal(t, true, body["mfa_required"])
+				require.NotEmpty(t, body["mfa_token"])
+				require.NotContains(t, body, "access_token")
+				require.NotContains(t, body, "user")
 			},
 		},
 		{
@@ -194,10 +373,10 @@ func TestLoginUserAPI(t *testing.T) {
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, sql.ErrNoRows)
+					Return(db.User{}, db.ErrRecordNotFound)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireInvalidCredentials(t, recorder)
 			},
 		},
 		{
@@ -211,9 +390,135 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					RecordFailedLogin(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(int32(1), nil)
+				store.EXPECT().
+					LockUser(gomock.Any(), gomock.Any()).
+					Times(0)
+				expectAudit(store, user.Username, db.AuditLoginFailed)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireInvalidCredentials(t, recorder)
+			},
+		},
+		{
+			name: "IncorrectPasswordLocksUser",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect",
+			},
+			setupServer: func(server *Server) {
+				server.config.LoginLockoutThreshold = 5
+				server.config.LoginLockoutDuration = time.Minute
+				server.config.LoginLockoutMaxDuration = time.Hour
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RecordFailedLogin(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(int32(6), nil)
+				store.EXPECT().
+					LockUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.LockUserParams) error {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now().Add(2*time.Minute), arg.LockedUntil, time.Second)
+						return nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				requireInvalidCredentials(t, recorder)
+			},
+		},
+		{
+			name: "LockedUser",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				locked := user
+				locked.FailedLoginAttempts = 5
+				locked.LockedUntil = time.Now().Add(time.Minute)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(locked, nil)
+				store.EXPECT().
+					RecordFailedLogin(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				requireInvalidCredentials(t, recorder)
+			},
+		},
+		{
+			name: "ResetsFailedLogins",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				failed := user
+				failed.FailedLoginAttempts = 2
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(failed, nil)
+				store.EXPECT().
+					ResetFailedLogins(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UsernameRateLimited",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			setupServer: func(server *Server) {
+				server.loginUsernameLimiter = ratelimit.NewTokenBucket(1, time.Minute)
+				server.loginUsernameLimiter.Allow("user:" + user.Username)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name: "IPRateLimited",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			setupServer: func(server *Server) {
+				server.loginIPLimiter = ratelimit.NewTokenBucket(1, time.Minute)
+				server.loginIPLimiter.Allow("ip:")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
 			},
 		},
 		{
@@ -258,8 +563,12 @@ func TestLoginUserAPI(t *testing.T) {
 
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
+			allowAudit(store)
 
 			server := newTestServer(t, store)
+			if tc.setupServer != nil {
+				tc.setupServer(server)
+			}
 			recorder := httptest.NewRecorder()
 
 			// Marshal body data to JSON
@@ -276,29 +585,1035 @@ func TestLoginUserAPI(t *testing.T) {
 	}
 }
 
-func createRandomUser(t *testing.T) (user db.User, password string) {
-	password = util.RandomString(6)
-	hashedPassword, err := util.HashPassword(password)
-	require.NoError(t, err)
+func TestVerifyEmailAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	secretCode := util.RandomString(32)
 
-	user = db.User{
-		Username:       util.RandomOwner(),
-		HashedPassword: hashedPassword,
-		Fullname:       util.RandomOwner(),
-		Email:          util.RandomEmail(),
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: fmt.Sprintf("email_id=1&secret_code=%s", secretCode),
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.VerifyEmailTxParams{EmailID: 1, SecretCode: secretCode}
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.VerifyEmailTxResult{User: user}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"is_verified":true}`, recorder.Body.String())
+			},
+		},
+		{
+			name:  "InvalidCode",
+			query: "email_id=1&secret_code=wrong",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingEmailID",
+			query: fmt.Sprintf("secret_code=%s", secretCode),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: fmt.Sprintf("email_id=1&secret_code=%s", secretCode),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
 	}
-	return
-}
 
-func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
-	var gotUser db.User
-	err := json.Unmarshal(body.Bytes(), &gotUser)
-	require.NoError(t, err)
+	for i := range testCases {
+		tc := testCases[i]
 
-	fmt.Println("body", gotUser, "compare", user)
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
-	require.Equal(t, user.Username, gotUser.Username)
-	require.Equal(t, user.Fullname, gotUser.Fullname)
-	require.Equal(t, user.Email, gotUser.Email)
-	require.Empty(t, gotUser.HashedPassword)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/users/verify_email?"+tc.query, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+// requireInvalidCredentials checks that a failed login gives nothing away
+// about why it failed.
+func requireInvalidCredentials(t *testing.T, recorder *httptest.ResponseRecorder) {
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	var body gin.H
+	err := json.Unmarshal(recorder.Body.Bytes(), &body)
+	require.NoError(t, err)
+	require.Equal(t, gin.H{"error": errInvalidCredentials.Error()}, body)
+}
+
+func TestUpdateUserAPI(t *testing.T) {
+	user, password := createRandomUser(t)
+	newFullname := util.RandomOwner()
+	newEmail := util.RandomEmail()
+	newPassword := util.RandomString(8)
+
+	testCases := []struct {
+		name             string
+		username         string
+		body             gin.H
+		setupAuth        func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker)
+		buildStubs       func(store *mockdb.MockStore)
+		buildDistributor func(distributor *mockwk.MockTaskDistributor)
+		checkResponse    func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			body: gin.H{
+				"fullname": newFullname,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				updated := user
+				updated.Fullname = newFullname
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, pgtype.Text{String: newFullname, Valid: true}, arg.Fullname)
+						require.False(t, arg.Email.Valid)
+						require.False(t, arg.HashedPassword.Valid)
+						require.False(t, arg.PasswordChangedAt.Valid)
+						return db.UpdateUserTxResult{User: updated}, arg.AfterUpdate(store, updated)
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				updated := user
+				updated.Fullname = newFullname
+				requireBodyMatchUser(t, recorder.Body, updated)
+			},
+		},
+		{
+			name:     "EmailChanged",
+			username: user.Username,
+			body: gin.H{
+				"email":            newEmail,
+				"current_password": password,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				updated := user
+				updated.Email = newEmail
+				updated.IsEmailVerified = false
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, pgtype.Text{String: newEmail, Valid: true}, arg.Email)
+						return db.UpdateUserTxResult{User: updated}, arg.AfterUpdate(store, updated)
+					})
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {
+				payload := &worker.PayloadSendVerifyEmail{Username: user.Username}
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Eq(payload), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "PasswordChanged",
+			username: user.Username,
+			body: gin.H{
+				"password":         newPassword,
+				"current_password": password,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.True(t, arg.HashedPassword.Valid)
+						require.NoError(t, util.CompareHashAndPassword(arg.HashedPassword.String, newPassword))
+						require.True(t, arg.PasswordChangedAt.Valid)
+						require.WithinDuration(t, time.Now(), arg.PasswordChangedAt.Time, time.Second)
+						return db.UpdateUserTxResult{User: user}, arg.AfterUpdate(store, user)
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res updateUserResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Equal(t, user.Username, res.Username)
+				require.NotEmpty(t, res.AccessToken)
+			},
+		},
+		{
+			name:     "PasswordChangedSteppedUp",
+			username: user.Username,
+			body: gin.H{
+				"password": newPassword,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				stepUpToken, err := tokenMaker.CreateStepUpToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+stepUpToken)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.True(t, arg.PasswordChangedAt.Valid)
+						return db.UpdateUserTxResult{User: user}, arg.AfterUpdate(store, user)
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "PasswordChangeWithoutCurrentPassword",
+			username: user.Username,
+			body: gin.H{
+				"password": newPassword,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "PasswordChangeWrongCurrentPassword",
+			username: user.Username,
+			body: gin.H{
+				"password":         newPassword,
+				"current_password": "incorrect",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RecordFailedLogin(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(int32(1), nil)
+				expectAudit(store, user.Username, db.AuditStepUpFailed)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "OtherUser",
+			username: "someoneelse",
+			body: gin.H{
+				"fullname": newFullname,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NoAuthorization",
+			username: user.Username,
+			body: gin.H{
+				"fullname": newFullname,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidEmail",
+			username: user.Username,
+			body: gin.H{
+				"email": "invalid-email",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "PasswordTooShort",
+			username: user.Username,
+			body: gin.H{
+				"password": "123",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			username: user.Username,
+			body: gin.H{
+				"fullname": newFullname,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "EmailChangeWithoutCurrentPassword",
+			username: user.Username,
+			body: gin.H{
+				"email": newEmail,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "DuplicateEmail",
+			username: user.Username,
+			body: gin.H{
+				"email": newEmail,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				stepUpToken, err := tokenMaker.CreateStepUpToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+stepUpToken)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+			if tc.buildDistributor != nil {
+				tc.buildDistributor(distributor)
+			}
+
+			server := newTestServer(t, store)
+			server.distributor = distributor
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/users/%s", tc.username)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestForgotPasswordAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	testCases := []struct {
+		name             string
+		body             gin.H
+		buildStubs       func(store *mockdb.MockStore)
+		buildDistributor func(distributor *mockwk.MockTaskDistributor)
+		checkResponse    func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {
+				payload := &worker.PayloadSendPasswordReset{Username: user.Username}
+				distributor.EXPECT().
+					DistributeTaskSendPasswordReset(gomock.Any(), gomock.Nil(), gomock.Eq(payload), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {
+				distributor.EXPECT().
+					DistributeTaskSendPasswordReset(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{"email": "invalid-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DistributeError",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {
+				distributor.EXPECT().
+					DistributeTaskSendPasswordReset(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("cannot enqueue task"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
+			tc.buildStubs(store)
+			tc.buildDistributor(distributor)
+
+			server := newTestServer(t, store)
+			server.distributor = distributor
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/forgot_password", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestForgotPasswordRateLimitAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	other, _ := createRandomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	distributor := mockwk.NewMockTaskDistributor(ctrl)
+	store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+	store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(other.Email)).Times(1).Return(other, nil)
+	distributor.EXPECT().
+		DistributeTaskSendPasswordReset(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(nil)
+
+	server := newTestServer(t, store)
+	server.distributor = distributor
+	server.resetIPLimiter = ratelimit.NewTokenBucket(3, time.Hour)
+	server.resetEmailLimiter = ratelimit.NewTokenBucket(1, time.Hour)
+
+	forgot := func(email string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(gin.H{"email": email})
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, "/users/forgot_password", bytes.NewReader(data))
+		require.NoError(t, err)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusAccepted, forgot(user.Email).Code)
+
+	// The same inbox cannot be flooded, whatever the case of the address.
+	recorder := forgot(strings.ToUpper(user.Email))
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	require.Equal(t, http.StatusAccepted, forgot(other.Email).Code)
+
+	// Nor can one client go through many inboxes.
+	require.Equal(t, http.StatusTooManyRequests, forgot(util.RandomEmail()).Code)
+}
+
+func TestCheckResetPasswordAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	token := util.RandomString(32)
+	reset := db.PasswordReset{
+		Username:  user.Username,
+		TokenHash: util.HashToken(token),
+		ExpiredAt: time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordReset(gomock.Any(), gomock.Eq(reset.TokenHash)).
+					Times(1).
+					Return(reset, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res checkResetPasswordResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+				require.Equal(t, user.Username, res.Username)
+				require.True(t, reset.ExpiredAt.Equal(res.ExpiredAt))
+			},
+		},
+		{
+			name: "InvalidToken",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordReset(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PasswordReset{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.AppBaseURL = "http://bank.example"
+
+			// Follow the link from the email the user actually receives.
+			msg := mail.PasswordResetMessage(server.config.AppBaseURL, user.Email, user.Fullname, token)
+			match := regexp.MustCompile(`href="([^"]+)"`).FindStringSubmatch(msg.Body)
+			require.Len(t, match, 2)
+			link := html.UnescapeString(match[1])
+			require.True(t, strings.HasPrefix(link, server.config.AppBaseURL))
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, strings.TrimPrefix(link, server.config.AppBaseURL), nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestResendVerifyEmailAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	user.IsEmailVerified = false
+
+	testCases := []struct {
+		name             string
+		setupAuth        func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker)
+		buildStubs       func(store *mockdb.MockStore)
+		buildDistributor func(distributor *mockwk.MockTaskDistributor)
+		checkResponse    func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {
+				payload := &worker.PayloadSendVerifyEmail{Username: user.Username}
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Nil(), gomock.Eq(payload), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				verified := user
+				verified.IsEmailVerified = true
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(verified, nil)
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UserNotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "DistributeError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.TokenMaker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("cannot enqueue task"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+			tc.buildDistributor(distributor)
+
+			server := newTestServer(t, store)
+			server.distributor = distributor
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users/verify_email/resend", nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestResendVerifyEmailRateLimitAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	user.IsEmailVerified = false
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	distributor := mockwk.NewMockTaskDistributor(ctrl)
+	allowAuth(store)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(2).
+		Return(user, nil)
+	distributor.EXPECT().
+		DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(nil)
+
+	server := newTestServer(t, store)
+	server.distributor = distributor
+	server.verifyEmailLimiter = ratelimit.NewTokenBucket(2, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, "/users/verify_email/resend", nil)
+		require.NoError(t, err)
+
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+
+		if i < 2 {
+			require.Equal(t, http.StatusAccepted, recorder.Code)
+			continue
+		}
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+	}
+}
+
+func TestResetPasswordAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	token := util.RandomString(32)
+	newPassword := util.RandomString(8)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"token": token, "password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+						require.Equal(t, util.HashToken(token), arg.TokenHash)
+						require.NoError(t, util.CompareHashAndPassword(arg.HashedPassword, newPassword))
+						return db.ResetPasswordTxResult{User: user}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "InvalidToken",
+			body: gin.H{"token": "wrong", "password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PasswordTooShort",
+			body: gin.H{"token": token, "password": "123"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"token": token, "password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/reset_password", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func createRandomUser(t *testing.T) (user db.User, password string) {
+	password = util.RandomString(10)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user = db.User{
+		Username:        util.RandomOwner(),
+		HashedPassword:  hashedPassword,
+		Fullname:        util.RandomOwner(),
+		Email:           util.RandomEmail(),
+		IsEmailVerified: true,
+	}
+	return
+}
+
+func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
+	var gotUser db.User
+	err := json.Unmarshal(body.Bytes(), &gotUser)
+	require.NoError(t, err)
+
+	fmt.Println("body", gotUser, "compare", user)
+
+	require.Equal(t, user.Username, gotUser.Username)
+	require.Equal(t, user.Fullname, gotUser.Fullname)
+	require.Equal(t, user.Email, gotUser.Email)
+	require.Empty(t, gotUser.HashedPassword)
+}
+
+func TestLoginUserForwardedForAPI(t *testing.T) {
+	user, password := createRandomUser(t)
+
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		code           int
+	}{
+		{
+			name: "SpoofedHeader",
+			code: http.StatusTooManyRequests,
+		},
+		{
+			name:           "TrustedProxy",
+			trustedProxies: []string{"203.0.113.0/24"},
+			code:           http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUser(gomock.Any(), gomock.Eq(user.Username)).
+				AnyTimes().
+				Return(user, nil)
+			allowAudit(store)
+
+			server := newTestServer(t, store)
+			server.config.TrustedProxies = tc.trustedProxies
+			require.NoError(t, server.setupRouter())
+			server.loginIPLimiter = ratelimit.NewTokenBucket(1, time.Minute)
+
+			data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+			require.NoError(t, err)
+
+			var recorder *httptest.ResponseRecorder
+			for _, forwardedFor := range []string{"198.51.100.1", "198.51.100.2"} {
+				recorder = httptest.NewRecorder()
+				request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+				require.NoError(t, err)
+				request.RemoteAddr = "203.0.113.7:40000"
+				request.Header.Set("X-Forwarded-For", forwardedFor)
+
+				server.router.ServeHTTP(recorder, request)
+			}
+			require.Equal(t, tc.code, recorder.Code)
+		})
+	}
 }
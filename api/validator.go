@@ -1,6 +1,7 @@
 package api
 
 import (
+	db "simplebank/db/sqlc"
 	"simplebank/util"
 
 	"github.com/go-playground/validator/v10"
@@ -12,3 +13,21 @@ var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
 	}
 	return false
 }
+
+var validWebhookEvent validator.Func = func(fl validator.FieldLevel) bool {
+	if eventType, ok := fl.Field().Interface().(string); ok {
+		for _, t := range db.WebhookEventTypes {
+			if eventType == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var validAccountNumber validator.Func = func(fl validator.FieldLevel) bool {
+	if number, ok := fl.Field().Interface().(string); ok {
+		return util.ValidAccountNumber(number)
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// watchHeartbeat keeps idle streams from being cut by proxies.
+const watchHeartbeat = 15 * time.Second
+
+// watchAccount streams the account as server-sent events: an "account"
+// event with its current state, then an "update" event with the account
+// after every transfer or hold that touches it, and the new entry for a
+// transfer. The hub is
+// subscribed to before the account is read, so no transfer falls in
+// between.
+func (server *Server) watchAccount(c *gin.Context) {
+	var req getAccountReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	sub := server.hub.Subscribe(req.ID)
+	defer sub.Close()
+
+	acc, err := server.store.GetAccount(c, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if acc.Owner != payload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("account", acc)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(watchHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		case update, ok := <-sub.C:
+			if !ok {
+				c.SSEvent("error", errorResponse(sub.Err()))
+				return false
+			}
+			c.SSEvent("update", update)
+			return true
+		}
+	})
+}
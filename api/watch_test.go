@@ -0,0 +1,167 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type serverSentEvent struct {
+	name string
+	data string
+}
+
+// readEvent reads the next event from an SSE stream, skipping comments.
+func readEvent(t *testing.T, r *bufio.Reader) serverSentEvent {
+	var event serverSentEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+
+		switch {
+		case line == "" && event.name != "":
+			return event
+		case strings.HasPrefix(line, "event:"):
+			event.name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			event.data = strings.TrimPrefix(line, "data:")
+		}
+	}
+}
+
+func TestWatchAccountAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	acc := createRandomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	allowAuth(store)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(acc.ID)).
+		Times(1).
+		Return(acc, nil)
+
+	server := newTestServer(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/accounts/%d/watch", httpServer.URL, acc.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	body := bufio.NewReader(response.Body)
+
+	event := readEvent(t, body)
+	require.Equal(t, "account", event.name)
+	var got db.Account
+	require.NoError(t, json.Unmarshal([]byte(event.data), &got))
+	require.Equal(t, acc.ID, got.ID)
+
+	updated := acc
+	updated.Balance += 10
+	server.hub.Broadcast(db.AccountUpdate{
+		Account: updated,
+		Entry:   &db.Entry{ID: 1, AccountID: acc.ID, Amount: 10},
+	})
+
+	event = readEvent(t, body)
+	require.Equal(t, "update", event.name)
+	var update db.AccountUpdate
+	require.NoError(t, json.Unmarshal([]byte(event.data), &update))
+	require.Equal(t, updated.Balance, update.Account.Balance)
+	require.Equal(t, int64(10), update.Entry.Amount)
+
+	server.hub.Close()
+	event = readEvent(t, body)
+	require.Equal(t, "error", event.name)
+}
+
+func TestWatchAccountAPIErrors(t *testing.T) {
+	user, _ := createRandomUser(t)
+	acc := createRandomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "NotOwner",
+			accountID: acc.ID,
+			username:  "mallory",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(acc, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountID: acc.ID,
+			username:  user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(acc.ID)).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidID",
+			accountID: 0,
+			username:  user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/watch", tc.accountID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"simplebank/webhook"
+	"simplebank/worker"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type webhookEndpointResponse struct {
+	ID         int64     `json:"id"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newWebhookEndpointResponse(endpoint db.WebhookEndpoint) webhookEndpointResponse {
+	return webhookEndpointResponse{
+		ID:         endpoint.ID,
+		Url:        endpoint.Url,
+		EventTypes: endpoint.EventTypes,
+		IsActive:   endpoint.IsActive,
+		CreatedAt:  endpoint.CreatedAt,
+	}
+}
+
+type createWebhookEndpointReq struct {
+	Url        string   `json:"url" binding:"required,url"`
+	EventTypes []string `json:"event_types" binding:"dive,webhook_event"`
+}
+
+type createWebhookEndpointRes struct {
+	webhookEndpointResponse
+	// Secret signs every request sent to the endpoint. It is only ever
+	// shown here.
+	Secret string `json:"secret"`
+}
+
+func (server *Server) createWebhookEndpoint(c *gin.Context) {
+	var req createWebhookEndpointReq
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := webhook.CheckURL(c, server.webhookResolver, req.Url); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	secret, err := util.RandomSecret(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	eventTypes := req.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	endpoint, err := server.store.CreateWebhookEndpoint(c, db.CreateWebhookEndpointParams{
+		Owner:      payload.Username,
+		Url:        req.Url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, createWebhookEndpointRes{
+		webhookEndpointResponse: newWebhookEndpointResponse(endpoint),
+		Secret:                  endpoint.Secret,
+	})
+}
+
+func (server *Server) listWebhookEndpoints(c *gin.Context) {
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	endpoints, err := server.store.ListWebhookEndpoints(c, payload.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	res := make([]webhookEndpointResponse, len(endpoints))
+	for i, endpoint := range endpoints {
+		res[i] = newWebhookEndpointResponse(endpoint)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+type webhookEndpointReq struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deleteWebhookEndpoint(c *gin.Context) {
+	var req webhookEndpointReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	endpoint, err := server.store.DeactivateWebhookEndpoint(c, db.DeactivateWebhookEndpointParams{
+		ID:    req.ID,
+		Owner: payload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newWebhookEndpointResponse(endpoint))
+}
+
+// validWebhookEndpoint loads the endpoint with the given ID and makes sure
+// the authenticated user owns it.
+func (server *Server) validWebhookEndpoint(c *gin.Context, id int64) (db.WebhookEndpoint, bool) {
+	endpoint, err := server.store.GetWebhookEndpoint(c, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return endpoint, false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return endpoint, false
+	}
+
+	payload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	if endpoint.Owner != payload.Username {
+		err := errors.New("webhook endpoint doesn't belong to the authenticated user")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return endpoint, false
+	}
+	return endpoint, true
+}
+
+type listWebhookDeliveriesReq struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+// listWebhookDeliveries is the delivery log of an endpoint, newest first.
+func (server *Server) listWebhookDeliveries(c *gin.Context) {
+	var uri webhookEndpointReq
+	var req listWebhookDeliveriesReq
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.validWebhookEndpoint(c, uri.ID); !ok {
+		return
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(c, db.ListWebhookDeliveriesParams{
+		EndpointID: uri.ID,
+		Limit:      req.PageSize,
+		Offset:     (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+type webhookDeliveryReq struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// replayWebhookDelivery sends a failed delivery again, with a fresh set of
+// attempts.
+func (server *Server) replayWebhookDelivery(c *gin.Context) {
+	var req webhookDeliveryReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	delivery, err := server.store.GetWebhookDelivery(c, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.validWebhookEndpoint(c, delivery.EndpointID); !ok {
+		return
+	}
+
+	delivery, err = server.store.ReplayWebhookDeliveryTx(c, db.ReplayWebhookDeliveryTxParams{
+		ID: delivery.ID,
+		AfterReplay: func(q db.Querier, delivery db.WebhookDelivery) error {
+			return server.distributor.DistributeTaskDeliverWebhook(
+				c, q,
+				&worker.PayloadDeliverWebhook{DeliveryID: delivery.ID},
+				worker.MaxAttempts(worker.WebhookMaxAttempts),
+			)
+		},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrWebhookDeliveryNotFailed) {
+			c.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
@@ -0,0 +1,558 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"simplebank/worker"
+	mockwk "simplebank/worker/mock"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomWebhookEndpoint(owner string) db.WebhookEndpoint {
+	return db.WebhookEndpoint{
+		ID:         int64(util.RandomInt(1, 1000)),
+		Owner:      owner,
+		Url:        "https://example.com/hooks",
+		Secret:     util.RandomString(64),
+		EventTypes: []string{db.EventAccountCredited},
+		IsActive:   true,
+	}
+}
+
+// stubResolver answers DNS lookups from a fixed table.
+type stubResolver map[string]string
+
+func (r stubResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ip, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return []net.IPAddr{{IP: net.ParseIP(ip)}}, nil
+}
+
+var testWebhookResolver = stubResolver{
+	"example.com":          "93.184.216.34",
+	"internal.example.com": "10.0.0.5",
+}
+
+func TestCreateWebhookEndpointAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	endpoint := randomWebhookEndpoint(user.Username)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"url":         endpoint.Url,
+				"event_types": endpoint.EventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateWebhookEndpointParams) (db.WebhookEndpoint, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.Equal(t, endpoint.Url, arg.Url)
+						require.Equal(t, endpoint.EventTypes, arg.EventTypes)
+						require.Len(t, arg.Secret, 64)
+						return endpoint, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got createWebhookEndpointRes
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, endpoint.ID, got.ID)
+				require.Equal(t, endpoint.Secret, got.Secret)
+			},
+		},
+		{
+			name: "AllEvents",
+			body: gin.H{"url": endpoint.Url},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateWebhookEndpointParams) (db.WebhookEndpoint, error) {
+						require.NotNil(t, arg.EventTypes)
+						require.Empty(t, arg.EventTypes)
+						return endpoint, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownEventType",
+			body: gin.H{
+				"url":         endpoint.Url,
+				"event_types": []string{"account.closed"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidURL",
+			body: gin.H{"url": "not a url"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PlainHTTP",
+			body: gin.H{"url": "http://example.com/hooks"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Loopback",
+			body: gin.H{"url": "https://127.0.0.1:8443/hooks"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "MetadataService",
+			body: gin.H{"url": "https://169.254.169.254/latest/meta-data"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PrivateNetwork",
+			body: gin.H{"url": "https://[fd00::1]/hooks"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ResolvesToPrivate",
+			body: gin.H{"url": "https://internal.example.com/hooks"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Unresolvable",
+			body: gin.H{"url": "https://nowhere.example.com/hooks"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"url": endpoint.Url},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.WebhookEndpoint{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.webhookResolver = testWebhookResolver
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListWebhookEndpointsAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	endpoints := []db.WebhookEndpoint{
+		randomWebhookEndpoint(user.Username),
+		randomWebhookEndpoint(user.Username),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	allowAuth(store)
+	store.EXPECT().
+		ListWebhookEndpoints(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(endpoints, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/webhooks", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), "secret")
+
+	var got []webhookEndpointResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Len(t, got, len(endpoints))
+	require.Equal(t, endpoints[1].ID, got[1].ID)
+}
+
+func TestDeleteWebhookEndpointAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	endpoint := randomWebhookEndpoint(user.Username)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.DeactivateWebhookEndpointParams{ID: endpoint.ID, Owner: user.Username}
+				inactive := endpoint
+				inactive.IsActive = false
+				store.EXPECT().
+					DeactivateWebhookEndpoint(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(inactive, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got webhookEndpointResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.False(t, got.IsActive)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeactivateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.WebhookEndpoint{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/webhooks/%d", endpoint.ID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListWebhookDeliveriesAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	endpoint := randomWebhookEndpoint(user.Username)
+	deliveries := []db.WebhookDelivery{
+		{ID: 2, EndpointID: endpoint.ID, Status: db.WebhookDeliveryFailed, Attempts: 12},
+		{ID: 1, EndpointID: endpoint.ID, Status: db.WebhookDeliverySucceeded, Attempts: 1},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			query:    "page_id=2&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).
+					Times(1).
+					Return(endpoint, nil)
+				arg := db.ListWebhookDeliveriesParams{EndpointID: endpoint.ID, Limit: 5, Offset: 5}
+				store.EXPECT().
+					ListWebhookDeliveries(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(deliveries, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []db.WebhookDelivery
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, deliveries, got)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: util.RandomOwner(),
+			query:    "page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).
+					Times(1).
+					Return(endpoint, nil)
+				store.EXPECT().ListWebhookDeliveries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "EndpointNotFound",
+			username: user.Username,
+			query:    "page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.WebhookEndpoint{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidPageSize",
+			username: user.Username,
+			query:    "page_id=1&page_size=1000",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/webhooks/%d/deliveries?%s", endpoint.ID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestReplayWebhookDeliveryAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	endpoint := randomWebhookEndpoint(user.Username)
+	delivery := db.WebhookDelivery{
+		ID:         int64(util.RandomInt(1, 1000)),
+		EndpointID: endpoint.ID,
+		Status:     db.WebhookDeliveryFailed,
+		Attempts:   worker.WebhookMaxAttempts,
+	}
+
+	testCases := []struct {
+		name             string
+		username         string
+		buildStubs       func(store *mockdb.MockStore)
+		buildDistributor func(distributor *mockwk.MockTaskDistributor)
+		checkResponse    func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).Times(1).Return(delivery, nil)
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+				store.EXPECT().
+					ReplayWebhookDeliveryTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ReplayWebhookDeliveryTxParams) (db.WebhookDelivery, error) {
+						require.Equal(t, delivery.ID, arg.ID)
+						pending := delivery
+						pending.Status = db.WebhookDeliveryPending
+						return pending, arg.AfterReplay(store, pending)
+					})
+			},
+			buildDistributor: func(distributor *mockwk.MockTaskDistributor) {
+				payload := &worker.PayloadDeliverWebhook{DeliveryID: delivery.ID}
+				distributor.EXPECT().
+					DistributeTaskDeliverWebhook(gomock.Any(), gomock.Any(), gomock.Eq(payload), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.WebhookDelivery
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.WebhookDeliveryPending, got.Status)
+			},
+		},
+		{
+			name:     "NotFailed",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(delivery, nil)
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Any()).Times(1).Return(endpoint, nil)
+				store.EXPECT().
+					ReplayWebhookDeliveryTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.WebhookDelivery{}, db.ErrWebhookDeliveryNotFailed)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: util.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(delivery, nil)
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Any()).Times(1).Return(endpoint, nil)
+				store.EXPECT().ReplayWebhookDeliveryTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "DeliveryNotFound",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.WebhookDelivery{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			allowAuth(store)
+			tc.buildStubs(store)
+
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
+			if tc.buildDistributor != nil {
+				tc.buildDistributor(distributor)
+			}
+
+			server := newTestServer(t, store)
+			server.distributor = distributor
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/webhook_deliveries/%d/replay", delivery.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
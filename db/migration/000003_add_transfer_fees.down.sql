@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS fee_rules;
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fee";
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE "fee_rules" (
+  "currency" varchar PRIMARY KEY,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "percent_bps" bigint NOT NULL DEFAULT 0,
+  "max_fee" bigint NOT NULL DEFAULT 0,
+  "fee_account_id" bigint NOT NULL,
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfers" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "fee_rules"."percent_bps" IS 'basis points of the transfer amount';
+
+COMMENT ON COLUMN "fee_rules"."max_fee" IS '0 means no cap';
+
+ALTER TABLE "fee_rules" ADD FOREIGN KEY ("fee_account_id") REFERENCES "accounts" ("id");
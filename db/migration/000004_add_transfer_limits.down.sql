@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS transfer_limits;
+
+DROP INDEX IF EXISTS "transfers_from_account_id_created_at_idx";
\ No newline at end of file
@@ -0,0 +1,34 @@
+CREATE TABLE "transfer_limits" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar,
+  "account_id" bigint,
+  "currency" varchar,
+  "max_amount" bigint NOT NULL DEFAULT 0,
+  "daily_amount" bigint NOT NULL DEFAULT 0,
+  "daily_count" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "transfer_limits" ("owner");
+
+CREATE INDEX ON "transfer_limits" ("account_id");
+
+CREATE INDEX ON "transfer_limits" ("currency");
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+COMMENT ON COLUMN "transfer_limits"."owner" IS 'null matches every user';
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'null matches every account';
+
+COMMENT ON COLUMN "transfer_limits"."currency" IS 'null matches every currency';
+
+COMMENT ON COLUMN "transfer_limits"."max_amount" IS '0 means no limit';
+
+COMMENT ON COLUMN "transfer_limits"."daily_amount" IS '0 means no limit';
+
+COMMENT ON COLUMN "transfer_limits"."daily_count" IS '0 means no limit';
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
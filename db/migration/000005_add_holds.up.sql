@@ -0,0 +1,31 @@
+ALTER TABLE "accounts" ADD COLUMN "available_balance" bigint NOT NULL DEFAULT 0;
+
+UPDATE "accounts" SET "available_balance" = "balance";
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "holds" ("account_id");
+
+CREATE INDEX ON "holds" ("status", "expires_at");
+
+COMMENT ON COLUMN "accounts"."available_balance" IS 'balance minus pending holds';
+
+COMMENT ON COLUMN "holds"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "holds"."status" IS 'pending, captured, released or expired';
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,20 @@
+CREATE TABLE "tasks" (
+  "id" bigserial PRIMARY KEY,
+  "queue" varchar NOT NULL DEFAULT 'default',
+  "type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "max_attempts" integer NOT NULL,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "run_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "tasks" ("queue", "status", "run_at");
+
+COMMENT ON COLUMN "tasks"."status" IS 'pending, running, completed or dead';
+
+COMMENT ON COLUMN "tasks"."locked_until" IS 'a running task past this time is claimed again';
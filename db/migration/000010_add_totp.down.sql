@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS mfa_backup_codes;
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "totp_enabled";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "totp_secret";
@@ -0,0 +1,14 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar NOT NULL DEFAULT '';
+ALTER TABLE "users" ADD COLUMN "totp_enabled" bool NOT NULL DEFAULT false;
+
+CREATE TABLE "mfa_backup_codes" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "is_used" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "mfa_backup_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE UNIQUE INDEX ON "mfa_backup_codes" ("username", "code_hash");
@@ -0,0 +1,7 @@
+CREATE TABLE "step_up_rules" (
+  "currency" varchar PRIMARY KEY,
+  "threshold" bigint NOT NULL,
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "step_up_rules"."threshold" IS 'transfers above this amount need a fresh second factor';
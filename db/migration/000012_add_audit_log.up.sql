@@ -0,0 +1,36 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
+
+CREATE TABLE "audit_log" (
+  "id" bigserial PRIMARY KEY,
+  "action" varchar NOT NULL,
+  "actor" varchar NOT NULL DEFAULT '',
+  "resource" varchar NOT NULL DEFAULT '',
+  "ip" varchar NOT NULL DEFAULT '',
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "request_id" varchar NOT NULL DEFAULT '',
+  "before" json NOT NULL,
+  "after" json NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  "prev_hash" varchar NOT NULL,
+  "hash" varchar UNIQUE NOT NULL
+);
+
+CREATE INDEX ON "audit_log" ("actor");
+
+CREATE INDEX ON "audit_log" ("resource");
+
+CREATE INDEX ON "audit_log" ("action");
+
+COMMENT ON COLUMN "audit_log"."before" IS 'json rather than jsonb so that the text hashed is the text stored';
+
+COMMENT ON COLUMN "audit_log"."hash" IS 'sha256 of prev_hash and the row, chaining every row to the one before it';
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+BEFORE UPDATE OR DELETE OR TRUNCATE ON "audit_log"
+FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
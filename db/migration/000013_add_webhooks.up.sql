@@ -0,0 +1,50 @@
+CREATE TABLE "webhook_endpoints" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL DEFAULT '{}',
+  "is_active" bool NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_events" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "type" varchar NOT NULL,
+  "payload" json NOT NULL,
+  "is_dispatched" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "endpoint_id" bigint NOT NULL,
+  "event_id" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "response_status" int NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhook_endpoints" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_events" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("endpoint_id") REFERENCES "webhook_endpoints" ("id");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "webhook_events" ("id");
+
+CREATE INDEX ON "webhook_endpoints" ("owner");
+
+CREATE INDEX ON "webhook_events" ("id") WHERE NOT "is_dispatched";
+
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("endpoint_id", "event_id");
+
+COMMENT ON COLUMN "webhook_endpoints"."event_types" IS 'empty means every event type';
+
+COMMENT ON COLUMN "webhook_events"."is_dispatched" IS 'set once a delivery has been queued for every subscribed endpoint';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded or failed';
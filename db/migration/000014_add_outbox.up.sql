@@ -0,0 +1,12 @@
+CREATE TABLE "outbox" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "type" varchar NOT NULL,
+  "payload" json NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "published_at" timestamptz
+);
+
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL;
+
+COMMENT ON COLUMN "outbox"."account_id" IS 'events of one account are published in id order';
@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "bulk_transfer_id";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "reference";
+
+DROP TABLE IF EXISTS bulk_transfers;
@@ -0,0 +1,21 @@
+CREATE TABLE "bulk_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "mode" varchar NOT NULL,
+  "item_count" int NOT NULL,
+  "succeeded" int NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "bulk_transfers" ("from_account_id");
+
+ALTER TABLE "transfers" ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+ALTER TABLE "transfers" ADD COLUMN "bulk_transfer_id" bigint;
+
+CREATE INDEX ON "transfers" ("bulk_transfer_id");
+
+COMMENT ON COLUMN "bulk_transfers"."mode" IS 'atomic or per_item';
+
+ALTER TABLE "bulk_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("bulk_transfer_id") REFERENCES "bulk_transfers" ("id");
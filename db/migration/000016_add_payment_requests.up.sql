@@ -0,0 +1,29 @@
+CREATE TABLE "payment_requests" (
+  "id" bigserial PRIMARY KEY,
+  "requester_account_id" bigint NOT NULL,
+  "payer" varchar NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "note" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "payment_requests" ("requester_account_id");
+
+CREATE INDEX ON "payment_requests" ("payer");
+
+COMMENT ON COLUMN "payment_requests"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "payment_requests"."status" IS 'pending, paid, declined, cancelled or expired';
+
+COMMENT ON COLUMN "payment_requests"."transfer_id" IS 'the transfer that paid the request';
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("requester_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("payer") REFERENCES "users" ("username");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
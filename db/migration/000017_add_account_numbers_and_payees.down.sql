@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS payees;
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "number";
+
+DROP FUNCTION IF EXISTS random_account_number;
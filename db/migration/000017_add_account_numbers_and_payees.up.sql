@@ -0,0 +1,44 @@
+-- Ten random digits followed by two ISO 7064 MOD 97-10 check digits, the
+-- scheme IBANs use, so that the whole number is 1 modulo 97. Draws again
+-- until the number is not already taken.
+CREATE FUNCTION random_account_number() RETURNS varchar AS $$
+DECLARE
+  base bigint;
+  candidate varchar;
+BEGIN
+  LOOP
+    base := floor(random() * 10000000000)::bigint;
+    candidate := lpad(base::text, 10, '0') || lpad((98 - (base * 100) % 97)::text, 2, '0');
+    EXIT WHEN NOT EXISTS (SELECT 1 FROM "accounts" WHERE "number" = candidate);
+  END LOOP;
+  RETURN candidate;
+END;
+$$ LANGUAGE plpgsql VOLATILE;
+
+ALTER TABLE "accounts" ADD COLUMN "number" varchar;
+
+UPDATE "accounts" SET "number" = random_account_number();
+
+ALTER TABLE "accounts" ALTER COLUMN "number" SET NOT NULL;
+
+ALTER TABLE "accounts" ALTER COLUMN "number" SET DEFAULT random_account_number();
+
+CREATE UNIQUE INDEX ON "accounts" ("number");
+
+COMMENT ON COLUMN "accounts"."number" IS 'what users share instead of the id';
+
+CREATE TABLE "payees" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "nickname" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "nickname");
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "account_id");
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
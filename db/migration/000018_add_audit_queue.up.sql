@@ -0,0 +1,14 @@
+CREATE TABLE "audit_queue" (
+  "id" bigserial PRIMARY KEY,
+  "action" varchar NOT NULL,
+  "actor" varchar NOT NULL DEFAULT '',
+  "resource" varchar NOT NULL DEFAULT '',
+  "ip" varchar NOT NULL DEFAULT '',
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "request_id" varchar NOT NULL DEFAULT '',
+  "before" json NOT NULL,
+  "after" json NOT NULL,
+  "created_at" timestamptz NOT NULL
+);
+
+COMMENT ON TABLE "audit_queue" IS 'events written by money transactions, waiting to be chained into audit_log';
@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS "payment_requests" DROP COLUMN IF EXISTS "link_token_hash";
+
+DELETE FROM "payment_requests" WHERE "payer" IS NULL;
+ALTER TABLE IF EXISTS "payment_requests" ALTER COLUMN "payer" SET NOT NULL;
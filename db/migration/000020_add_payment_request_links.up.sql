@@ -0,0 +1,9 @@
+ALTER TABLE "payment_requests" ALTER COLUMN "payer" DROP NOT NULL;
+
+ALTER TABLE "payment_requests" ADD COLUMN "link_token_hash" varchar NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX ON "payment_requests" ("link_token_hash") WHERE "link_token_hash" <> '';
+
+COMMENT ON COLUMN "payment_requests"."payer" IS 'null for an open request, until someone pays it through its link';
+
+COMMENT ON COLUMN "payment_requests"."link_token_hash" IS 'sha256 of the token in the request''s shareable link';
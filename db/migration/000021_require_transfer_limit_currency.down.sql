@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "transfer_limits" ALTER COLUMN "currency" DROP NOT NULL;
+
+COMMENT ON COLUMN "transfer_limits"."currency" IS 'null matches every currency';
@@ -0,0 +1,20 @@
+-- Amounts in different currencies cannot be added up or compared, so every
+-- limit now has a currency. An account's limit takes the account's.
+UPDATE "transfer_limits"
+SET "currency" = "accounts"."currency"
+FROM "accounts"
+WHERE "accounts"."id" = "transfer_limits"."account_id"
+  AND "transfer_limits"."currency" IS NULL;
+
+-- Any other limit without one is split into a limit per currency in use.
+INSERT INTO "transfer_limits" ("owner", "currency", "max_amount", "daily_amount", "daily_count", "created_at")
+SELECT l."owner", c."currency", l."max_amount", l."daily_amount", l."daily_count", l."created_at"
+FROM "transfer_limits" l
+CROSS JOIN (SELECT DISTINCT "currency" FROM "accounts") c
+WHERE l."currency" IS NULL;
+
+DELETE FROM "transfer_limits" WHERE "currency" IS NULL;
+
+ALTER TABLE "transfer_limits" ALTER COLUMN "currency" SET NOT NULL;
+
+COMMENT ON COLUMN "transfer_limits"."currency" IS 'the currency of the amounts and of the transfers counted';
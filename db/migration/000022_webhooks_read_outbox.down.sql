@@ -0,0 +1,28 @@
+CREATE TABLE "webhook_events" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "type" varchar NOT NULL,
+  "payload" json NOT NULL,
+  "is_dispatched" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- Keeping the outbox ids leaves the deliveries pointing at their events.
+INSERT INTO "webhook_events" ("id", "account_id", "type", "payload", "is_dispatched", "created_at")
+SELECT "id", "account_id", "type", "payload", "webhook_dispatched_at" IS NOT NULL, "created_at"
+FROM "outbox"
+WHERE "type" IN ('transfer.created', 'account.credited', 'account.debited');
+
+SELECT setval(pg_get_serial_sequence('webhook_events', 'id'), (SELECT COALESCE(MAX("id"), 0) + 1 FROM "outbox"), false);
+
+ALTER TABLE "webhook_deliveries" DROP CONSTRAINT "webhook_deliveries_event_id_fkey";
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "webhook_events" ("id");
+
+ALTER TABLE "webhook_events" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX ON "webhook_events" ("id") WHERE NOT "is_dispatched";
+
+COMMENT ON COLUMN "webhook_events"."is_dispatched" IS 'set once a delivery has been queued for every subscribed endpoint';
+
+ALTER TABLE "outbox" DROP COLUMN "webhook_dispatched_at";
@@ -0,0 +1,33 @@
+-- Webhook deliveries are fanned out from the outbox instead of a copy of
+-- every event in webhook_events.
+ALTER TABLE "outbox" ADD COLUMN "webhook_dispatched_at" timestamptz;
+
+-- Events already in the outbox were dispatched from their copies.
+UPDATE "outbox" SET "webhook_dispatched_at" = now();
+
+-- Move the copies over, so that their deliveries keep their event and the
+-- ones not dispatched yet still are. They were published with the outbox
+-- events they copy, so the relay must not pick them up again.
+ALTER TABLE "outbox" ADD COLUMN "webhook_event_id" bigint;
+
+INSERT INTO "outbox" ("account_id", "type", "payload", "created_at", "published_at", "webhook_dispatched_at", "webhook_event_id")
+SELECT "account_id", "type", "payload", "created_at", now(), CASE WHEN "is_dispatched" THEN now() END, "id"
+FROM "webhook_events"
+ORDER BY "id";
+
+ALTER TABLE "webhook_deliveries" DROP CONSTRAINT "webhook_deliveries_event_id_fkey";
+
+UPDATE "webhook_deliveries"
+SET "event_id" = "outbox"."id"
+FROM "outbox"
+WHERE "outbox"."webhook_event_id" = "webhook_deliveries"."event_id";
+
+ALTER TABLE "outbox" DROP COLUMN "webhook_event_id";
+
+DROP TABLE "webhook_events";
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "outbox" ("id");
+
+CREATE INDEX ON "outbox" ("id") WHERE "webhook_dispatched_at" IS NULL;
+
+COMMENT ON COLUMN "outbox"."webhook_dispatched_at" IS 'set once a delivery has been queued for every subscribed endpoint';
@@ -0,0 +1,83 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const usage = "usage: simplebank migrate up [N] | down N|--all | status | version"
+
+// RunCommand executes the migrate subcommand described by args against the
+// database at dbSource and writes its report to w. Rolling everything back
+// drops the whole schema, so down takes a step count or an explicit --all.
+func RunCommand(dbSource string, args []string, w io.Writer) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "up", "down":
+	case "status", "version":
+		if len(args) > 1 {
+			return fmt.Errorf("migrate %s takes no arguments\n%s", args[0], usage)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], usage)
+	}
+
+	steps := 0
+	switch {
+	case len(args) == 2 && args[0] == "down" && args[1] == "--all":
+	case len(args) == 2:
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of steps %q\n%s", args[1], usage)
+		}
+		steps = n
+	case args[0] == "down":
+		return fmt.Errorf("migrate down needs a number of steps or --all\n%s", usage)
+	}
+
+	mg, err := New(dbSource)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	switch args[0] {
+	case "up":
+		err = mg.Up(steps)
+	case "down":
+		err = mg.Down(steps)
+	case "status":
+		var statuses []Status
+		statuses, err = mg.Status()
+		for _, s := range statuses {
+			state := "pending"
+			switch {
+			case s.Dirty:
+				state = "dirty"
+			case s.Applied:
+				state = "applied"
+			}
+			fmt.Fprintf(w, "%-8s %s\n", state, s.Name)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := mg.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "version %d", version)
+	if dirty {
+		fmt.Fprint(w, " (dirty)")
+	}
+	fmt.Fprintln(w)
+	return nil
+}
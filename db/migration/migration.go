@@ -0,0 +1,155 @@
+package migration
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// ErrDirty means a migration failed part way and left the schema somewhere
+// between two versions. It has to be repaired by hand.
+var ErrDirty = errors.New("database schema is dirty")
+
+// Migrator applies the embedded migrations. The pgx driver holds a
+// Postgres advisory lock for the duration of every command, so several
+// instances booting at once apply each migration only once.
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+type Status struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+	Dirty   bool   `json:"dirty"`
+}
+
+func New(dbSource string) (*Migrator, error) {
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", source, databaseURL(dbSource))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create migrator: %w", err)
+	}
+
+	return &Migrator{m: m}, nil
+}
+
+// Up applies n pending migrations, or all of them when n is 0.
+func (mg *Migrator) Up(n int) error {
+	var err error
+	if n > 0 {
+		err = mg.m.Steps(n)
+	} else {
+		err = mg.m.Up()
+	}
+	return ignoreNoChange(err)
+}
+
+// Down rolls back n migrations, or all of them when n is 0.
+func (mg *Migrator) Down(n int) error {
+	var err error
+	if n > 0 {
+		err = mg.m.Steps(-n)
+	} else {
+		err = mg.m.Down()
+	}
+	return ignoreNoChange(err)
+}
+
+// Version returns the current schema version. A database without any
+// migration applied is at version 0.
+func (mg *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = mg.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return
+}
+
+// Status lists every embedded migration and whether it has been applied.
+// When the schema is dirty it still returns the list, with the half-applied
+// migration marked, along with ErrDirty.
+func (mg *Migrator) Status() ([]Status, error) {
+	current, dirty, err := mg.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(names))
+	for _, name := range names {
+		var version uint
+		if _, err := fmt.Sscanf(name, "%d_", &version); err != nil {
+			return nil, fmt.Errorf("invalid migration name %s: %w", name, err)
+		}
+		statuses = append(statuses, Status{
+			Version: version,
+			Name:    strings.TrimSuffix(name, ".up.sql"),
+			Applied: version < current || version == current && !dirty,
+			Dirty:   version == current && dirty,
+		})
+	}
+	if dirty {
+		return statuses, fmt.Errorf("%w at version %d", ErrDirty, current)
+	}
+	return statuses, nil
+}
+
+// Pending reports whether some embedded migrations have not been applied.
+// A dirty schema is an error rather than merely pending.
+func (mg *Migrator) Pending() (bool, error) {
+	statuses, err := mg.Status()
+	if err != nil {
+		return false, err
+	}
+	for _, s := range statuses {
+		if !s.Applied {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (mg *Migrator) Close() error {
+	sourceErr, dbErr := mg.m.Close()
+	if sourceErr != nil {
+		return sourceErr
+	}
+	return dbErr
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) || errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// databaseURL points a postgres connection string at the pgx driver of
+// golang-migrate.
+func databaseURL(dbSource string) string {
+	for _, scheme := range []string{"postgresql://", "postgres://"} {
+		if strings.HasPrefix(dbSource, scheme) {
+			return "pgx5://" + strings.TrimPrefix(dbSource, scheme)
+		}
+	}
+	return dbSource
+}
@@ -1,14 +1,22 @@
 -- name: CreateAccount :one
 INSERT INTO accounts (
-  owner, balance, currency
+  owner, balance, available_balance, currency
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $2, $3
 ) RETURNING *;
 
 -- name: GetAccount :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: GetAccountByNumber :one
+SELECT * FROM accounts
+WHERE number = $1 LIMIT 1;
+
+-- name: GetAccountByOwnerAndCurrency :one
+SELECT * FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1;
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
 Where owner = $1
@@ -18,16 +26,37 @@ OFFSET $3;
 
 -- name: UpdateAccount :one
 UPDATE accounts
-SET balance = $2
+SET available_balance = available_balance + ($2 - balance),
+  balance = $2
 WHERE id = $1
 RETURNING *;
 
 -- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + sqlc.arg(amount)
+SET balance = balance + sqlc.arg(amount),
+  available_balance = available_balance + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: AddAccountAvailableBalance :one
+UPDATE accounts
+SET available_balance = available_balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
 -- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id = $1;
+
+-- name: GetAccountForUpdate :one
+SELECT * FROM accounts
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListExistingAccountIDs :many
+SELECT id FROM accounts
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
+-- name: NotifyAccountUpdate :exec
+-- Postgres holds the notification back until the transaction commits.
+SELECT pg_notify(sqlc.arg(channel)::text, sqlc.arg(payload)::text);
@@ -0,0 +1,59 @@
+-- name: LockAuditLog :exec
+-- Serialises appends so that every row chains onto the latest one. The
+-- lock is held until the surrounding transaction ends.
+SELECT pg_advisory_xact_lock(hashtext('audit_log'));
+
+-- name: GetLastAuditLogHash :one
+SELECT hash FROM audit_log
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: CreateAuditLog :one
+INSERT INTO audit_log (
+  action,
+  actor,
+  resource,
+  ip,
+  user_agent,
+  request_id,
+  before,
+  after,
+  created_at,
+  prev_hash,
+  hash
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING *;
+
+-- name: ListAuditLogs :many
+SELECT * FROM audit_log
+WHERE id > sqlc.arg(after_id)
+  AND (sqlc.narg(actor)::varchar IS NULL OR actor = sqlc.narg(actor))
+  AND (sqlc.narg(action)::varchar IS NULL OR action = sqlc.narg(action))
+  AND (sqlc.narg(resource)::varchar IS NULL OR resource = sqlc.narg(resource))
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: CreateQueuedAuditLog :one
+INSERT INTO audit_queue (
+  action,
+  actor,
+  resource,
+  ip,
+  user_agent,
+  request_id,
+  before,
+  after,
+  created_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: ListQueuedAuditLogs :many
+SELECT * FROM audit_queue
+ORDER BY id
+LIMIT $1;
+
+-- name: DeleteQueuedAuditLogs :exec
+DELETE FROM audit_queue
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
@@ -0,0 +1,17 @@
+-- name: CreateBulkTransfer :one
+INSERT INTO bulk_transfers (
+  from_account_id, mode, item_count, succeeded
+) VALUES (
+  $1, $2, $3, 0
+) RETURNING *;
+
+-- name: SetBulkTransferSucceeded :one
+UPDATE bulk_transfers
+SET succeeded = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListBulkTransferItems :many
+SELECT * FROM transfers
+WHERE bulk_transfer_id = $1
+ORDER BY id;
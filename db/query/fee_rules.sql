@@ -0,0 +1,21 @@
+-- name: UpsertFeeRule :one
+INSERT INTO fee_rules (
+  currency, flat_fee, percent_bps, max_fee, fee_account_id
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (currency) DO UPDATE
+SET flat_fee = EXCLUDED.flat_fee,
+  percent_bps = EXCLUDED.percent_bps,
+  max_fee = EXCLUDED.max_fee,
+  fee_account_id = EXCLUDED.fee_account_id,
+  updated_at = now()
+RETURNING *;
+
+-- name: GetFeeRule :one
+SELECT * FROM fee_rules
+WHERE currency = $1 LIMIT 1;
+
+-- name: ListFeeRules :many
+SELECT * FROM fee_rules
+ORDER BY currency;
@@ -0,0 +1,37 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+  account_id, to_account_id, amount, expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListHolds :many
+SELECT * FROM holds
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListExpiredHolds :many
+SELECT * FROM holds
+WHERE status = 'pending' AND expires_at <= now()
+ORDER BY expires_at
+LIMIT $1
+FOR NO KEY UPDATE SKIP LOCKED;
+
+-- name: UpdateHoldStatus :one
+UPDATE holds
+SET status = sqlc.arg(status),
+  transfer_id = sqlc.narg(transfer_id),
+  updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,18 @@
+-- name: CreateBackupCode :one
+INSERT INTO mfa_backup_codes (
+  username, code_hash
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: DeleteBackupCodes :exec
+DELETE FROM mfa_backup_codes
+WHERE username = $1;
+
+-- name: UseBackupCode :one
+UPDATE mfa_backup_codes
+SET is_used = true
+WHERE username = $1
+  AND code_hash = $2
+  AND is_used = false
+RETURNING *;
@@ -0,0 +1,37 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox (
+  account_id, type, payload
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetOutboxEvent :one
+SELECT * FROM outbox
+WHERE id = $1 LIMIT 1;
+
+-- name: LockOutbox :exec
+-- Only one relay publishes at a time, so that events leave in id order.
+SELECT pg_advisory_xact_lock(hashtext('outbox'));
+
+-- name: ListUnpublishedOutboxEvents :many
+SELECT * FROM outbox
+WHERE published_at IS NULL
+ORDER BY id
+LIMIT $1;
+
+-- name: MarkOutboxEventsPublished :exec
+UPDATE outbox
+SET published_at = now()
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
+-- name: ClaimOutboxEventsForWebhooks :many
+UPDATE outbox
+SET webhook_dispatched_at = now()
+WHERE id IN (
+  SELECT o.id FROM outbox o
+  WHERE o.webhook_dispatched_at IS NULL
+  ORDER BY o.id
+  LIMIT sqlc.arg('limit')
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
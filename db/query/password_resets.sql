@@ -0,0 +1,27 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+  username, token_hash, expired_at
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: UsePasswordReset :one
+UPDATE password_resets
+SET is_used = true
+WHERE token_hash = $1
+  AND is_used = false
+  AND expired_at > now()
+RETURNING *;
+
+-- name: InvalidatePasswordResets :exec
+UPDATE password_resets
+SET is_used = true
+WHERE username = $1
+  AND is_used = false;
+
+-- name: GetPasswordReset :one
+SELECT * FROM password_resets
+WHERE token_hash = $1
+  AND is_used = false
+  AND expired_at > now()
+LIMIT 1;
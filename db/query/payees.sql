@@ -0,0 +1,32 @@
+-- name: CreatePayee :one
+INSERT INTO payees (
+  owner, account_id, nickname
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: ListPayees :many
+-- The account id stays internal; payees are shown by account number.
+SELECT
+  payees.id,
+  payees.nickname,
+  accounts.number AS account_number,
+  accounts.owner AS account_owner,
+  accounts.currency,
+  payees.created_at
+FROM payees
+JOIN accounts ON accounts.id = payees.account_id
+WHERE payees.owner = $1
+ORDER BY payees.nickname
+LIMIT $2
+OFFSET $3;
+
+-- name: GetPayeeAccount :one
+SELECT accounts.* FROM payees
+JOIN accounts ON accounts.id = payees.account_id
+WHERE payees.owner = $1 AND payees.nickname = $2
+LIMIT 1;
+
+-- name: DeletePayee :execrows
+DELETE FROM payees
+WHERE id = $1 AND owner = $2;
@@ -0,0 +1,41 @@
+-- name: CreatePaymentRequest :one
+INSERT INTO payment_requests (
+  requester_account_id, payer, amount, currency, note, expires_at, link_token_hash
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetPaymentRequest :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPaymentRequestByLinkToken :one
+SELECT * FROM payment_requests
+WHERE link_token_hash = sqlc.arg(link_token_hash)
+  AND link_token_hash <> ''
+LIMIT 1;
+
+-- name: GetPaymentRequestForUpdate :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListPaymentRequests :many
+-- Lists the requests the user sent from one of their accounts, received as
+-- the payer, or both.
+SELECT payment_requests.* FROM payment_requests
+JOIN accounts ON accounts.id = payment_requests.requester_account_id
+WHERE (sqlc.arg(as_requester)::bool AND accounts.owner = sqlc.arg(username))
+  OR (sqlc.arg(as_payer)::bool AND payment_requests.payer = sqlc.arg(username))
+ORDER BY payment_requests.id DESC
+LIMIT sqlc.arg(page_size)
+OFFSET sqlc.arg(page_offset);
+
+-- name: UpdatePaymentRequestStatus :one
+UPDATE payment_requests
+SET status = sqlc.arg(status),
+  transfer_id = sqlc.narg(transfer_id),
+  payer = COALESCE(payer, sqlc.narg(payer)),
+  updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,18 @@
+-- name: UpsertStepUpRule :one
+INSERT INTO step_up_rules (
+  currency, threshold
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (currency) DO UPDATE
+SET threshold = EXCLUDED.threshold,
+  updated_at = now()
+RETURNING *;
+
+-- name: GetStepUpRule :one
+SELECT * FROM step_up_rules
+WHERE currency = $1 LIMIT 1;
+
+-- name: ListStepUpRules :many
+SELECT * FROM step_up_rules
+ORDER BY currency;
@@ -0,0 +1,72 @@
+-- name: CreateTask :one
+INSERT INTO tasks (
+  queue, type, payload, max_attempts, run_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetTask :one
+SELECT * FROM tasks
+WHERE id = $1 LIMIT 1;
+
+-- name: ClaimTasks :many
+UPDATE tasks
+SET status = 'running',
+    attempts = attempts + 1,
+    locked_until = sqlc.arg(locked_until),
+    updated_at = now()
+WHERE id IN (
+  SELECT t.id FROM tasks t
+  WHERE t.queue = sqlc.arg(queue)
+    AND (
+      (t.status = 'pending' AND t.run_at <= now())
+      OR (t.status = 'running' AND t.locked_until < now())
+    )
+  ORDER BY t.run_at, t.id
+  LIMIT sqlc.arg('limit')
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteTask :exec
+UPDATE tasks
+SET status = 'completed',
+    last_error = '',
+    updated_at = now()
+WHERE id = $1;
+
+-- name: RetryTask :exec
+UPDATE tasks
+SET status = 'pending',
+    run_at = sqlc.arg(run_at),
+    last_error = sqlc.arg(last_error),
+    updated_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: KillTask :exec
+UPDATE tasks
+SET status = 'dead',
+    last_error = sqlc.arg(last_error),
+    updated_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: RequeueDeadTask :one
+UPDATE tasks
+SET status = 'pending',
+    attempts = 0,
+    run_at = now(),
+    updated_at = now()
+WHERE id = $1 AND status = 'dead'
+RETURNING *;
+
+-- name: ListTasks :many
+SELECT * FROM tasks
+WHERE queue = $1 AND status = $2
+ORDER BY id DESC
+LIMIT $3
+OFFSET $4;
+
+-- name: CountTasks :many
+SELECT queue, status, count(*) AS count FROM tasks
+GROUP BY queue, status
+ORDER BY queue, status;
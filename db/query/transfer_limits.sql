@@ -0,0 +1,31 @@
+-- name: CreateTransferLimit :one
+INSERT INTO transfer_limits (
+  owner, account_id, currency, max_amount, daily_amount, daily_count
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetTransferLimit :one
+SELECT * FROM transfer_limits
+WHERE id = $1 LIMIT 1;
+
+-- name: ListApplicableTransferLimits :many
+SELECT * FROM transfer_limits
+WHERE (owner IS NULL OR owner = sqlc.arg(owner)::varchar)
+  AND (account_id IS NULL OR account_id = sqlc.arg(account_id)::bigint)
+  AND currency = sqlc.arg(currency)::varchar
+ORDER BY id;
+
+-- name: ListTransferLimits :many
+SELECT * FROM transfer_limits
+ORDER BY id;
+
+-- name: LockOwnerTransferLimits :exec
+-- Serialises the transfers of one owner, whose accounts share the limits
+-- that are not tied to an account. The lock is held until the surrounding
+-- transaction ends.
+SELECT pg_advisory_xact_lock(hashtext('transfer_limits:' || sqlc.arg(owner)::varchar));
+
+-- name: DeleteTransferLimit :exec
+DELETE FROM transfer_limits
+WHERE id = $1;
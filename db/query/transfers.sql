@@ -1,8 +1,8 @@
 -- name: CreateTransfer :one
 INSERT INTO transfers (
-  from_account_id, to_account_id, amount
+  from_account_id, to_account_id, amount, fee, reference, bulk_transfer_id
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetTransfer :one
@@ -15,3 +15,21 @@ WHERE from_account_id = $1 OR to_account_id = $2
 ORDER BY id
 LIMIT $3
 OFFSET $4;
+
+-- name: GetDailyTransferTotals :one
+SELECT
+  COALESCE(SUM(amount), 0)::bigint AS total_amount,
+  COUNT(*) AS total_count
+FROM transfers
+WHERE from_account_id = $1
+  AND created_at >= date_trunc('day', now());
+
+-- name: GetOwnerDailyTransferTotals :one
+SELECT
+  COALESCE(SUM(transfers.amount), 0)::bigint AS total_amount,
+  COUNT(*) AS total_count
+FROM transfers
+JOIN accounts ON accounts.id = transfers.from_account_id
+WHERE accounts.owner = sqlc.arg(owner)
+  AND accounts.currency = sqlc.arg(currency)
+  AND transfers.created_at >= date_trunc('day', now());
@@ -7,4 +7,75 @@ INSERT INTO users (
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
\ No newline at end of file
+WHERE username = $1 LIMIT 1;
+
+-- name: RecordFailedLogin :one
+UPDATE users
+SET failed_login_attempts = failed_login_attempts + 1
+WHERE username = $1
+RETURNING failed_login_attempts;
+
+-- name: LockUser :exec
+UPDATE users
+SET locked_until = GREATEST(locked_until, sqlc.arg(locked_until))
+WHERE username = sqlc.arg(username);
+
+-- name: ResetFailedLogins :exec
+UPDATE users
+SET failed_login_attempts = 0,
+    locked_until = '0001-01-01 00:00:00Z'
+WHERE username = $1;
+
+-- name: MarkEmailVerified :one
+UPDATE users
+SET is_email_verified = true
+WHERE username = $1 AND email = $2
+RETURNING *;
+
+-- name: GetUserPasswordChangedAt :one
+SELECT password_changed_at FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: UpdateUser :one
+UPDATE users
+SET
+  hashed_password = COALESCE(sqlc.narg(hashed_password), hashed_password),
+  password_changed_at = COALESCE(sqlc.narg(password_changed_at), password_changed_at),
+  fullname = COALESCE(sqlc.narg(fullname), fullname),
+  email = COALESCE(sqlc.narg(email), email),
+  is_email_verified = is_email_verified AND COALESCE(sqlc.narg(email) = email, true)
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = $2
+WHERE username = $1
+  AND totp_enabled = false
+RETURNING *;
+
+-- name: EnableUserTOTP :one
+UPDATE users
+SET totp_enabled = true,
+    totp_last_step = $2
+WHERE username = $1
+  AND totp_secret <> ''
+RETURNING *;
+
+-- name: UseTOTPStep :one
+-- Moves the last accepted step forward. Nothing is returned when step is not
+-- past it, so each code can only be used once.
+UPDATE users
+SET totp_last_step = sqlc.arg(step)
+WHERE username = sqlc.arg(username)
+  AND totp_last_step < sqlc.arg(step)
+RETURNING *;
+
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE;
@@ -0,0 +1,64 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+  owner, url, secret, event_types
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE owner = $1 AND is_active
+ORDER BY id;
+
+-- name: DeactivateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET is_active = false
+WHERE id = $1 AND owner = $2
+RETURNING *;
+
+-- name: ListWebhookEndpointsForEvent :many
+SELECT e.* FROM webhook_endpoints e
+JOIN accounts a ON a.owner = e.owner
+WHERE a.id = sqlc.arg(account_id)
+  AND e.is_active
+  AND (cardinality(e.event_types) = 0 OR sqlc.arg(type)::varchar = ANY(e.event_types))
+ORDER BY e.id;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+  endpoint_id, event_id
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: RecordWebhookAttempt :one
+UPDATE webhook_deliveries
+SET status = sqlc.arg(status),
+    attempts = attempts + 1,
+    response_status = sqlc.arg(response_status),
+    last_error = sqlc.arg(last_error),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ResetFailedWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending',
+    updated_at = now()
+WHERE id = $1 AND status = 'failed'
+RETURNING *;
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// AccountUpdatesChannel is the Postgres channel that committed transfers are
+// announced on, so that every server instance can push them to watchers.
+const AccountUpdatesChannel = "account_updates"
+
+// AccountUpdate is the payload sent on AccountUpdatesChannel: the account
+// as the transaction left it and the entry a transfer added to it. Holds
+// only move the available balance and come without an entry.
+type AccountUpdate struct {
+	Account Account `json:"account"`
+	Entry   *Entry  `json:"entry,omitempty"`
+}
+
+// notifyAccountUpdates announces both sides of a transfer. It runs once the
+// accounts are in their final state for the transaction, which for a hold
+// capture is after the available balance has been given back.
+func notifyAccountUpdates(ctx context.Context, q *Queries, result TransferTxResult) error {
+	updates := []AccountUpdate{
+		{Account: result.FromAccount, Entry: &result.FromEntry},
+		{Account: result.ToAccount, Entry: &result.ToEntry},
+	}
+
+	for _, update := range updates {
+		if err := notifyAccount(ctx, q, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func notifyAccount(ctx context.Context, q *Queries, update AccountUpdate) error {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("cannot marshal account update: %w", err)
+	}
+	return q.NotifyAccountUpdate(ctx, NotifyAccountUpdateParams{
+		Channel: AccountUpdatesChannel,
+		Payload: string(payload),
+	})
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTxNotifiesAccountUpdates(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := creatRandomAccount(t)
+	acc2 := creatRandomAccount(t)
+
+	conn, err := testDB.Acquire(context.Background())
+	require.NoError(t, err)
+	defer conn.Release()
+
+	_, err = conn.Exec(context.Background(), "LISTEN "+AccountUpdatesChannel)
+	require.NoError(t, err)
+	defer conn.Exec(context.Background(), "UNLISTEN "+AccountUpdatesChannel)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	updates := make(map[int64]AccountUpdate)
+	for len(updates) < 2 {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		require.NoError(t, err)
+
+		var update AccountUpdate
+		require.NoError(t, json.Unmarshal([]byte(notification.Payload), &update))
+		if update.Entry != nil && (update.Entry.ID == result.FromEntry.ID || update.Entry.ID == result.ToEntry.ID) {
+			updates[update.Account.ID] = update
+		}
+	}
+
+	require.Equal(t, result.FromAccount.Balance, updates[acc1.ID].Account.Balance)
+	require.Equal(t, result.FromEntry.Amount, updates[acc1.ID].Entry.Amount)
+	require.Equal(t, result.ToAccount.Balance, updates[acc2.ID].Account.Balance)
+	require.Equal(t, result.ToEntry.Amount, updates[acc2.ID].Entry.Amount)
+}
+
+func TestHoldTxNotifiesAccountUpdates(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := creatRandomAccount(t)
+	acc2 := creatRandomAccount(t)
+
+	conn, err := testDB.Acquire(context.Background())
+	require.NoError(t, err)
+	defer conn.Release()
+
+	_, err = conn.Exec(context.Background(), "LISTEN "+AccountUpdatesChannel)
+	require.NoError(t, err)
+	defer conn.Exec(context.Background(), "UNLISTEN "+AccountUpdatesChannel)
+
+	created, err := store.CreateHoldTx(context.Background(), CreateHoldTxParams{
+		AccountID:   acc1.ID,
+		ToAccountID: acc2.ID,
+		Amount:      10,
+		Duration:    time.Minute,
+	})
+	require.NoError(t, err)
+	released, err := store.ReleaseHoldTx(context.Background(), created.Hold.ID)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var balances []int64
+	for len(balances) < 2 {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		require.NoError(t, err)
+
+		var update AccountUpdate
+		require.NoError(t, json.Unmarshal([]byte(notification.Payload), &update))
+		if update.Account.ID == acc1.ID {
+			require.Nil(t, update.Entry)
+			balances = append(balances, update.Account.AvailableBalance)
+		}
+	}
+
+	require.Equal(t, []int64{created.Account.AvailableBalance, released.Account.AvailableBalance}, balances)
+	require.Equal(t, acc1.AvailableBalance-10, balances[0])
+	require.Equal(t, acc1.AvailableBalance, balances[1])
+}
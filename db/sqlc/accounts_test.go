@@ -2,7 +2,6 @@ package db
 
 import (
 	"context"
-	"database/sql"
 	"simplebank/util"
 	"testing"
 	"time"
@@ -22,7 +21,9 @@ func creatRandomAccount(t *testing.T) Account {
 	require.NoError(t, err)
 	require.Equal(t, args.Owner, acc.Owner)
 	require.Equal(t, args.Balance, acc.Balance)
+	require.Equal(t, args.Balance, acc.AvailableBalance)
 	require.Equal(t, args.Currency, acc.Currency)
+	require.True(t, util.ValidAccountNumber(acc.Number))
 
 	require.NotZero(t, acc.ID)
 	require.NotZero(t, acc.CreatedAt)
@@ -47,6 +48,29 @@ func TestGetAccount(t *testing.T) {
 	require.WithinDuration(t, acc1.CreatedAt, acc2.CreatedAt, time.Second)
 }
 
+func TestGetAccountByNumber(t *testing.T) {
+	acc1 := creatRandomAccount(t)
+	acc2, err := testQueries.GetAccountByNumber(context.Background(), acc1.Number)
+
+	require.NoError(t, err)
+	require.Equal(t, acc1.ID, acc2.ID)
+	require.Equal(t, acc1.Number, acc2.Number)
+
+	_, err = testQueries.GetAccountByNumber(context.Background(), util.RandomAccountNumber())
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestGetAccountByOwnerAndCurrency(t *testing.T) {
+	acc1 := creatRandomAccount(t)
+	acc2, err := testQueries.GetAccountByOwnerAndCurrency(context.Background(), GetAccountByOwnerAndCurrencyParams{
+		Owner:    acc1.Owner,
+		Currency: acc1.Currency,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, acc1.ID, acc2.ID)
+}
+
 func TestUpdateAccount(t *testing.T) {
 	acc1 := creatRandomAccount(t)
 	args := UpdateAccountParams{
@@ -60,6 +84,7 @@ func TestUpdateAccount(t *testing.T) {
 	require.Equal(t, acc1.ID, acc2.ID)
 	require.Equal(t, acc1.Owner, acc2.Owner)
 	require.Equal(t, args.Balance, acc2.Balance)
+	require.Equal(t, args.Balance, acc2.AvailableBalance)
 	require.Equal(t, acc1.Currency, acc2.Currency)
 
 	require.WithinDuration(t, acc1.CreatedAt, acc2.CreatedAt, time.Second)
@@ -78,6 +103,7 @@ func TestAddAccountBalance(t *testing.T) {
 	require.Equal(t, acc1.ID, acc2.ID)
 	require.Equal(t, acc1.Owner, acc2.Owner)
 	require.Equal(t, acc2.Balance, acc1.Balance+args.Amount)
+	require.Equal(t, acc2.AvailableBalance, acc1.AvailableBalance+args.Amount)
 	require.Equal(t, acc1.Currency, acc2.Currency)
 
 	require.WithinDuration(t, acc1.CreatedAt, acc2.CreatedAt, time.Second)
@@ -90,7 +116,7 @@ func TestDeleteAccount(t *testing.T) {
 
 	acc2, getErr := testQueries.GetAccount(context.Background(), acc1.ID)
 	require.Error(t, getErr)
-	require.EqualError(t, getErr, sql.ErrNoRows.Error())
+	require.EqualError(t, getErr, ErrRecordNotFound.Error())
 	require.Empty(t, acc2)
 }
 
@@ -0,0 +1,305 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	AuditLoginSucceeded  = "login.succeeded"
+	AuditLoginFailed     = "login.failed"
+	AuditMFAFailed       = "login.mfa_failed"
+	AuditStepUpFailed    = "step_up.failed"
+	AuditUserCreated     = "user.created"
+	AuditUserUpdated     = "user.updated"
+	AuditTokensRevoked   = "tokens.revoked"
+	AuditAccountCreated  = "account.created"
+	AuditTransferCreated = "transfer.created"
+)
+
+// AuditContext says who asked for an audited action and from where. Actor
+// is empty for anonymous requests.
+type AuditContext struct {
+	Actor     string `json:"actor"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	RequestID string `json:"request_id"`
+}
+
+// AuditEvent is what happened. Before and After are marshalled to JSON and
+// may be nil.
+type AuditEvent struct {
+	Action   string
+	Resource string
+	Before   interface{}
+	After    interface{}
+}
+
+func UserResource(username string) string {
+	return "user:" + username
+}
+
+func AccountResource(id int64) string {
+	return fmt.Sprintf("account:%d", id)
+}
+
+func TransferResource(id int64) string {
+	return fmt.Sprintf("transfer:%d", id)
+}
+
+// auditUser is the part of a user that goes into the audit log. Secrets
+// such as password hashes and TOTP seeds are left out.
+type auditUser struct {
+	Username          string    `json:"username"`
+	Fullname          string    `json:"fullname"`
+	Email             string    `json:"email"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	TotpEnabled       bool      `json:"totp_enabled"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+func newAuditUser(user User) auditUser {
+	return auditUser{
+		Username:          user.Username,
+		Fullname:          user.Fullname,
+		Email:             user.Email,
+		Role:              user.Role,
+		IsEmailVerified:   user.IsEmailVerified,
+		TotpEnabled:       user.TotpEnabled,
+		PasswordChangedAt: user.PasswordChangedAt,
+	}
+}
+
+// appendAudit adds event to the end of the hash chain. It must run inside
+// a transaction: the advisory lock it takes is what keeps two appends from
+// chaining onto the same row, and it is only released on commit. Every
+// append in the database therefore waits for the ones before it to commit,
+// so money transactions use queueAudit instead.
+func appendAudit(ctx context.Context, q *Queries, audit AuditContext, event AuditEvent) (AuditLog, error) {
+	entry, err := newAuditEntry(audit, event)
+	if err != nil {
+		return AuditLog{}, err
+	}
+	return chainAudit(ctx, q, entry)
+}
+
+// queueAudit records event in the audit queue. It commits or rolls back with
+// the caller's transaction like appendAudit, but takes no lock; SealAuditLogTx
+// moves the event onto the chain later.
+func queueAudit(ctx context.Context, q *Queries, audit AuditContext, event AuditEvent) error {
+	entry, err := newAuditEntry(audit, event)
+	if err != nil {
+		return err
+	}
+	_, err = q.CreateQueuedAuditLog(ctx, CreateQueuedAuditLogParams{
+		Action:    entry.Action,
+		Actor:     entry.Actor,
+		Resource:  entry.Resource,
+		Ip:        entry.Ip,
+		UserAgent: entry.UserAgent,
+		RequestID: entry.RequestID,
+		Before:    entry.Before,
+		After:     entry.After,
+		CreatedAt: entry.CreatedAt,
+	})
+	return err
+}
+
+func newAuditEntry(audit AuditContext, event AuditEvent) (AuditLog, error) {
+	before, err := json.Marshal(event.Before)
+	if err != nil {
+		return AuditLog{}, fmt.Errorf("cannot marshal audit state: %w", err)
+	}
+	after, err := json.Marshal(event.After)
+	if err != nil {
+		return AuditLog{}, fmt.Errorf("cannot marshal audit state: %w", err)
+	}
+
+	return AuditLog{
+		Action:    event.Action,
+		Actor:     audit.Actor,
+		Resource:  event.Resource,
+		Ip:        audit.IP,
+		UserAgent: audit.UserAgent,
+		RequestID: audit.RequestID,
+		Before:    before,
+		After:     after,
+		// Postgres keeps microseconds; hash what will be read back.
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}, nil
+}
+
+// chainAudit links entry to the latest row and inserts it.
+func chainAudit(ctx context.Context, q *Queries, entry AuditLog) (AuditLog, error) {
+	if err := q.LockAuditLog(ctx); err != nil {
+		return AuditLog{}, err
+	}
+
+	prevHash, err := q.GetLastAuditLogHash(ctx)
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return AuditLog{}, err
+	}
+
+	entry.PrevHash = prevHash
+	entry.Hash = auditHash(entry)
+
+	return q.CreateAuditLog(ctx, CreateAuditLogParams{
+		Action:    entry.Action,
+		Actor:     entry.Actor,
+		Resource:  entry.Resource,
+		Ip:        entry.Ip,
+		UserAgent: entry.UserAgent,
+		RequestID: entry.RequestID,
+		Before:    entry.Before,
+		After:     entry.After,
+		CreatedAt: entry.CreatedAt,
+		PrevHash:  entry.PrevHash,
+		Hash:      entry.Hash,
+	})
+}
+
+// auditHash covers every column but the ID, so that editing any field of a
+// row, or removing a row, breaks the chain from there on.
+func auditHash(entry AuditLog) string {
+	data, _ := json.Marshal(struct {
+		PrevHash  string          `json:"prev_hash"`
+		Action    string          `json:"action"`
+		Actor     string          `json:"actor"`
+		Resource  string          `json:"resource"`
+		IP        string          `json:"ip"`
+		UserAgent string          `json:"user_agent"`
+		RequestID string          `json:"request_id"`
+		Before    json.RawMessage `json:"before"`
+		After     json.RawMessage `json:"after"`
+		CreatedAt string          `json:"created_at"`
+	}{
+		PrevHash:  entry.PrevHash,
+		Action:    entry.Action,
+		Actor:     entry.Actor,
+		Resource:  entry.Resource,
+		IP:        entry.Ip,
+		UserAgent: entry.UserAgent,
+		RequestID: entry.RequestID,
+		Before:    entry.Before,
+		After:     entry.After,
+		CreatedAt: entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AppendAuditTx records an event that has no transaction of its own, such
+// as a login.
+func (s *SQLStore) AppendAuditTx(ctx context.Context, audit AuditContext, event AuditEvent) (AuditLog, error) {
+	var result AuditLog
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+		result, err = appendAudit(ctx, q, audit, event)
+		return err
+	})
+
+	return result, err
+}
+
+// SealAuditLogTx moves up to limit queued events onto the chain in the order
+// they were queued, and returns how many it moved. Queued events are not
+// listed or verified until then.
+func (s *SQLStore) SealAuditLogTx(ctx context.Context, limit int32) (int, error) {
+	var sealed []int64
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		sealed = nil
+
+		// Locking before listing keeps two sealers from chaining the same
+		// events.
+		if err := q.LockAuditLog(ctx); err != nil {
+			return err
+		}
+
+		queued, err := q.ListQueuedAuditLogs(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range queued {
+			_, err := chainAudit(ctx, q, AuditLog{
+				Action:    e.Action,
+				Actor:     e.Actor,
+				Resource:  e.Resource,
+				Ip:        e.Ip,
+				UserAgent: e.UserAgent,
+				RequestID: e.RequestID,
+				Before:    e.Before,
+				After:     e.After,
+				CreatedAt: e.CreatedAt,
+			})
+			if err != nil {
+				return err
+			}
+			sealed = append(sealed, e.ID)
+		}
+
+		if len(sealed) == 0 {
+			return nil
+		}
+		return q.DeleteQueuedAuditLogs(ctx, sealed)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(sealed), nil
+}
+
+type AuditVerification struct {
+	Checked int64 `json:"checked"`
+	Valid   bool  `json:"valid"`
+	// BrokenAt is the ID of the first row that does not match its hash or
+	// does not chain onto the row before it.
+	BrokenAt int64 `json:"broken_at,omitempty"`
+}
+
+const auditVerifyBatch = 1000
+
+// VerifyAuditLog walks the whole chain in ID order and recomputes every
+// hash.
+func (s *SQLStore) VerifyAuditLog(ctx context.Context) (AuditVerification, error) {
+	var result AuditVerification
+
+	var afterID int64
+	prevHash := ""
+	for {
+		entries, err := s.ListAuditLogs(ctx, ListAuditLogsParams{
+			AfterID:  afterID,
+			PageSize: auditVerifyBatch,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		for _, entry := range entries {
+			if entry.PrevHash != prevHash || auditHash(entry) != entry.Hash {
+				result.BrokenAt = entry.ID
+				return result, nil
+			}
+			result.Checked++
+			prevHash = entry.Hash
+			afterID = entry.ID
+		}
+
+		if len(entries) < auditVerifyBatch {
+			result.Valid = true
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: audit_log.sql
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createAuditLog = `-- name: CreateAuditLog :one
+INSERT INTO audit_log (
+  action,
+  actor,
+  resource,
+  ip,
+  user_agent,
+  request_id,
+  before,
+  after,
+  created_at,
+  prev_hash,
+  hash
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING id, action, actor, resource, ip, user_agent, request_id, before, after, created_at, prev_hash, hash
+`
+
+type CreateAuditLogParams struct {
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	Resource  string          `json:"resource"`
+	Ip        string          `json:"ip"`
+	UserAgent string          `json:"user_agent"`
+	RequestID string          `json:"request_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error) {
+	row := q.db.QueryRow(ctx, createAuditLog,
+		arg.Action,
+		arg.Actor,
+		arg.Resource,
+		arg.Ip,
+		arg.UserAgent,
+		arg.RequestID,
+		arg.Before,
+		arg.After,
+		arg.CreatedAt,
+		arg.PrevHash,
+		arg.Hash,
+	)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.Action,
+		&i.Actor,
+		&i.Resource,
+		&i.Ip,
+		&i.UserAgent,
+		&i.RequestID,
+		&i.Before,
+		&i.After,
+		&i.CreatedAt,
+		&i.PrevHash,
+		&i.Hash,
+	)
+	return i, err
+}
+
+const createQueuedAuditLog = `-- name: CreateQueuedAuditLog :one
+INSERT INTO audit_queue (
+  action,
+  actor,
+  resource,
+  ip,
+  user_agent,
+  request_id,
+  before,
+  after,
+  created_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, action, actor, resource, ip, user_agent, request_id, before, after, created_at
+`
+
+type CreateQueuedAuditLogParams struct {
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	Resource  string          `json:"resource"`
+	Ip        string          `json:"ip"`
+	UserAgent string          `json:"user_agent"`
+	RequestID string          `json:"request_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func (q *Queries) CreateQueuedAuditLog(ctx context.Context, arg CreateQueuedAuditLogParams) (AuditQueue, error) {
+	row := q.db.QueryRow(ctx, createQueuedAuditLog,
+		arg.Action,
+		arg.Actor,
+		arg.Resource,
+		arg.Ip,
+		arg.UserAgent,
+		arg.RequestID,
+		arg.Before,
+		arg.After,
+		arg.CreatedAt,
+	)
+	var i AuditQueue
+	err := row.Scan(
+		&i.ID,
+		&i.Action,
+		&i.Actor,
+		&i.Resource,
+		&i.Ip,
+		&i.UserAgent,
+		&i.RequestID,
+		&i.Before,
+		&i.After,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteQueuedAuditLogs = `-- name: DeleteQueuedAuditLogs :exec
+DELETE FROM audit_queue
+WHERE id = ANY($1::bigint[])
+`
+
+func (q *Queries) DeleteQueuedAuditLogs(ctx context.Context, ids []int64) error {
+	_, err := q.db.Exec(ctx, deleteQueuedAuditLogs, ids)
+	return err
+}
+
+const getLastAuditLogHash = `-- name: GetLastAuditLogHash :one
+SELECT hash FROM audit_log
+ORDER BY id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastAuditLogHash(ctx context.Context) (string, error) {
+	row := q.db.QueryRow(ctx, getLastAuditLogHash)
+	var hash string
+	err := row.Scan(&hash)
+	return hash, err
+}
+
+const listAuditLogs = `-- name: ListAuditLogs :many
+SELECT id, action, actor, resource, ip, user_agent, request_id, before, after, created_at, prev_hash, hash FROM audit_log
+WHERE id > $1
+  AND ($2::varchar IS NULL OR actor = $2)
+  AND ($3::varchar IS NULL OR action = $3)
+  AND ($4::varchar IS NULL OR resource = $4)
+ORDER BY id
+LIMIT $5
+`
+
+type ListAuditLogsParams struct {
+	AfterID  int64       `json:"after_id"`
+	Actor    pgtype.Text `json:"actor"`
+	Action   pgtype.Text `json:"action"`
+	Resource pgtype.Text `json:"resource"`
+	PageSize int32       `json:"page_size"`
+}
+
+func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, listAuditLogs,
+		arg.AfterID,
+		arg.Actor,
+		arg.Action,
+		arg.Resource,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.Actor,
+			&i.Resource,
+			&i.Ip,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Before,
+			&i.After,
+			&i.CreatedAt,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQueuedAuditLogs = `-- name: ListQueuedAuditLogs :many
+SELECT id, action, actor, resource, ip, user_agent, request_id, before, after, created_at FROM audit_queue
+ORDER BY id
+LIMIT $1
+`
+
+func (q *Queries) ListQueuedAuditLogs(ctx context.Context, limit int32) ([]AuditQueue, error) {
+	rows, err := q.db.Query(ctx, listQueuedAuditLogs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditQueue{}
+	for rows.Next() {
+		var i AuditQueue
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.Actor,
+			&i.Resource,
+			&i.Ip,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Before,
+			&i.After,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockAuditLog = `-- name: LockAuditLog :exec
+SELECT pg_advisory_xact_lock(hashtext('audit_log'))
+`
+
+// Serialises appends so that every row chains onto the latest one. The
+// lock is held until the surrounding transaction ends.
+func (q *Queries) LockAuditLog(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, lockAuditLog)
+	return err
+}
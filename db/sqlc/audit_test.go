@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func randomAuditContext() AuditContext {
+	return AuditContext{
+		Actor:     util.RandomOwner(),
+		IP:        "203.0.113.7",
+		UserAgent: "simplebank-test",
+		RequestID: util.RandomString(16),
+	}
+}
+
+func TestAppendAuditTx(t *testing.T) {
+	store := NewStore(testDB)
+	audit := randomAuditContext()
+
+	entry1, err := store.AppendAuditTx(context.Background(), audit, AuditEvent{
+		Action:   AuditLoginSucceeded,
+		Resource: UserResource(audit.Actor),
+	})
+	require.NoError(t, err)
+	require.Equal(t, AuditLoginSucceeded, entry1.Action)
+	require.Equal(t, audit.Actor, entry1.Actor)
+	require.Equal(t, audit.IP, entry1.Ip)
+	require.Equal(t, audit.UserAgent, entry1.UserAgent)
+	require.Equal(t, audit.RequestID, entry1.RequestID)
+	require.JSONEq(t, "null", string(entry1.Before))
+	require.Equal(t, auditHash(entry1), entry1.Hash)
+
+	entry2, err := store.AppendAuditTx(context.Background(), audit, AuditEvent{
+		Action:   AuditLoginFailed,
+		Resource: UserResource(audit.Actor),
+		After:    map[string]int{"failed_login_attempts": 1},
+	})
+	require.NoError(t, err)
+	require.Equal(t, entry1.Hash, entry2.PrevHash)
+	require.JSONEq(t, `{"failed_login_attempts":1}`, string(entry2.After))
+
+	result, err := store.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Valid)
+	require.GreaterOrEqual(t, result.Checked, int64(2))
+}
+
+func TestAppendAuditTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	n := 10
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.AppendAuditTx(context.Background(), randomAuditContext(), AuditEvent{
+				Action: AuditLoginSucceeded,
+			})
+			errs <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	result, err := store.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Valid)
+}
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	store := NewStore(testDB)
+
+	entry, err := store.AppendAuditTx(context.Background(), randomAuditContext(), AuditEvent{
+		Action: AuditLoginSucceeded,
+	})
+	require.NoError(t, err)
+
+	_, err = testDB.Exec(context.Background(), "UPDATE audit_log SET actor = 'mallory' WHERE id = $1", entry.ID)
+	require.ErrorContains(t, err, "append-only")
+
+	_, err = testDB.Exec(context.Background(), "DELETE FROM audit_log WHERE id = $1", entry.ID)
+	require.ErrorContains(t, err, "append-only")
+}
+
+func TestAuditHashCoversRow(t *testing.T) {
+	entry := AuditLog{
+		Action:    AuditTransferCreated,
+		Actor:     util.RandomOwner(),
+		Resource:  TransferResource(1),
+		Ip:        "203.0.113.7",
+		RequestID: util.RandomString(16),
+		Before:    json.RawMessage(`{"balance":10}`),
+		After:     json.RawMessage(`{"balance":5}`),
+		CreatedAt: time.Now().UTC(),
+		PrevHash:  util.RandomString(64),
+	}
+	hash := auditHash(entry)
+	require.Len(t, hash, 64)
+
+	tampered := []func(e *AuditLog){
+		func(e *AuditLog) { e.Action = AuditLoginSucceeded },
+		func(e *AuditLog) { e.Actor = "mallory" },
+		func(e *AuditLog) { e.Resource = TransferResource(2) },
+		func(e *AuditLog) { e.Ip = "198.51.100.1" },
+		func(e *AuditLog) { e.UserAgent = "curl" },
+		func(e *AuditLog) { e.RequestID = "other" },
+		func(e *AuditLog) { e.Before = json.RawMessage(`{"balance":11}`) },
+		func(e *AuditLog) { e.After = json.RawMessage(`{"balance":6}`) },
+		func(e *AuditLog) { e.CreatedAt = e.CreatedAt.Add(time.Microsecond) },
+		func(e *AuditLog) { e.PrevHash = "" },
+	}
+	for _, tamper := range tampered {
+		e := entry
+		tamper(&e)
+		require.NotEqual(t, hash, auditHash(e))
+	}
+
+	// The hash must not depend on the zone the timestamp is read back in.
+	e := entry
+	e.CreatedAt = e.CreatedAt.In(time.FixedZone("WAT", 3600))
+	require.Equal(t, hash, auditHash(e))
+}
+
+func TestTransferTxIsAudited(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := creatRandomAccount(t)
+	acc2 := creatRandomAccount(t)
+	audit := randomAuditContext()
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        1,
+		Audit:         audit,
+	})
+	require.NoError(t, err)
+
+	arg := ListAuditLogsParams{
+		Resource: pgtype.Text{String: TransferResource(result.Transfer.ID), Valid: true},
+		PageSize: 10,
+	}
+	entries, err := testQueries.ListAuditLogs(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	sealAuditLog(t, store)
+
+	entries, err = testQueries.ListAuditLogs(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, AuditTransferCreated, entries[0].Action)
+	require.Equal(t, audit.Actor, entries[0].Actor)
+
+	var before, after auditTransfer
+	require.NoError(t, json.Unmarshal(entries[0].Before, &before))
+	require.NoError(t, json.Unmarshal(entries[0].After, &after))
+	require.Equal(t, acc1.Balance, before.FromAccount.Balance)
+	require.Equal(t, acc1.Balance-1, after.FromAccount.Balance)
+	require.Equal(t, result.Transfer.ID, after.Transfer.ID)
+}
+
+// TestTransferTxSkipsAuditLock makes sure money transactions never wait on
+// the audit chain's global lock, and that the events they queue are chained
+// once it is free.
+func TestTransferTxSkipsAuditLock(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := creatRandomAccount(t)
+	acc2 := creatRandomAccount(t)
+
+	tx, err := testDB.Begin(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback(context.Background())
+	require.NoError(t, New(tx).LockAuditLog(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        1,
+		Audit:         randomAuditContext(),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, tx.Rollback(context.Background()))
+	sealAuditLog(t, store)
+
+	entries, err := testQueries.ListAuditLogs(context.Background(), ListAuditLogsParams{
+		Resource: pgtype.Text{String: TransferResource(result.Transfer.ID), Valid: true},
+		PageSize: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	verification, err := store.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	require.True(t, verification.Valid)
+}
+
+func sealAuditLog(t *testing.T, store Store) {
+	for {
+		n, err := store.SealAuditLogTx(context.Background(), 1000)
+		require.NoError(t, err)
+		if n < 1000 {
+			return
+		}
+	}
+}
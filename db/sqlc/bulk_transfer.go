@@ -0,0 +1,287 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simplebank/metrics"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// BulkModeAtomic runs every item or none of them.
+	BulkModeAtomic = "atomic"
+	// BulkModePerItem runs every valid item on its own, so that a failing
+	// item does not stop the others.
+	BulkModePerItem = "per_item"
+)
+
+const (
+	BulkItemSucceeded = "succeeded"
+	BulkItemFailed    = "failed"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("account currency mismatch")
+	ErrSameAccount      = errors.New("cannot transfer to the source account")
+)
+
+// BulkTransferItemError tells which item made an atomic bulk transfer fail.
+type BulkTransferItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BulkTransferItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *BulkTransferItemError) Unwrap() error {
+	return e.Err
+}
+
+type BulkTransferItem struct {
+	ToAccountID int64    `json:"to_account_id"`
+	Amount      int64    `json:"amount"`
+	Reference   string   `json:"reference"`
+	Quote       FeeQuote `json:"quote"`
+}
+
+type BulkTransferTxParams struct {
+	FromAccountID int64              `json:"from_account_id"`
+	Mode          string             `json:"mode"`
+	Items         []BulkTransferItem `json:"items"`
+	Audit         AuditContext       `json:"-"`
+}
+
+type BulkTransferItemResult struct {
+	Index    int       `json:"index"`
+	Status   string    `json:"status"`
+	Transfer *Transfer `json:"transfer,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Err      error     `json:"-"`
+}
+
+type BulkTransferTxResult struct {
+	BulkTransfer BulkTransfer             `json:"bulk_transfer"`
+	FromAccount  Account                  `json:"from_account"`
+	Items        []BulkTransferItemResult `json:"items"`
+}
+
+// BulkTransferTx pays every item from one account in a single transaction
+// that locks all the accounts involved once, up front. In atomic mode the
+// first invalid or failing item rolls everything back with a
+// *BulkTransferItemError. In per-item mode every item runs in its own
+// savepoint and failures are only reported in its result.
+func (s *SQLStore) BulkTransferTx(ctx context.Context, arg BulkTransferTxParams) (BulkTransferTxResult, error) {
+	var result BulkTransferTxResult
+
+	start := time.Now()
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		result = BulkTransferTxResult{Items: make([]BulkTransferItemResult, len(arg.Items))}
+
+		locked, err := lockBulkAccounts(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		result.BulkTransfer, err = q.CreateBulkTransfer(ctx, CreateBulkTransferParams{
+			FromAccountID: arg.FromAccountID,
+			Mode:          arg.Mode,
+			ItemCount:     int32(len(arg.Items)),
+		})
+		if err != nil {
+			return err
+		}
+		bulkID := pgtype.Int8{Int64: result.BulkTransfer.ID, Valid: true}
+
+		if arg.Mode == BulkModeAtomic {
+			for i, item := range arg.Items {
+				if err := validBulkItem(locked, arg.FromAccountID, item); err != nil {
+					return &BulkTransferItemError{Index: i, Err: err}
+				}
+			}
+		}
+
+		var succeeded int32
+		for i, item := range arg.Items {
+			itemResult := &result.Items[i]
+			itemResult.Index = i
+
+			transfer, err := bulkTransferItem(ctx, q, locked, arg, item, bulkID)
+			if err != nil {
+				if arg.Mode == BulkModeAtomic {
+					return &BulkTransferItemError{Index: i, Err: err}
+				}
+				var itemErr *bulkItemFailure
+				if !errors.As(err, &itemErr) {
+					return err
+				}
+				itemResult.Status = BulkItemFailed
+				itemResult.Error = itemErr.err.Error()
+				itemResult.Err = itemErr.err
+				continue
+			}
+
+			itemResult.Status = BulkItemSucceeded
+			itemResult.Transfer = &transfer
+			succeeded++
+		}
+
+		result.BulkTransfer, err = q.SetBulkTransferSucceeded(ctx, SetBulkTransferSucceededParams{
+			ID:        result.BulkTransfer.ID,
+			Succeeded: succeeded,
+		})
+		if err != nil {
+			return err
+		}
+		result.FromAccount = locked[arg.FromAccountID]
+		return nil
+	})
+	metrics.TransferTxDuration.Observe(time.Since(start).Seconds())
+	observeBulkTransfer(result, err)
+
+	return result, err
+}
+
+// bulkItemFailure marks an error that only failed its own item, after which
+// the transaction is still usable.
+type bulkItemFailure struct {
+	err error
+}
+
+func (e *bulkItemFailure) Error() string {
+	return e.err.Error()
+}
+
+func (e *bulkItemFailure) Unwrap() error {
+	return e.err
+}
+
+func bulkTransferItem(ctx context.Context, q *Queries, locked map[int64]Account, arg BulkTransferTxParams, item BulkTransferItem, bulkID pgtype.Int8) (Transfer, error) {
+	if err := validBulkItem(locked, arg.FromAccountID, item); err != nil {
+		return Transfer{}, &bulkItemFailure{err}
+	}
+
+	params := TransferTxParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   item.ToAccountID,
+		Amount:        item.Amount,
+		Quote:         item.Quote,
+		Reference:     item.Reference,
+		Audit:         arg.Audit,
+	}
+	run := func() (TransferTxResult, error) {
+		result, err := applyTransfer(ctx, q, locked, params, bulkID)
+		if err != nil {
+			return result, err
+		}
+		return result, notifyAccountUpdates(ctx, q, result)
+	}
+
+	if arg.Mode == BulkModeAtomic {
+		result, err := run()
+		return result.Transfer, err
+	}
+
+	// A rolled back item must leave locked as it found it.
+	saved := make(map[int64]Account, 3)
+	for _, id := range []int64{arg.FromAccountID, item.ToAccountID, item.Quote.FeeAccountID} {
+		if acc, ok := locked[id]; ok {
+			saved[id] = acc
+		}
+	}
+
+	var result TransferTxResult
+	err := withSavepoint(ctx, q, func() error {
+		var err error
+		result, err = run()
+		return err
+	})
+	if err != nil {
+		for id, acc := range saved {
+			locked[id] = acc
+		}
+	}
+	return result.Transfer, err
+}
+
+// withSavepoint runs fn in a savepoint and rolls back to it when fn fails.
+// The error of fn comes back as a *bulkItemFailure unless the transaction has
+// to be given up anyway: when rolling back fails, or for the errors that
+// execTx retries the whole transaction on.
+func withSavepoint(ctx context.Context, q *Queries, fn func() error) error {
+	if _, err := q.db.Exec(ctx, "SAVEPOINT bulk_item"); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		if _, retryable := retryableTxError(err); retryable {
+			return err
+		}
+		if _, rberr := q.db.Exec(ctx, "ROLLBACK TO SAVEPOINT bulk_item"); rberr != nil {
+			return fmt.Errorf("item err: %w, rb err: %v", err, rberr)
+		}
+		return &bulkItemFailure{err}
+	}
+
+	_, err := q.db.Exec(ctx, "RELEASE SAVEPOINT bulk_item")
+	return err
+}
+
+// lockBulkAccounts locks the source, destination and fee accounts of every
+// item in ascending ID order, like lockAccounts. Destinations that do not
+// exist are left out and fail their item later on.
+func lockBulkAccounts(ctx context.Context, q *Queries, arg BulkTransferTxParams) (map[int64]Account, error) {
+	ids := []int64{arg.FromAccountID}
+	for _, item := range arg.Items {
+		ids = append(ids, item.ToAccountID)
+		if item.Quote.Fee > 0 {
+			ids = append(ids, item.Quote.FeeAccountID)
+		}
+	}
+
+	existing, err := q.ListExistingAccountIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	locked, err := lockAccounts(ctx, q, existing)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := locked[arg.FromAccountID]; !ok {
+		return nil, ErrRecordNotFound
+	}
+	return locked, nil
+}
+
+func validBulkItem(locked map[int64]Account, fromAccountID int64, item BulkTransferItem) error {
+	if item.ToAccountID == fromAccountID {
+		return ErrSameAccount
+	}
+	to, ok := locked[item.ToAccountID]
+	if !ok {
+		return ErrRecordNotFound
+	}
+	if to.Currency != locked[fromAccountID].Currency {
+		return ErrCurrencyMismatch
+	}
+	return nil
+}
+
+func observeBulkTransfer(result BulkTransferTxResult, err error) {
+	if err != nil {
+		observeTransfer(TransferTxResult{}, err)
+		return
+	}
+	for _, item := range result.Items {
+		if item.Transfer == nil {
+			observeTransfer(TransferTxResult{}, item.Err)
+			continue
+		}
+		observeTransfer(TransferTxResult{Transfer: *item.Transfer, FromAccount: result.FromAccount}, nil)
+	}
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createAccountInCurrency(t *testing.T, currency string) Account {
+	user := creatRandomUser(t)
+	acc, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  1000,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	return acc
+}
+
+func TestBulkTransferTxPerItem(t *testing.T) {
+	store := NewStore(testDB)
+	from := createAccountInCurrency(t, "USD")
+	to1 := createAccountInCurrency(t, "USD")
+	to2 := createAccountInCurrency(t, "USD")
+	other := createAccountInCurrency(t, "EUR")
+
+	result, err := store.BulkTransferTx(context.Background(), BulkTransferTxParams{
+		FromAccountID: from.ID,
+		Mode:          BulkModePerItem,
+		Items: []BulkTransferItem{
+			{ToAccountID: to1.ID, Amount: 10, Reference: "salary 1"},
+			{ToAccountID: other.ID, Amount: 20, Reference: "salary 2"},
+			{ToAccountID: to2.ID, Amount: 30, Reference: "salary 3"},
+			{ToAccountID: from.ID, Amount: 40},
+		},
+		Audit: randomAuditContext(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(4), result.BulkTransfer.ItemCount)
+	require.Equal(t, int32(2), result.BulkTransfer.Succeeded)
+	require.Equal(t, from.Balance-40, result.FromAccount.Balance)
+
+	require.Len(t, result.Items, 4)
+	require.Equal(t, BulkItemSucceeded, result.Items[0].Status)
+	require.Equal(t, "salary 1", result.Items[0].Transfer.Reference)
+	require.Equal(t, BulkItemFailed, result.Items[1].Status)
+	require.ErrorIs(t, result.Items[1].Err, ErrCurrencyMismatch)
+	require.Equal(t, BulkItemSucceeded, result.Items[2].Status)
+	require.Equal(t, BulkItemFailed, result.Items[3].Status)
+	require.ErrorIs(t, result.Items[3].Err, ErrSameAccount)
+
+	transfers, err := testQueries.ListBulkTransferItems(context.Background(), pgtype.Int8{Int64: result.BulkTransfer.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.Equal(t, to1.ID, transfers[0].ToAccountID)
+	require.Equal(t, to2.ID, transfers[1].ToAccountID)
+
+	acc, err := testQueries.GetAccount(context.Background(), to2.ID)
+	require.NoError(t, err)
+	require.Equal(t, to2.Balance+30, acc.Balance)
+}
+
+func TestBulkTransferTxPerItemLimit(t *testing.T) {
+	store := NewStore(testDB)
+	from := createAccountInCurrency(t, "USD")
+	to := createAccountInCurrency(t, "USD")
+
+	_, err := testQueries.CreateTransferLimit(context.Background(), CreateTransferLimitParams{
+		AccountID: pgtype.Int8{Int64: from.ID, Valid: true},
+		Currency:  from.Currency,
+		MaxAmount: 50,
+	})
+	require.NoError(t, err)
+
+	result, err := store.BulkTransferTx(context.Background(), BulkTransferTxParams{
+		FromAccountID: from.ID,
+		Mode:          BulkModePerItem,
+		Items: []BulkTransferItem{
+			{ToAccountID: to.ID, Amount: 100},
+			{ToAccountID: to.ID, Amount: 10},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), result.BulkTransfer.Succeeded)
+
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, result.Items[0].Err, &limitErr)
+	require.Equal(t, LimitMaxAmount, limitErr.Limit)
+	require.Equal(t, from.Balance-10, result.FromAccount.Balance)
+}
+
+func TestBulkTransferTxAtomic(t *testing.T) {
+	store := NewStore(testDB)
+	from := createAccountInCurrency(t, "USD")
+	to := createAccountInCurrency(t, "USD")
+
+	_, err := store.BulkTransferTx(context.Background(), BulkTransferTxParams{
+		FromAccountID: from.ID,
+		Mode:          BulkModeAtomic,
+		Items: []BulkTransferItem{
+			{ToAccountID: to.ID, Amount: 10},
+			{ToAccountID: to.ID + 1_000_000, Amount: 10},
+		},
+	})
+	var itemErr *BulkTransferItemError
+	require.ErrorAs(t, err, &itemErr)
+	require.Equal(t, 1, itemErr.Index)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	acc, err := testQueries.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance, acc.Balance)
+
+	result, err := store.BulkTransferTx(context.Background(), BulkTransferTxParams{
+		FromAccountID: from.ID,
+		Mode:          BulkModeAtomic,
+		Items: []BulkTransferItem{
+			{ToAccountID: to.ID, Amount: 10},
+			{ToAccountID: to.ID, Amount: 15},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), result.BulkTransfer.Succeeded)
+	require.Equal(t, from.Balance-25, result.FromAccount.Balance)
+}
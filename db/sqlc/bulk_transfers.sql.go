@@ -0,0 +1,101 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: bulk_transfers.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createBulkTransfer = `-- name: CreateBulkTransfer :one
+INSERT INTO bulk_transfers (
+  from_account_id, mode, item_count, succeeded
+) VALUES (
+  $1, $2, $3, 0
+) RETURNING id, from_account_id, mode, item_count, succeeded, created_at
+`
+
+type CreateBulkTransferParams struct {
+	FromAccountID int64  `json:"from_account_id"`
+	Mode          string `json:"mode"`
+	ItemCount     int32  `json:"item_count"`
+}
+
+func (q *Queries) CreateBulkTransfer(ctx context.Context, arg CreateBulkTransferParams) (BulkTransfer, error) {
+	row := q.db.QueryRow(ctx, createBulkTransfer, arg.FromAccountID, arg.Mode, arg.ItemCount)
+	var i BulkTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.Mode,
+		&i.ItemCount,
+		&i.Succeeded,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listBulkTransferItems = `-- name: ListBulkTransferItems :many
+SELECT id, from_account_id, to_account_id, amount, created_at, fee, reference, bulk_transfer_id FROM transfers
+WHERE bulk_transfer_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListBulkTransferItems(ctx context.Context, bulkTransferID pgtype.Int8) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listBulkTransferItems, bulkTransferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Fee,
+			&i.Reference,
+			&i.BulkTransferID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setBulkTransferSucceeded = `-- name: SetBulkTransferSucceeded :one
+UPDATE bulk_transfers
+SET succeeded = $2
+WHERE id = $1
+RETURNING id, from_account_id, mode, item_count, succeeded, created_at
+`
+
+type SetBulkTransferSucceededParams struct {
+	ID        int64 `json:"id"`
+	Succeeded int32 `json:"succeeded"`
+}
+
+func (q *Queries) SetBulkTransferSucceeded(ctx context.Context, arg SetBulkTransferSucceededParams) (BulkTransfer, error) {
+	row := q.db.QueryRow(ctx, setBulkTransferSucceeded, arg.ID, arg.Succeeded)
+	var i BulkTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.Mode,
+		&i.ItemCount,
+		&i.Succeeded,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CreateAccountTxParams struct {
+	CreateAccountParams
+	Audit AuditContext
+}
+
+func (s *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error) {
+	var result Account
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+		result, err = q.CreateAccount(ctx, arg.CreateAccountParams)
+		if err != nil {
+			return err
+		}
+
+		err = createOutboxEvents(ctx, q, accountEvent{result.ID, EventAccountCreated, result})
+		if err != nil {
+			return err
+		}
+
+		_, err = appendAudit(ctx, q, arg.Audit, AuditEvent{
+			Action:   AuditAccountCreated,
+			Resource: AccountResource(result.ID),
+			After:    result,
+		})
+		return err
+	})
+
+	return result, err
+}
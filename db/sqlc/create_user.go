@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CreateUserTxParams struct {
+	CreateUserParams
+	// AfterCreate runs inside the transaction with q bound to it, so that
+	// work queued there is committed or rolled back together with the user.
+	AfterCreate func(q Querier, user User) error
+	Audit       AuditContext
+}
+
+type CreateUserTxResult struct {
+	User User `json:"user"`
+}
+
+func (s *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		_, err = appendAudit(ctx, q, arg.Audit, AuditEvent{
+			Action:   AuditUserCreated,
+			Resource: UserResource(result.User.Username),
+			After:    newAuditUser(result.User),
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.AfterCreate == nil {
+			return nil
+		}
+		return arg.AfterCreate(q, result.User)
+	})
+
+	return result, err
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomCreateUserParams() CreateUserParams {
+	return CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "123456",
+		Fullname:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+}
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	var task Task
+	arg := CreateUserTxParams{
+		CreateUserParams: randomCreateUserParams(),
+		AfterCreate: func(q Querier, user User) error {
+			var err error
+			task, err = q.CreateTask(context.Background(), CreateTaskParams{
+				Queue:       "default",
+				Type:        "test",
+				Payload:     []byte(`{"username":"` + user.Username + `"}`),
+				MaxAttempts: 1,
+				RunAt:       user.CreatedAt,
+			})
+			return err
+		},
+	}
+
+	result, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, result.User.Username)
+	require.False(t, result.User.IsEmailVerified)
+
+	_, err = testQueries.GetTask(context.Background(), task.ID)
+	require.NoError(t, err)
+}
+
+func TestCreateUserTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+	errAfterCreate := errors.New("cannot enqueue")
+
+	arg := CreateUserTxParams{
+		CreateUserParams: randomCreateUserParams(),
+		AfterCreate: func(q Querier, user User) error {
+			return errAfterCreate
+		},
+	}
+
+	_, err := store.CreateUserTx(context.Background(), arg)
+	require.ErrorIs(t, err, errAfterCreate)
+
+	_, err = testQueries.GetUser(context.Background(), arg.Username)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type EnrollTOTPTxParams struct {
+	Username         string   `json:"username"`
+	TOTPSecret       string   `json:"totp_secret"`
+	BackupCodeHashes []string `json:"backup_code_hashes"`
+}
+
+type EnrollTOTPTxResult struct {
+	User User `json:"user"`
+}
+
+// EnrollTOTPTx stores a pending TOTP secret and replaces the user's backup
+// codes. The secret only takes effect once EnableUserTOTP confirms it. It
+// fails with ErrRecordNotFound when TOTP is already enabled.
+func (s *SQLStore) EnrollTOTPTx(ctx context.Context, arg EnrollTOTPTxParams) (EnrollTOTPTxResult, error) {
+	var result EnrollTOTPTxResult
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+		result.User, err = q.SetUserTOTPSecret(ctx, SetUserTOTPSecretParams{
+			Username:   arg.Username,
+			TotpSecret: arg.TOTPSecret,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteBackupCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.BackupCodeHashes {
+			_, err = q.CreateBackupCode(ctx, CreateBackupCodeParams{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrollTOTPTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := creatRandomUser(t)
+
+	arg := EnrollTOTPTxParams{
+		Username:         user.Username,
+		TOTPSecret:       util.RandomString(32),
+		BackupCodeHashes: []string{util.HashToken("code1"), util.HashToken("code2")},
+	}
+	result, err := store.EnrollTOTPTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.TOTPSecret, result.User.TotpSecret)
+	require.False(t, result.User.TotpEnabled)
+
+	// Enrolling again replaces the secret and the backup codes.
+	arg.TOTPSecret = util.RandomString(32)
+	arg.BackupCodeHashes = []string{util.HashToken("code3")}
+	result, err = store.EnrollTOTPTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.TOTPSecret, result.User.TotpSecret)
+
+	_, err = testQueries.UseBackupCode(context.Background(), UseBackupCodeParams{
+		Username: user.Username,
+		CodeHash: util.HashToken("code1"),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	code, err := testQueries.UseBackupCode(context.Background(), UseBackupCodeParams{
+		Username: user.Username,
+		CodeHash: util.HashToken("code3"),
+	})
+	require.NoError(t, err)
+	require.True(t, code.IsUsed)
+
+	// Backup codes are single use.
+	_, err = testQueries.UseBackupCode(context.Background(), UseBackupCodeParams{
+		Username: user.Username,
+		CodeHash: util.HashToken("code3"),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	enabled, err := testQueries.EnableUserTOTP(context.Background(), EnableUserTOTPParams{
+		Username:     user.Username,
+		TotpLastStep: 100,
+	})
+	require.NoError(t, err)
+	require.True(t, enabled.TotpEnabled)
+	require.Equal(t, int64(100), enabled.TotpLastStep)
+
+	// Once enabled, the secret cannot be swapped out.
+	_, err = store.EnrollTOTPTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	user := creatRandomUser(t)
+
+	used, err := testQueries.UseTOTPStep(context.Background(), UseTOTPStepParams{
+		Username: user.Username,
+		Step:     100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), used.TotpLastStep)
+
+	// The same step, or an earlier one, is a replay.
+	for _, step := range []int64{100, 99} {
+		_, err = testQueries.UseTOTPStep(context.Background(), UseTOTPStepParams{
+			Username: user.Username,
+			Step:     step,
+		})
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	}
+
+	used, err = testQueries.UseTOTPStep(context.Background(), UseTOTPStepParams{
+		Username: user.Username,
+		Step:     101,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(101), used.TotpLastStep)
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	ForeignKeyViolation  = "23503"
+	UniqueViolation      = "23505"
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+)
+
+var ErrRecordNotFound = pgx.ErrNoRows
+
+var ErrUniqueViolation = &pgconn.PgError{
+	Code: UniqueViolation,
+}
+
+// ErrorCode returns the SQLSTATE of a database error, or an empty string
+// when err did not come from the database.
+func ErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
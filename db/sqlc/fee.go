@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"simplebank/util"
+)
+
+// ErrFeeAccountCurrencyMismatch means the fee rule of a currency credits an
+// account in another currency. SetFeeRule refuses to write such a rule, but
+// one written straight to the database stops its transfers instead.
+var ErrFeeAccountCurrencyMismatch = errors.New("fee account currency does not match the fee rule")
+
+type FeeQuote struct {
+	Currency     string `json:"currency"`
+	Amount       int64  `json:"amount"`
+	Fee          int64  `json:"fee"`
+	Total        int64  `json:"total"`
+	FeeAccountID int64  `json:"-"`
+}
+
+// QuoteFee prices a transfer using the fee rule of its currency. A currency
+// without a rule is free to transfer.
+func (s *SQLStore) QuoteFee(ctx context.Context, currency string, amount int64) (FeeQuote, error) {
+	quote := FeeQuote{
+		Currency: currency,
+		Amount:   amount,
+		Total:    amount,
+	}
+
+	rule, err := s.GetFeeRule(ctx, currency)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return quote, nil
+		}
+		return quote, err
+	}
+
+	feeAccount, err := s.GetAccount(ctx, rule.FeeAccountID)
+	if err != nil {
+		return quote, err
+	}
+	if feeAccount.Currency != currency {
+		return quote, ErrFeeAccountCurrencyMismatch
+	}
+
+	quote.Fee = util.CalculateFee(amount, rule.FlatFee, rule.PercentBps, rule.MaxFee)
+	quote.Total = amount + quote.Fee
+	quote.FeeAccountID = rule.FeeAccountID
+	return quote, nil
+}
+
+// SetFeeRule creates or replaces the fee rule of a currency. The fees are
+// credited to the fee account as they are, so it must hold that currency.
+func (s *SQLStore) SetFeeRule(ctx context.Context, arg UpsertFeeRuleParams) (FeeRule, error) {
+	feeAccount, err := s.GetAccount(ctx, arg.FeeAccountID)
+	if err != nil {
+		return FeeRule{}, err
+	}
+	if feeAccount.Currency != arg.Currency {
+		return FeeRule{}, ErrFeeAccountCurrencyMismatch
+	}
+	return s.UpsertFeeRule(ctx, arg)
+}
@@ -0,0 +1,104 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: fee_rules.sql
+
+package db
+
+import (
+	"context"
+)
+
+const getFeeRule = `-- name: GetFeeRule :one
+SELECT currency, flat_fee, percent_bps, max_fee, fee_account_id, updated_at FROM fee_rules
+WHERE currency = $1 LIMIT 1
+`
+
+func (q *Queries) GetFeeRule(ctx context.Context, currency string) (FeeRule, error) {
+	row := q.db.QueryRow(ctx, getFeeRule, currency)
+	var i FeeRule
+	err := row.Scan(
+		&i.Currency,
+		&i.FlatFee,
+		&i.PercentBps,
+		&i.MaxFee,
+		&i.FeeAccountID,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listFeeRules = `-- name: ListFeeRules :many
+SELECT currency, flat_fee, percent_bps, max_fee, fee_account_id, updated_at FROM fee_rules
+ORDER BY currency
+`
+
+func (q *Queries) ListFeeRules(ctx context.Context) ([]FeeRule, error) {
+	rows, err := q.db.Query(ctx, listFeeRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FeeRule{}
+	for rows.Next() {
+		var i FeeRule
+		if err := rows.Scan(
+			&i.Currency,
+			&i.FlatFee,
+			&i.PercentBps,
+			&i.MaxFee,
+			&i.FeeAccountID,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertFeeRule = `-- name: UpsertFeeRule :one
+INSERT INTO fee_rules (
+  currency, flat_fee, percent_bps, max_fee, fee_account_id
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (currency) DO UPDATE
+SET flat_fee = EXCLUDED.flat_fee,
+  percent_bps = EXCLUDED.percent_bps,
+  max_fee = EXCLUDED.max_fee,
+  fee_account_id = EXCLUDED.fee_account_id,
+  updated_at = now()
+RETURNING currency, flat_fee, percent_bps, max_fee, fee_account_id, updated_at
+`
+
+type UpsertFeeRuleParams struct {
+	Currency     string `json:"currency"`
+	FlatFee      int64  `json:"flat_fee"`
+	PercentBps   int64  `json:"percent_bps"`
+	MaxFee       int64  `json:"max_fee"`
+	FeeAccountID int64  `json:"fee_account_id"`
+}
+
+func (q *Queries) UpsertFeeRule(ctx context.Context, arg UpsertFeeRuleParams) (FeeRule, error) {
+	row := q.db.QueryRow(ctx, upsertFeeRule,
+		arg.Currency,
+		arg.FlatFee,
+		arg.PercentBps,
+		arg.MaxFee,
+		arg.FeeAccountID,
+	)
+	var i FeeRule
+	err := row.Scan(
+		&i.Currency,
+		&i.FlatFee,
+		&i.PercentBps,
+		&i.MaxFee,
+		&i.FeeAccountID,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
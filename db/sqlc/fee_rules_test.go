@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func upsertRandomFeeRule(t *testing.T) FeeRule {
+	feeAccount := creatRandomAccount(t)
+	args := UpsertFeeRuleParams{
+		Currency:     feeAccount.Currency,
+		FlatFee:      int64(util.RandomInt(0, 50)),
+		PercentBps:   int64(util.RandomInt(0, 200)),
+		MaxFee:       int64(util.RandomInt(100, 500)),
+		FeeAccountID: feeAccount.ID,
+	}
+
+	rule, err := testQueries.UpsertFeeRule(context.Background(), args)
+	require.NoError(t, err)
+	require.Equal(t, args.Currency, rule.Currency)
+	require.Equal(t, args.FlatFee, rule.FlatFee)
+	require.Equal(t, args.PercentBps, rule.PercentBps)
+	require.Equal(t, args.MaxFee, rule.MaxFee)
+	require.Equal(t, args.FeeAccountID, rule.FeeAccountID)
+	require.NotZero(t, rule.UpdatedAt)
+
+	return rule
+}
+
+func TestUpsertFeeRule(t *testing.T) {
+	upsertRandomFeeRule(t)
+}
+
+func TestGetFeeRule(t *testing.T) {
+	rule1 := upsertRandomFeeRule(t)
+	rule2, err := testQueries.GetFeeRule(context.Background(), rule1.Currency)
+
+	require.NoError(t, err)
+	require.Equal(t, rule1.Currency, rule2.Currency)
+	require.Equal(t, rule1.FeeAccountID, rule2.FeeAccountID)
+}
+
+func TestQuoteFee(t *testing.T) {
+	store := NewStore(testDB)
+	rule := upsertRandomFeeRule(t)
+	amount := int64(util.RandomAmount())
+
+	quote, err := store.QuoteFee(context.Background(), rule.Currency, amount)
+	require.NoError(t, err)
+
+	fee := util.CalculateFee(amount, rule.FlatFee, rule.PercentBps, rule.MaxFee)
+	require.Equal(t, rule.Currency, quote.Currency)
+	require.Equal(t, amount, quote.Amount)
+	require.Equal(t, fee, quote.Fee)
+	require.Equal(t, amount+fee, quote.Total)
+	require.Equal(t, rule.FeeAccountID, quote.FeeAccountID)
+}
+
+func TestQuoteFeeCurrencyMismatch(t *testing.T) {
+	store := NewStore(testDB)
+	feeAccount := createAccountInCurrency(t, util.USD)
+	// XTS is the ISO code reserved for testing, so no other test transfers it.
+	rule, err := testQueries.UpsertFeeRule(context.Background(), UpsertFeeRuleParams{
+		Currency:     "XTS",
+		FlatFee:      10,
+		FeeAccountID: feeAccount.ID,
+	})
+	require.NoError(t, err)
+
+	_, err = store.QuoteFee(context.Background(), rule.Currency, 100)
+	require.ErrorIs(t, err, ErrFeeAccountCurrencyMismatch)
+}
+
+func TestSetFeeRule(t *testing.T) {
+	store := NewStore(testDB)
+	feeAccount := createAccountInCurrency(t, util.USD)
+	arg := UpsertFeeRuleParams{
+		Currency:     "XTS",
+		FlatFee:      10,
+		FeeAccountID: feeAccount.ID,
+	}
+
+	_, err := store.SetFeeRule(context.Background(), arg)
+	require.ErrorIs(t, err, ErrFeeAccountCurrencyMismatch)
+
+	arg.FeeAccountID = createAccountInCurrency(t, "XTS").ID
+	rule, err := store.SetFeeRule(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.FeeAccountID, rule.FeeAccountID)
+}
+
+func TestTransferTxFeeAccountCurrencyMismatch(t *testing.T) {
+	store := NewStore(testDB)
+	from := createAccountInCurrency(t, util.USD)
+	to := createAccountInCurrency(t, util.USD)
+	feeAccount := createAccountInCurrency(t, util.NGN)
+
+	// A quote taken before the rule went wrong must not move money either.
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+		Quote:         FeeQuote{Currency: util.USD, Amount: 10, Fee: 1, Total: 11, FeeAccountID: feeAccount.ID},
+		Audit:         randomAuditContext(),
+	})
+	require.ErrorIs(t, err, ErrFeeAccountCurrencyMismatch)
+
+	acc, err := testQueries.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance, acc.Balance)
+}
@@ -0,0 +1,231 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"simplebank/metrics"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	HoldStatusPending  = "pending"
+	HoldStatusCaptured = "captured"
+	HoldStatusReleased = "released"
+	HoldStatusExpired  = "expired"
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient available balance")
+	ErrHoldNotPending    = errors.New("hold is no longer pending")
+	ErrHoldExpired       = errors.New("hold has expired")
+)
+
+type CreateHoldTxParams struct {
+	AccountID   int64         `json:"account_id"`
+	ToAccountID int64         `json:"to_account_id"`
+	Amount      int64         `json:"amount"`
+	Duration    time.Duration `json:"duration"`
+}
+
+type HoldTxResult struct {
+	Hold    Hold    `json:"hold"`
+	Account Account `json:"account"`
+}
+
+// CreateHoldTx reserves the amount on the account by lowering its available
+// balance. The balance itself only changes once the hold is captured.
+func (s *SQLStore) CreateHoldTx(ctx context.Context, arg CreateHoldTxParams) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		acc, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if acc.AvailableBalance < arg.Amount {
+			return ErrInsufficientFunds
+		}
+
+		result.Account, err = q.AddAccountAvailableBalance(ctx, AddAccountAvailableBalanceParams{
+			ID:     arg.AccountID,
+			Amount: -arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.CreateHold(ctx, CreateHoldParams{
+			AccountID:   arg.AccountID,
+			ToAccountID: arg.ToAccountID,
+			Amount:      arg.Amount,
+			ExpiresAt:   time.Now().Add(arg.Duration),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = createOutboxEvents(ctx, q, accountEvent{arg.AccountID, EventHoldCreated, result.Hold})
+		if err != nil {
+			return err
+		}
+		return notifyAccount(ctx, q, AccountUpdate{Account: result.Account})
+	})
+
+	return result, err
+}
+
+type CaptureHoldTxParams struct {
+	HoldID int64        `json:"hold_id"`
+	Quote  FeeQuote     `json:"quote"`
+	Audit  AuditContext `json:"-"`
+}
+
+type CaptureHoldTxResult struct {
+	Hold Hold `json:"hold"`
+	TransferTxResult
+}
+
+// CaptureHoldTx turns a pending hold into a transfer to the destination
+// account. The reserved amount goes back to the available balance first, so
+// that the transfer can debit it for real.
+func (s *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	start := time.Now()
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		hold, err := lockPendingHold(ctx, q, arg.HoldID)
+		if err != nil {
+			return err
+		}
+		if !hold.ExpiresAt.After(time.Now()) {
+			return ErrHoldExpired
+		}
+
+		params := TransferTxParams{
+			FromAccountID: hold.AccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        hold.Amount,
+			Quote:         arg.Quote,
+			Audit:         arg.Audit,
+		}
+		locked, err := lockTransferAccounts(ctx, q, params)
+		if err != nil {
+			return err
+		}
+
+		locked[hold.AccountID], err = q.AddAccountAvailableBalance(ctx, AddAccountAvailableBalanceParams{
+			ID:     hold.AccountID,
+			Amount: hold.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = applyTransfer(ctx, q, locked, params, pgtype.Int8{})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+			ID:         hold.ID,
+			Status:     HoldStatusCaptured,
+			TransferID: pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		err = createOutboxEvents(ctx, q, accountEvent{hold.AccountID, EventHoldCaptured, result.Hold})
+		if err != nil {
+			return err
+		}
+		return notifyAccountUpdates(ctx, q, result.TransferTxResult)
+	})
+	metrics.TransferTxDuration.Observe(time.Since(start).Seconds())
+	observeTransfer(result.TransferTxResult, err)
+
+	return result, err
+}
+
+func (s *SQLStore) ReleaseHoldTx(ctx context.Context, holdID int64) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		hold, err := lockPendingHold(ctx, q, holdID)
+		if err != nil {
+			return err
+		}
+		result, err = releaseHold(ctx, q, hold, HoldStatusReleased)
+		return err
+	})
+
+	return result, err
+}
+
+// ExpireHoldsTx releases up to limit pending holds whose expiry has passed and
+// returns how many it released. Holds locked by another transaction are
+// skipped and picked up by a later sweep.
+func (s *SQLStore) ExpireHoldsTx(ctx context.Context, limit int32) (int, error) {
+	var n int
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		holds, err := q.ListExpiredHolds(ctx, limit)
+		if err != nil {
+			return err
+		}
+		for _, hold := range holds {
+			if _, err := releaseHold(ctx, q, hold, HoldStatusExpired); err != nil {
+				return err
+			}
+		}
+		n = len(holds)
+		return nil
+	})
+
+	return n, err
+}
+
+func lockPendingHold(ctx context.Context, q *Queries, holdID int64) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return hold, err
+	}
+	if hold.Status != HoldStatusPending {
+		return hold, ErrHoldNotPending
+	}
+	return hold, nil
+}
+
+func releaseHold(ctx context.Context, q *Queries, hold Hold, status string) (HoldTxResult, error) {
+	var result HoldTxResult
+	var err error
+
+	result.Account, err = q.AddAccountAvailableBalance(ctx, AddAccountAvailableBalanceParams{
+		ID:     hold.AccountID,
+		Amount: hold.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+		ID:     hold.ID,
+		Status: status,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	event := EventHoldReleased
+	if status == HoldStatusExpired {
+		event = EventHoldExpired
+	}
+	err = createOutboxEvents(ctx, q, accountEvent{hold.AccountID, event, result.Hold})
+	if err != nil {
+		return result, err
+	}
+	return result, notifyAccount(ctx, q, AccountUpdate{Account: result.Account})
+}
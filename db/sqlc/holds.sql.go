@@ -0,0 +1,209 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: holds.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createHold = `-- name: CreateHold :one
+INSERT INTO holds (
+  account_id, to_account_id, amount, expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, account_id, to_account_id, amount, status, transfer_id, expires_at, created_at, updated_at
+`
+
+type CreateHoldParams struct {
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error) {
+	row := q.db.QueryRow(ctx, createHold,
+		arg.AccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ExpiresAt,
+	)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getHold = `-- name: GetHold :one
+SELECT id, account_id, to_account_id, amount, status, transfer_id, expires_at, created_at, updated_at FROM holds
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetHold(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRow(ctx, getHold, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getHoldForUpdate = `-- name: GetHoldForUpdate :one
+SELECT id, account_id, to_account_id, amount, status, transfer_id, expires_at, created_at, updated_at FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetHoldForUpdate(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRow(ctx, getHoldForUpdate, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listExpiredHolds = `-- name: ListExpiredHolds :many
+SELECT id, account_id, to_account_id, amount, status, transfer_id, expires_at, created_at, updated_at FROM holds
+WHERE status = 'pending' AND expires_at <= now()
+ORDER BY expires_at
+LIMIT $1
+FOR NO KEY UPDATE SKIP LOCKED
+`
+
+func (q *Queries) ListExpiredHolds(ctx context.Context, limit int32) ([]Hold, error) {
+	rows, err := q.db.Query(ctx, listExpiredHolds, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Hold{}
+	for rows.Next() {
+		var i Hold
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Status,
+			&i.TransferID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHolds = `-- name: ListHolds :many
+SELECT id, account_id, to_account_id, amount, status, transfer_id, expires_at, created_at, updated_at FROM holds
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListHoldsParams struct {
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListHolds(ctx context.Context, arg ListHoldsParams) ([]Hold, error) {
+	rows, err := q.db.Query(ctx, listHolds, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Hold{}
+	for rows.Next() {
+		var i Hold
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Status,
+			&i.TransferID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateHoldStatus = `-- name: UpdateHoldStatus :one
+UPDATE holds
+SET status = $1,
+  transfer_id = $2,
+  updated_at = now()
+WHERE id = $3
+RETURNING id, account_id, to_account_id, amount, status, transfer_id, expires_at, created_at, updated_at
+`
+
+type UpdateHoldStatusParams struct {
+	Status     string      `json:"status"`
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	ID         int64       `json:"id"`
+}
+
+func (q *Queries) UpdateHoldStatus(ctx context.Context, arg UpdateHoldStatusParams) (Hold, error) {
+	row := q.db.QueryRow(ctx, updateHoldStatus, arg.Status, arg.TransferID, arg.ID)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
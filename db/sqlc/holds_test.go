@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomHold(t *testing.T, store Store, duration time.Duration) (Hold, Account, Account) {
+	acc1 := creatRandomAccount(t)
+	acc2 := creatRandomAccount(t)
+	amount := int64(10)
+
+	result, err := store.CreateHoldTx(context.Background(), CreateHoldTxParams{
+		AccountID:   acc1.ID,
+		ToAccountID: acc2.ID,
+		Amount:      amount,
+		Duration:    duration,
+	})
+	require.NoError(t, err)
+
+	hold := result.Hold
+	require.NotZero(t, hold.ID)
+	require.Equal(t, acc1.ID, hold.AccountID)
+	require.Equal(t, acc2.ID, hold.ToAccountID)
+	require.Equal(t, amount, hold.Amount)
+	require.Equal(t, HoldStatusPending, hold.Status)
+	require.False(t, hold.TransferID.Valid)
+	require.WithinDuration(t, time.Now().Add(duration), hold.ExpiresAt, time.Second)
+
+	require.Equal(t, acc1.Balance, result.Account.Balance)
+	require.Equal(t, acc1.AvailableBalance-amount, result.Account.AvailableBalance)
+
+	return hold, acc1, acc2
+}
+
+func TestCreateHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	createRandomHold(t, store, time.Hour)
+}
+
+func TestCreateHoldTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := creatRandomAccount(t)
+	acc2 := creatRandomAccount(t)
+
+	_, err := store.CreateHoldTx(context.Background(), CreateHoldTxParams{
+		AccountID:   acc1.ID,
+		ToAccountID: acc2.ID,
+		Amount:      acc1.AvailableBalance + 1,
+		Duration:    time.Hour,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	hold, acc1, acc2 := createRandomHold(t, store, time.Hour)
+
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	require.NoError(t, err)
+
+	require.Equal(t, HoldStatusCaptured, result.Hold.Status)
+	require.True(t, result.Hold.TransferID.Valid)
+	require.Equal(t, result.Transfer.ID, result.Hold.TransferID.Int64)
+	require.Equal(t, hold.Amount, result.Transfer.Amount)
+
+	require.Equal(t, acc1.Balance-hold.Amount, result.FromAccount.Balance)
+	require.Equal(t, acc1.AvailableBalance-hold.Amount, result.FromAccount.AvailableBalance)
+	require.Equal(t, acc2.Balance+hold.Amount, result.ToAccount.Balance)
+	require.Equal(t, acc2.AvailableBalance+hold.Amount, result.ToAccount.AvailableBalance)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldNotPending)
+}
+
+func TestTransferTxAfterHoldExceedsAvailable(t *testing.T) {
+	store := NewStore(testDB)
+	hold, acc1, acc2 := createRandomHold(t, store, time.Hour)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        acc1.AvailableBalance - hold.Amount + 1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	acc, err := testQueries.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.Equal(t, acc1.Balance, acc.Balance)
+	require.Equal(t, acc1.AvailableBalance-hold.Amount, acc.AvailableBalance)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        acc1.AvailableBalance - hold.Amount,
+	})
+	require.NoError(t, err)
+
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	require.NoError(t, err)
+	require.Zero(t, result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.AvailableBalance)
+}
+
+func TestReleaseHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	hold, acc1, _ := createRandomHold(t, store, time.Hour)
+
+	result, err := store.ReleaseHoldTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusReleased, result.Hold.Status)
+	require.Equal(t, acc1.Balance, result.Account.Balance)
+	require.Equal(t, acc1.AvailableBalance, result.Account.AvailableBalance)
+
+	_, err = store.ReleaseHoldTx(context.Background(), hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotPending)
+}
+
+func TestExpireHoldsTx(t *testing.T) {
+	store := NewStore(testDB)
+	hold, acc1, _ := createRandomHold(t, store, -time.Minute)
+
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldExpired)
+
+	for {
+		n, err := store.ExpireHoldsTx(context.Background(), 100)
+		require.NoError(t, err)
+		if n < 100 {
+			break
+		}
+	}
+
+	expired, err := testQueries.GetHold(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusExpired, expired.Status)
+
+	account, err := testQueries.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.Equal(t, acc1.AvailableBalance, account.AvailableBalance)
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	LimitMaxAmount   = "max_amount"
+	LimitDailyAmount = "daily_amount"
+	LimitDailyCount  = "daily_count"
+)
+
+// TransferAllowance is what is left of the limits that apply to an account
+// for the current day. A nil field means the account is not limited there.
+type TransferAllowance struct {
+	MaxAmount       *int64 `json:"max_amount,omitempty"`
+	RemainingAmount *int64 `json:"remaining_amount,omitempty"`
+	RemainingCount  *int64 `json:"remaining_count,omitempty"`
+}
+
+type TransferLimitError struct {
+	Limit     string            `json:"limit"`
+	Allowance TransferAllowance `json:"allowance"`
+}
+
+func (e *TransferLimitError) Error() string {
+	return fmt.Sprintf("transfer exceeds the %s limit", e.Limit)
+}
+
+// checkTransferLimits evaluates every limit matching the sending account
+// against the transfers of the day. A limit tied to the account counts the
+// transfers from it; any other limit counts those from all of the owner's
+// accounts in its currency, since amounts in different currencies do not add
+// up. The caller must hold the row lock on the account. The owner's advisory
+// lock is taken here, after the row locks, before reading the totals of a
+// limit shared between accounts, so that transfers from sibling accounts
+// cannot both pass it.
+func checkTransferLimits(ctx context.Context, q *Queries, from Account, amount int64) (TransferAllowance, error) {
+	var allowance TransferAllowance
+
+	limits, err := q.ListApplicableTransferLimits(ctx, ListApplicableTransferLimitsParams{
+		Owner:     from.Owner,
+		AccountID: from.ID,
+		Currency:  from.Currency,
+	})
+	if err != nil || len(limits) == 0 {
+		return allowance, err
+	}
+
+	totals := dailyTotals{q: q, from: from}
+	for _, l := range limits {
+		t, err := totals.get(ctx, l)
+		if err != nil {
+			return allowance, err
+		}
+
+		if l.MaxAmount > 0 {
+			allowance.MaxAmount = minLimit(allowance.MaxAmount, l.MaxAmount)
+		}
+		if l.DailyAmount > 0 {
+			allowance.RemainingAmount = minLimit(allowance.RemainingAmount, l.DailyAmount-t.TotalAmount)
+		}
+		if l.DailyCount > 0 {
+			allowance.RemainingCount = minLimit(allowance.RemainingCount, l.DailyCount-t.TotalCount)
+		}
+	}
+
+	switch {
+	case allowance.MaxAmount != nil && amount > *allowance.MaxAmount:
+		return allowance, &TransferLimitError{Limit: LimitMaxAmount, Allowance: allowance}
+	case allowance.RemainingAmount != nil && amount > *allowance.RemainingAmount:
+		return allowance, &TransferLimitError{Limit: LimitDailyAmount, Allowance: allowance}
+	case allowance.RemainingCount != nil && *allowance.RemainingCount < 1:
+		return allowance, &TransferLimitError{Limit: LimitDailyCount, Allowance: allowance}
+	}
+
+	if allowance.RemainingAmount != nil {
+		*allowance.RemainingAmount -= amount
+	}
+	if allowance.RemainingCount != nil {
+		*allowance.RemainingCount--
+	}
+	return allowance, nil
+}
+
+// dailyTotals loads the day's transfer totals a limit is checked against, at
+// most once per scope.
+type dailyTotals struct {
+	q       *Queries
+	from    Account
+	account *GetDailyTransferTotalsRow
+	owner   map[string]GetOwnerDailyTransferTotalsRow
+}
+
+func (d *dailyTotals) get(ctx context.Context, l TransferLimit) (GetDailyTransferTotalsRow, error) {
+	if l.AccountID.Valid {
+		if d.account == nil {
+			row, err := d.q.GetDailyTransferTotals(ctx, d.from.ID)
+			if err != nil {
+				return row, err
+			}
+			d.account = &row
+		}
+		return *d.account, nil
+	}
+
+	if d.owner == nil {
+		if err := d.q.LockOwnerTransferLimits(ctx, d.from.Owner); err != nil {
+			return GetDailyTransferTotalsRow{}, err
+		}
+		d.owner = make(map[string]GetOwnerDailyTransferTotalsRow)
+	}
+	row, ok := d.owner[l.Currency]
+	if !ok {
+		var err error
+		row, err = d.q.GetOwnerDailyTransferTotals(ctx, GetOwnerDailyTransferTotalsParams{
+			Owner:    d.from.Owner,
+			Currency: l.Currency,
+		})
+		if err != nil {
+			return GetDailyTransferTotalsRow{}, err
+		}
+		d.owner[l.Currency] = row
+	}
+	return GetDailyTransferTotalsRow(row), nil
+}
+
+func minLimit(current *int64, value int64) *int64 {
+	if value < 0 {
+		value = 0
+	}
+	if current == nil || value < *current {
+		return &value
+	}
+	return current
+}
@@ -1,24 +1,24 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"log"
 	"os"
 	"simplebank/util"
 	"testing"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var testQueries *Queries
-var testDB *sql.DB
+var testDB *pgxpool.Pool
 
 func TestMain(m *testing.M) {
 	config, err := util.LoadConfig("../..")
 	if err != nil {
 		log.Fatalln("cannot load env variables", err)
 	}
-	testDB, err = sql.Open(config.DBDriver, config.DBSource)
+	testDB, err = pgxpool.New(context.Background(), config.DBSource)
 	if err != nil {
 		log.Fatalln(err)
 	}
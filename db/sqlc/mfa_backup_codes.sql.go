@@ -0,0 +1,73 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: mfa_backup_codes.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createBackupCode = `-- name: CreateBackupCode :one
+INSERT INTO mfa_backup_codes (
+  username, code_hash
+) VALUES (
+  $1, $2
+) RETURNING id, username, code_hash, is_used, created_at
+`
+
+type CreateBackupCodeParams struct {
+	Username string `json:"username"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) CreateBackupCode(ctx context.Context, arg CreateBackupCodeParams) (MfaBackupCode, error) {
+	row := q.db.QueryRow(ctx, createBackupCode, arg.Username, arg.CodeHash)
+	var i MfaBackupCode
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CodeHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteBackupCodes = `-- name: DeleteBackupCodes :exec
+DELETE FROM mfa_backup_codes
+WHERE username = $1
+`
+
+func (q *Queries) DeleteBackupCodes(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, deleteBackupCodes, username)
+	return err
+}
+
+const useBackupCode = `-- name: UseBackupCode :one
+UPDATE mfa_backup_codes
+SET is_used = true
+WHERE username = $1
+  AND code_hash = $2
+  AND is_used = false
+RETURNING id, username, code_hash, is_used, created_at
+`
+
+type UseBackupCodeParams struct {
+	Username string `json:"username"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) UseBackupCode(ctx context.Context, arg UseBackupCodeParams) (MfaBackupCode, error) {
+	row := q.db.QueryRow(ctx, useBackupCode, arg.Username, arg.CodeHash)
+	var i MfaBackupCode
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CodeHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+	)
+	return i, err
+}
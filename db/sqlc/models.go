@@ -1,11 +1,14 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.15.0
+//   sqlc v1.18.0
 
 package db
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Account struct {
@@ -14,6 +17,51 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// balance minus pending holds
+	AvailableBalance int64 `json:"available_balance"`
+	// what users share instead of the id
+	Number string `json:"number"`
+}
+
+type AuditLog struct {
+	ID        int64  `json:"id"`
+	Action    string `json:"action"`
+	Actor     string `json:"actor"`
+	Resource  string `json:"resource"`
+	Ip        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	RequestID string `json:"request_id"`
+	// json rather than jsonb so that the text hashed is the text stored
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+	PrevHash  string          `json:"prev_hash"`
+	// sha256 of prev_hash and the row, chaining every row to the one before it
+	Hash string `json:"hash"`
+}
+
+// events written by money transactions, waiting to be chained into audit_log
+type AuditQueue struct {
+	ID        int64           `json:"id"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	Resource  string          `json:"resource"`
+	Ip        string          `json:"ip"`
+	UserAgent string          `json:"user_agent"`
+	RequestID string          `json:"request_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type BulkTransfer struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	// atomic or per_item
+	Mode      string    `json:"mode"`
+	ItemCount int32     `json:"item_count"`
+	Succeeded int32     `json:"succeeded"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Entry struct {
@@ -24,13 +72,139 @@ type Entry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type FeeRule struct {
+	Currency string `json:"currency"`
+	FlatFee  int64  `json:"flat_fee"`
+	// basis points of the transfer amount
+	PercentBps int64 `json:"percent_bps"`
+	// 0 means no cap
+	MaxFee       int64     `json:"max_fee"`
+	FeeAccountID int64     `json:"fee_account_id"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type Hold struct {
+	ID          int64 `json:"id"`
+	AccountID   int64 `json:"account_id"`
+	ToAccountID int64 `json:"to_account_id"`
+	// must be positive
+	Amount int64 `json:"amount"`
+	// pending, captured, released or expired
+	Status     string      `json:"status"`
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type MfaBackupCode struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	CodeHash  string    `json:"code_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Outbox struct {
+	ID int64 `json:"id"`
+	// events of one account are published in id order
+	AccountID   int64              `json:"account_id"`
+	Type        string             `json:"type"`
+	Payload     json.RawMessage    `json:"payload"`
+	CreatedAt   time.Time          `json:"created_at"`
+	PublishedAt pgtype.Timestamptz `json:"published_at"`
+	// set once a delivery has been queued for every subscribed endpoint
+	WebhookDispatchedAt pgtype.Timestamptz `json:"webhook_dispatched_at"`
+}
+
+type PasswordReset struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+type Payee struct {
+	ID        int64     `json:"id"`
+	Owner     string    `json:"owner"`
+	AccountID int64     `json:"account_id"`
+	Nickname  string    `json:"nickname"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PaymentRequest struct {
+	ID                 int64 `json:"id"`
+	RequesterAccountID int64 `json:"requester_account_id"`
+	// null for an open request, until someone pays it through its link
+	Payer pgtype.Text `json:"payer"`
+	// must be positive
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Note     string `json:"note"`
+	// pending, paid, declined, cancelled or expired
+	Status string `json:"status"`
+	// the transfer that paid the request
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	// sha256 of the token in the request's shareable link
+	LinkTokenHash string `json:"link_token_hash"`
+}
+
+type StepUpRule struct {
+	Currency string `json:"currency"`
+	// transfers above this amount need a fresh second factor
+	Threshold int64     `json:"threshold"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Task struct {
+	ID      int64  `json:"id"`
+	Queue   string `json:"queue"`
+	Type    string `json:"type"`
+	Payload []byte `json:"payload"`
+	// pending, running, completed or dead
+	Status      string    `json:"status"`
+	Attempts    int32     `json:"attempts"`
+	MaxAttempts int32     `json:"max_attempts"`
+	LastError   string    `json:"last_error"`
+	RunAt       time.Time `json:"run_at"`
+	// a running task past this time is claimed again
+	LockedUntil time.Time `json:"locked_until"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	// must be positive
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount         int64       `json:"amount"`
+	CreatedAt      time.Time   `json:"created_at"`
+	Fee            int64       `json:"fee"`
+	Reference      string      `json:"reference"`
+	BulkTransferID pgtype.Int8 `json:"bulk_transfer_id"`
+}
+
+type TransferLimit struct {
+	ID int64 `json:"id"`
+	// null matches every user
+	Owner pgtype.Text `json:"owner"`
+	// null matches every account
+	AccountID pgtype.Int8 `json:"account_id"`
+	// the currency of the amounts and of the transfers counted
+	Currency string `json:"currency"`
+	// 0 means no limit
+	MaxAmount int64 `json:"max_amount"`
+	// 0 means no limit
+	DailyAmount int64 `json:"daily_amount"`
+	// 0 means no limit
+	DailyCount int64     `json:"daily_count"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type User struct {
@@ -40,4 +214,47 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	// consecutive failed logins, reset on success
+	FailedLoginAttempts int32     `json:"failed_login_attempts"`
+	LockedUntil         time.Time `json:"locked_until"`
+	IsEmailVerified     bool      `json:"is_email_verified"`
+	TotpSecret          string    `json:"totp_secret"`
+	TotpEnabled         bool      `json:"totp_enabled"`
+	Role                string    `json:"role"`
+	// time step of the last accepted TOTP code; codes at or below it are replays
+	TotpLastStep int64 `json:"totp_last_step"`
+}
+
+type VerifyEmail struct {
+	ID         int64     `json:"id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email"`
+	SecretCode string    `json:"secret_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+}
+
+type WebhookDelivery struct {
+	ID         int64 `json:"id"`
+	EndpointID int64 `json:"endpoint_id"`
+	EventID    int64 `json:"event_id"`
+	// pending, succeeded or failed
+	Status         string    `json:"status"`
+	Attempts       int32     `json:"attempts"`
+	ResponseStatus int32     `json:"response_status"`
+	LastError      string    `json:"last_error"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type WebhookEndpoint struct {
+	ID     int64  `json:"id"`
+	Owner  string `json:"owner"`
+	Url    string `json:"url"`
+	Secret string `json:"secret"`
+	// empty means every event type
+	EventTypes []string  `json:"event_types"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
 }
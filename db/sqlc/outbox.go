@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	EventAccountCreated  = "account.created"
+	EventTransferCreated = "transfer.created"
+	EventAccountCredited = "account.credited"
+	EventAccountDebited  = "account.debited"
+	EventHoldCreated     = "hold.created"
+	EventHoldCaptured    = "hold.captured"
+	EventHoldReleased    = "hold.released"
+	EventHoldExpired     = "hold.expired"
+)
+
+// accountEvent is a domain event about one account.
+type accountEvent struct {
+	AccountID int64
+	Type      string
+	Payload   interface{}
+}
+
+// AccountMovement is the payload of account.credited and account.debited.
+type AccountMovement struct {
+	AccountID  int64  `json:"account_id"`
+	TransferID int64  `json:"transfer_id"`
+	Amount     int64  `json:"amount"`
+	Balance    int64  `json:"balance"`
+	Currency   string `json:"currency"`
+}
+
+// transferEvents describes a transfer: both parties hear about the transfer
+// and each hears about its own side of it. The fee leg goes to an internal
+// account and is not reported.
+func transferEvents(result TransferTxResult) []accountEvent {
+	debit := AccountMovement{
+		AccountID:  result.FromAccount.ID,
+		TransferID: result.Transfer.ID,
+		Amount:     result.FromEntry.Amount,
+		Balance:    result.FromAccount.Balance,
+		Currency:   result.FromAccount.Currency,
+	}
+	credit := AccountMovement{
+		AccountID:  result.ToAccount.ID,
+		TransferID: result.Transfer.ID,
+		Amount:     result.ToEntry.Amount,
+		Balance:    result.ToAccount.Balance,
+		Currency:   result.ToAccount.Currency,
+	}
+
+	return []accountEvent{
+		{result.FromAccount.ID, EventTransferCreated, result.Transfer},
+		{result.ToAccount.ID, EventTransferCreated, result.Transfer},
+		{result.FromAccount.ID, EventAccountDebited, debit},
+		{result.ToAccount.ID, EventAccountCredited, credit},
+	}
+}
+
+// createOutboxEvents writes events to the outbox for the relay to publish.
+//
+// Callers must hold the row lock of every account they write events for.
+// Another transaction then cannot write an event for the same account until
+// this one commits, so each account's events become visible in id order and
+// the relay never skips past one that is still in flight.
+func createOutboxEvents(ctx context.Context, q *Queries, events ...accountEvent) error {
+	for _, e := range events {
+		payload, err := json.Marshal(e.Payload)
+		if err != nil {
+			return fmt.Errorf("cannot marshal outbox event: %w", err)
+		}
+		_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+			AccountID: e.AccountID,
+			Type:      e.Type,
+			Payload:   payload,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type RelayOutboxTxParams struct {
+	Limit int32
+	// Publish runs for each event in id order. The relay stops at the first
+	// error and the event is tried again on the next run.
+	Publish func(event Outbox) error
+}
+
+// RelayOutboxTx publishes up to Limit unpublished events and marks those
+// that were accepted. It returns how many were published, along with the
+// publish error if one stopped it early. An event whose publish succeeded
+// but could not be marked is published again, so delivery is at least once.
+func (s *SQLStore) RelayOutboxTx(ctx context.Context, arg RelayOutboxTxParams) (int, error) {
+	var published []int64
+	var publishErr error
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		published = nil
+		publishErr = nil
+
+		if err := q.LockOutbox(ctx); err != nil {
+			return err
+		}
+
+		events, err := q.ListUnpublishedOutboxEvents(ctx, arg.Limit)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if publishErr = arg.Publish(event); publishErr != nil {
+				break
+			}
+			published = append(published, event.ID)
+		}
+
+		if len(published) == 0 {
+			return nil
+		}
+		return q.MarkOutboxEventsPublished(ctx, published)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(published), publishErr
+}
@@ -0,0 +1,157 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: outbox.sql
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const claimOutboxEventsForWebhooks = `-- name: ClaimOutboxEventsForWebhooks :many
+UPDATE outbox
+SET webhook_dispatched_at = now()
+WHERE id IN (
+  SELECT o.id FROM outbox o
+  WHERE o.webhook_dispatched_at IS NULL
+  ORDER BY o.id
+  LIMIT $1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, account_id, type, payload, created_at, published_at, webhook_dispatched_at
+`
+
+func (q *Queries) ClaimOutboxEventsForWebhooks(ctx context.Context, limit int32) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, claimOutboxEventsForWebhooks, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Outbox{}
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Type,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.PublishedAt,
+			&i.WebhookDispatchedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :one
+INSERT INTO outbox (
+  account_id, type, payload
+) VALUES (
+  $1, $2, $3
+) RETURNING id, account_id, type, payload, created_at, published_at, webhook_dispatched_at
+`
+
+type CreateOutboxEventParams struct {
+	AccountID int64           `json:"account_id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error) {
+	row := q.db.QueryRow(ctx, createOutboxEvent, arg.AccountID, arg.Type, arg.Payload)
+	var i Outbox
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Type,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.PublishedAt,
+		&i.WebhookDispatchedAt,
+	)
+	return i, err
+}
+
+const getOutboxEvent = `-- name: GetOutboxEvent :one
+SELECT id, account_id, type, payload, created_at, published_at, webhook_dispatched_at FROM outbox
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetOutboxEvent(ctx context.Context, id int64) (Outbox, error) {
+	row := q.db.QueryRow(ctx, getOutboxEvent, id)
+	var i Outbox
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Type,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.PublishedAt,
+		&i.WebhookDispatchedAt,
+	)
+	return i, err
+}
+
+const listUnpublishedOutboxEvents = `-- name: ListUnpublishedOutboxEvents :many
+SELECT id, account_id, type, payload, created_at, published_at, webhook_dispatched_at FROM outbox
+WHERE published_at IS NULL
+ORDER BY id
+LIMIT $1
+`
+
+func (q *Queries) ListUnpublishedOutboxEvents(ctx context.Context, limit int32) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, listUnpublishedOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Outbox{}
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Type,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.PublishedAt,
+			&i.WebhookDispatchedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockOutbox = `-- name: LockOutbox :exec
+SELECT pg_advisory_xact_lock(hashtext('outbox'))
+`
+
+// Only one relay publishes at a time, so that events leave in id order.
+func (q *Queries) LockOutbox(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, lockOutbox)
+	return err
+}
+
+const markOutboxEventsPublished = `-- name: MarkOutboxEventsPublished :exec
+UPDATE outbox
+SET published_at = now()
+WHERE id = ANY($1::bigint[])
+`
+
+func (q *Queries) MarkOutboxEventsPublished(ctx context.Context, ids []int64) error {
+	_, err := q.db.Exec(ctx, markOutboxEventsPublished, ids)
+	return err
+}
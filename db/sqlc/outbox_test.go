@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// relayAll drains the outbox and returns the published events of account.
+func relayAll(t *testing.T, store Store, accountID int64) []Outbox {
+	var events []Outbox
+	for {
+		n, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+			Limit: 100,
+			Publish: func(event Outbox) error {
+				if event.AccountID == accountID {
+					events = append(events, event)
+				}
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		if n == 0 {
+			return events
+		}
+	}
+}
+
+func TestRelayOutboxTx(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := createAccountInCurrency(t, "USD")
+	acc2 := createAccountInCurrency(t, "USD")
+
+	for i := 0; i < 2; i++ {
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: acc1.ID,
+			ToAccountID:   acc2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+	}
+
+	events := relayAll(t, store, acc1.ID)
+	require.Len(t, events, 4)
+
+	var types []string
+	for i, event := range events {
+		if i > 0 {
+			require.Greater(t, event.ID, events[i-1].ID)
+		}
+		types = append(types, event.Type)
+	}
+	require.Equal(t, []string{
+		EventTransferCreated, EventAccountDebited,
+		EventTransferCreated, EventAccountDebited,
+	}, types)
+
+	require.Empty(t, relayAll(t, store, acc1.ID))
+}
+
+func TestRelayOutboxTxPublishError(t *testing.T) {
+	store := NewStore(testDB)
+	relayAll(t, store, 0)
+
+	acc := creatRandomAccount(t)
+	hold, err := store.CreateHoldTx(context.Background(), CreateHoldTxParams{
+		AccountID:   acc.ID,
+		ToAccountID: creatRandomAccount(t).ID,
+		Amount:      1,
+		Duration:    time.Hour,
+	})
+	require.NoError(t, err)
+	_, err = store.ReleaseHoldTx(context.Background(), hold.Hold.ID)
+	require.NoError(t, err)
+
+	errBroker := errors.New("broker down")
+	n, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+		Limit: 100,
+		Publish: func(event Outbox) error {
+			if event.Type == EventHoldReleased {
+				return errBroker
+			}
+			return nil
+		},
+	})
+	require.ErrorIs(t, err, errBroker)
+	require.Equal(t, 1, n)
+
+	events := relayAll(t, store, acc.ID)
+	require.Len(t, events, 1)
+	require.Equal(t, EventHoldReleased, events[0].Type)
+}
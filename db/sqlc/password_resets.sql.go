@@ -0,0 +1,96 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: password_resets.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createPasswordReset = `-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+  username, token_hash, expired_at
+) VALUES (
+  $1, $2, $3
+) RETURNING id, username, token_hash, is_used, created_at, expired_at
+`
+
+type CreatePasswordResetParams struct {
+	Username  string    `json:"username"`
+	TokenHash string    `json:"token_hash"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+func (q *Queries) CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error) {
+	row := q.db.QueryRow(ctx, createPasswordReset, arg.Username, arg.TokenHash, arg.ExpiredAt)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const getPasswordReset = `-- name: GetPasswordReset :one
+SELECT id, username, token_hash, is_used, created_at, expired_at FROM password_resets
+WHERE token_hash = $1
+  AND is_used = false
+  AND expired_at > now()
+LIMIT 1
+`
+
+func (q *Queries) GetPasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error) {
+	row := q.db.QueryRow(ctx, getPasswordReset, tokenHash)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const invalidatePasswordResets = `-- name: InvalidatePasswordResets :exec
+UPDATE password_resets
+SET is_used = true
+WHERE username = $1
+  AND is_used = false
+`
+
+func (q *Queries) InvalidatePasswordResets(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, invalidatePasswordResets, username)
+	return err
+}
+
+const usePasswordReset = `-- name: UsePasswordReset :one
+UPDATE password_resets
+SET is_used = true
+WHERE token_hash = $1
+  AND is_used = false
+  AND expired_at > now()
+RETURNING id, username, token_hash, is_used, created_at, expired_at
+`
+
+func (q *Queries) UsePasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error) {
+	row := q.db.QueryRow(ctx, usePasswordReset, tokenHash)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
@@ -0,0 +1,142 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: payees.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createPayee = `-- name: CreatePayee :one
+INSERT INTO payees (
+  owner, account_id, nickname
+) VALUES (
+  $1, $2, $3
+) RETURNING id, owner, account_id, nickname, created_at
+`
+
+type CreatePayeeParams struct {
+	Owner     string `json:"owner"`
+	AccountID int64  `json:"account_id"`
+	Nickname  string `json:"nickname"`
+}
+
+func (q *Queries) CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error) {
+	row := q.db.QueryRow(ctx, createPayee, arg.Owner, arg.AccountID, arg.Nickname)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.AccountID,
+		&i.Nickname,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deletePayee = `-- name: DeletePayee :execrows
+DELETE FROM payees
+WHERE id = $1 AND owner = $2
+`
+
+type DeletePayeeParams struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+}
+
+func (q *Queries) DeletePayee(ctx context.Context, arg DeletePayeeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePayee, arg.ID, arg.Owner)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getPayeeAccount = `-- name: GetPayeeAccount :one
+SELECT accounts.id, accounts.owner, accounts.balance, accounts.currency, accounts.created_at, accounts.available_balance, accounts.number FROM payees
+JOIN accounts ON accounts.id = payees.account_id
+WHERE payees.owner = $1 AND payees.nickname = $2
+LIMIT 1
+`
+
+type GetPayeeAccountParams struct {
+	Owner    string `json:"owner"`
+	Nickname string `json:"nickname"`
+}
+
+func (q *Queries) GetPayeeAccount(ctx context.Context, arg GetPayeeAccountParams) (Account, error) {
+	row := q.db.QueryRow(ctx, getPayeeAccount, arg.Owner, arg.Nickname)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.AvailableBalance,
+		&i.Number,
+	)
+	return i, err
+}
+
+const listPayees = `-- name: ListPayees :many
+SELECT
+  payees.id,
+  payees.nickname,
+  accounts.number AS account_number,
+  accounts.owner AS account_owner,
+  accounts.currency,
+  payees.created_at
+FROM payees
+JOIN accounts ON accounts.id = payees.account_id
+WHERE payees.owner = $1
+ORDER BY payees.nickname
+LIMIT $2
+OFFSET $3
+`
+
+type ListPayeesParams struct {
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+type ListPayeesRow struct {
+	ID            int64     `json:"id"`
+	Nickname      string    `json:"nickname"`
+	AccountNumber string    `json:"account_number"`
+	AccountOwner  string    `json:"account_owner"`
+	Currency      string    `json:"currency"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// The account id stays internal; payees are shown by account number.
+func (q *Queries) ListPayees(ctx context.Context, arg ListPayeesParams) ([]ListPayeesRow, error) {
+	rows, err := q.db.Query(ctx, listPayees, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPayeesRow{}
+	for rows.Next() {
+		var i ListPayeesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Nickname,
+			&i.AccountNumber,
+			&i.AccountOwner,
+			&i.Currency,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
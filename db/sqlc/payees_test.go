@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayees(t *testing.T) {
+	owner := creatRandomUser(t)
+	acc1 := creatRandomAccount(t)
+	acc2 := creatRandomAccount(t)
+
+	payee1, err := testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:     owner.Username,
+		AccountID: acc1.ID,
+		Nickname:  "landlord",
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:     owner.Username,
+		AccountID: acc2.ID,
+		Nickname:  "babysitter",
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:     owner.Username,
+		AccountID: acc2.ID,
+		Nickname:  "landlord",
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	payees, err := testQueries.ListPayees(context.Background(), ListPayeesParams{
+		Owner: owner.Username,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, payees, 2)
+	require.Equal(t, "babysitter", payees[0].Nickname)
+	require.Equal(t, acc2.Number, payees[0].AccountNumber)
+	require.Equal(t, acc1.Owner, payees[1].AccountOwner)
+
+	acc, err := testQueries.GetPayeeAccount(context.Background(), GetPayeeAccountParams{
+		Owner:    owner.Username,
+		Nickname: "landlord",
+	})
+	require.NoError(t, err)
+	require.Equal(t, acc1.ID, acc.ID)
+
+	n, err := testQueries.DeletePayee(context.Background(), DeletePayeeParams{ID: payee1.ID, Owner: acc1.Owner})
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	n, err = testQueries.DeletePayee(context.Background(), DeletePayeeParams{ID: payee1.ID, Owner: owner.Username})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	_, err = testQueries.GetPayeeAccount(context.Background(), GetPayeeAccountParams{
+		Owner:    owner.Username,
+		Nickname: "landlord",
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"simplebank/metrics"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	PaymentRequestPending   = "pending"
+	PaymentRequestPaid      = "paid"
+	PaymentRequestDeclined  = "declined"
+	PaymentRequestCancelled = "cancelled"
+	PaymentRequestExpired   = "expired"
+)
+
+var (
+	ErrPaymentRequestNotPending = errors.New("payment request is no longer pending")
+	ErrPaymentRequestExpired    = errors.New("payment request has expired")
+)
+
+type AcceptPaymentRequestTxParams struct {
+	ID            int64        `json:"id"`
+	FromAccountID int64        `json:"from_account_id"`
+	Payer         string       `json:"payer"`
+	Quote         FeeQuote     `json:"quote"`
+	Audit         AuditContext `json:"-"`
+}
+
+type AcceptPaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest `json:"payment_request"`
+	TransferTxResult
+}
+
+// AcceptPaymentRequestTx pays a pending request with a transfer from the
+// payer's account to the requester's, and links the request to it. An open
+// request takes Payer as its payer. The duration and outcome are observed
+// like those of any other transfer, an expired request included.
+func (s *SQLStore) AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error) {
+	var result AcceptPaymentRequestTxResult
+	var expired bool
+
+	start := time.Now()
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		request, err := lockPendingPaymentRequest(ctx, q, arg.ID)
+		if err != nil {
+			return err
+		}
+		if expired, err = expirePaymentRequest(ctx, q, request); expired || err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = transferTx(ctx, q, TransferTxParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   request.RequesterAccountID,
+			Amount:        request.Amount,
+			Quote:         arg.Quote,
+			Reference:     request.Note,
+			Audit:         arg.Audit,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.PaymentRequest, err = q.UpdatePaymentRequestStatus(ctx, UpdatePaymentRequestStatusParams{
+			ID:         request.ID,
+			Status:     PaymentRequestPaid,
+			TransferID: pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
+			Payer:      pgtype.Text{String: arg.Payer, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return notifyAccountUpdates(ctx, q, result.TransferTxResult)
+	})
+	if err == nil && expired {
+		err = ErrPaymentRequestExpired
+	}
+	metrics.TransferTxDuration.Observe(time.Since(start).Seconds())
+	observeTransfer(result.TransferTxResult, err)
+
+	return result, err
+}
+
+type ClosePaymentRequestTxParams struct {
+	ID int64 `json:"id"`
+	// Status is PaymentRequestDeclined when the payer turns the request down,
+	// or PaymentRequestCancelled when the requester takes it back.
+	Status string `json:"status"`
+}
+
+// ClosePaymentRequestTx ends a pending request without paying it.
+func (s *SQLStore) ClosePaymentRequestTx(ctx context.Context, arg ClosePaymentRequestTxParams) (PaymentRequest, error) {
+	var result PaymentRequest
+	var expired bool
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		request, err := lockPendingPaymentRequest(ctx, q, arg.ID)
+		if err != nil {
+			return err
+		}
+		if expired, err = expirePaymentRequest(ctx, q, request); expired || err != nil {
+			return err
+		}
+
+		result, err = q.UpdatePaymentRequestStatus(ctx, UpdatePaymentRequestStatusParams{
+			ID:     request.ID,
+			Status: arg.Status,
+		})
+		return err
+	})
+	if err == nil && expired {
+		return result, ErrPaymentRequestExpired
+	}
+
+	return result, err
+}
+
+func lockPendingPaymentRequest(ctx context.Context, q *Queries, id int64) (PaymentRequest, error) {
+	request, err := q.GetPaymentRequestForUpdate(ctx, id)
+	if err != nil {
+		return request, err
+	}
+	if request.Status != PaymentRequestPending {
+		return request, ErrPaymentRequestNotPending
+	}
+	return request, nil
+}
+
+// expirePaymentRequest marks the request expired once its expiry has passed.
+// The caller commits that and then fails with ErrPaymentRequestExpired, so
+// the status is right for the next reader.
+func expirePaymentRequest(ctx context.Context, q *Queries, request PaymentRequest) (bool, error) {
+	if request.ExpiresAt.After(time.Now()) {
+		return false, nil
+	}
+	_, err := q.UpdatePaymentRequestStatus(ctx, UpdatePaymentRequestStatusParams{
+		ID:     request.ID,
+		Status: PaymentRequestExpired,
+	})
+	return true, err
+}
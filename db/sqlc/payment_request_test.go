@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPaymentRequest(t *testing.T, requester Account, payer string, expiresAt time.Time) PaymentRequest {
+	request, err := testQueries.CreatePaymentRequest(context.Background(), CreatePaymentRequestParams{
+		RequesterAccountID: requester.ID,
+		Payer:              pgtype.Text{String: payer, Valid: payer != ""},
+		Amount:             25,
+		Currency:           requester.Currency,
+		Note:               "dinner",
+		ExpiresAt:          expiresAt,
+		LinkTokenHash:      util.HashToken(util.RandomString(32)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestPending, request.Status)
+	require.False(t, request.TransferID.Valid)
+	return request
+}
+
+func TestAcceptPaymentRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+	requester := createAccountInCurrency(t, "USD")
+	payer := createAccountInCurrency(t, "USD")
+	request := createRandomPaymentRequest(t, requester, payer.Owner, time.Now().Add(time.Hour))
+
+	result, err := store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: payer.ID,
+		Payer:         payer.Owner,
+		Audit:         randomAuditContext(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestPaid, result.PaymentRequest.Status)
+	require.True(t, result.PaymentRequest.TransferID.Valid)
+	require.Equal(t, result.Transfer.ID, result.PaymentRequest.TransferID.Int64)
+	require.Equal(t, requester.ID, result.Transfer.ToAccountID)
+	require.Equal(t, request.Amount, result.Transfer.Amount)
+	require.Equal(t, request.Note, result.Transfer.Reference)
+	require.Equal(t, payer.Balance-request.Amount, result.FromAccount.Balance)
+	require.Equal(t, requester.Balance+request.Amount, result.ToAccount.Balance)
+
+	_, err = store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: payer.ID,
+		Payer:         payer.Owner,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+}
+
+func TestAcceptPaymentRequestTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	requester := createAccountInCurrency(t, "USD")
+	payer := createAccountInCurrency(t, "USD")
+	request := createRandomPaymentRequest(t, requester, payer.Owner, time.Now().Add(-time.Minute))
+
+	_, err := store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: payer.ID,
+		Payer:         payer.Owner,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestExpired)
+
+	request, err = testQueries.GetPaymentRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestExpired, request.Status)
+
+	acc, err := testQueries.GetAccount(context.Background(), payer.ID)
+	require.NoError(t, err)
+	require.Equal(t, payer.Balance, acc.Balance)
+}
+
+func TestAcceptPaymentRequestTxViaLink(t *testing.T) {
+	store := NewStore(testDB)
+	requester := createAccountInCurrency(t, "USD")
+	payer := createAccountInCurrency(t, "USD")
+	request := createRandomPaymentRequest(t, requester, "", time.Now().Add(time.Hour))
+	require.False(t, request.Payer.Valid)
+
+	found, err := testQueries.GetPaymentRequestByLinkToken(context.Background(), request.LinkTokenHash)
+	require.NoError(t, err)
+	require.Equal(t, request.ID, found.ID)
+
+	// Whoever pays an open request becomes its payer.
+	result, err := store.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            request.ID,
+		FromAccountID: payer.ID,
+		Payer:         payer.Owner,
+		Audit:         randomAuditContext(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestPaid, result.PaymentRequest.Status)
+	require.Equal(t, pgtype.Text{String: payer.Owner, Valid: true}, result.PaymentRequest.Payer)
+
+	_, err = testQueries.GetPaymentRequestByLinkToken(context.Background(), util.HashToken(util.RandomString(32)))
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestClosePaymentRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+	requester := createAccountInCurrency(t, "USD")
+	payer := createAccountInCurrency(t, "USD")
+	request := createRandomPaymentRequest(t, requester, payer.Owner, time.Now().Add(time.Hour))
+
+	closed, err := store.ClosePaymentRequestTx(context.Background(), ClosePaymentRequestTxParams{
+		ID:     request.ID,
+		Status: PaymentRequestDeclined,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestDeclined, closed.Status)
+
+	_, err = store.ClosePaymentRequestTx(context.Background(), ClosePaymentRequestTxParams{
+		ID:     request.ID,
+		Status: PaymentRequestCancelled,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+}
+
+func TestListPaymentRequests(t *testing.T) {
+	requester := createAccountInCurrency(t, "USD")
+	payer := createAccountInCurrency(t, "USD")
+	sent := createRandomPaymentRequest(t, requester, payer.Owner, time.Now().Add(time.Hour))
+	received := createRandomPaymentRequest(t, payer, requester.Owner, time.Now().Add(time.Hour))
+
+	requests, err := testQueries.ListPaymentRequests(context.Background(), ListPaymentRequestsParams{
+		Username:    requester.Owner,
+		AsRequester: true,
+		AsPayer:     true,
+		PageSize:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	require.Equal(t, received.ID, requests[0].ID)
+	require.Equal(t, sent.ID, requests[1].ID)
+
+	requests, err = testQueries.ListPaymentRequests(context.Background(), ListPaymentRequestsParams{
+		Username: payer.Owner,
+		AsPayer:  true,
+		PageSize: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	require.Equal(t, sent.ID, requests[0].ID)
+}
@@ -0,0 +1,238 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: payment_requests.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createPaymentRequest = `-- name: CreatePaymentRequest :one
+INSERT INTO payment_requests (
+  requester_account_id, payer, amount, currency, note, expires_at, link_token_hash
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, requester_account_id, payer, amount, currency, note, status, transfer_id, expires_at, created_at, updated_at, link_token_hash
+`
+
+type CreatePaymentRequestParams struct {
+	RequesterAccountID int64       `json:"requester_account_id"`
+	Payer              pgtype.Text `json:"payer"`
+	Amount             int64       `json:"amount"`
+	Currency           string      `json:"currency"`
+	Note               string      `json:"note"`
+	ExpiresAt          time.Time   `json:"expires_at"`
+	LinkTokenHash      string      `json:"link_token_hash"`
+}
+
+func (q *Queries) CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error) {
+	row := q.db.QueryRow(ctx, createPaymentRequest,
+		arg.RequesterAccountID,
+		arg.Payer,
+		arg.Amount,
+		arg.Currency,
+		arg.Note,
+		arg.ExpiresAt,
+		arg.LinkTokenHash,
+	)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RequesterAccountID,
+		&i.Payer,
+		&i.Amount,
+		&i.Currency,
+		&i.Note,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LinkTokenHash,
+	)
+	return i, err
+}
+
+const getPaymentRequest = `-- name: GetPaymentRequest :one
+SELECT id, requester_account_id, payer, amount, currency, note, status, transfer_id, expires_at, created_at, updated_at, link_token_hash FROM payment_requests
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error) {
+	row := q.db.QueryRow(ctx, getPaymentRequest, id)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RequesterAccountID,
+		&i.Payer,
+		&i.Amount,
+		&i.Currency,
+		&i.Note,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LinkTokenHash,
+	)
+	return i, err
+}
+
+const getPaymentRequestByLinkToken = `-- name: GetPaymentRequestByLinkToken :one
+SELECT id, requester_account_id, payer, amount, currency, note, status, transfer_id, expires_at, created_at, updated_at, link_token_hash FROM payment_requests
+WHERE link_token_hash = $1
+  AND link_token_hash <> ''
+LIMIT 1
+`
+
+func (q *Queries) GetPaymentRequestByLinkToken(ctx context.Context, linkTokenHash string) (PaymentRequest, error) {
+	row := q.db.QueryRow(ctx, getPaymentRequestByLinkToken, linkTokenHash)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RequesterAccountID,
+		&i.Payer,
+		&i.Amount,
+		&i.Currency,
+		&i.Note,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LinkTokenHash,
+	)
+	return i, err
+}
+
+const getPaymentRequestForUpdate = `-- name: GetPaymentRequestForUpdate :one
+SELECT id, requester_account_id, payer, amount, currency, note, status, transfer_id, expires_at, created_at, updated_at, link_token_hash FROM payment_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error) {
+	row := q.db.QueryRow(ctx, getPaymentRequestForUpdate, id)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RequesterAccountID,
+		&i.Payer,
+		&i.Amount,
+		&i.Currency,
+		&i.Note,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LinkTokenHash,
+	)
+	return i, err
+}
+
+const listPaymentRequests = `-- name: ListPaymentRequests :many
+SELECT payment_requests.id, payment_requests.requester_account_id, payment_requests.payer, payment_requests.amount, payment_requests.currency, payment_requests.note, payment_requests.status, payment_requests.transfer_id, payment_requests.expires_at, payment_requests.created_at, payment_requests.updated_at, payment_requests.link_token_hash FROM payment_requests
+JOIN accounts ON accounts.id = payment_requests.requester_account_id
+WHERE ($1::bool AND accounts.owner = $2)
+  OR ($3::bool AND payment_requests.payer = $2)
+ORDER BY payment_requests.id DESC
+LIMIT $5
+OFFSET $4
+`
+
+type ListPaymentRequestsParams struct {
+	AsRequester bool   `json:"as_requester"`
+	Username    string `json:"username"`
+	AsPayer     bool   `json:"as_payer"`
+	PageOffset  int32  `json:"page_offset"`
+	PageSize    int32  `json:"page_size"`
+}
+
+// Lists the requests the user sent from one of their accounts, received as
+// the payer, or both.
+func (q *Queries) ListPaymentRequests(ctx context.Context, arg ListPaymentRequestsParams) ([]PaymentRequest, error) {
+	rows, err := q.db.Query(ctx, listPaymentRequests,
+		arg.AsRequester,
+		arg.Username,
+		arg.AsPayer,
+		arg.PageOffset,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PaymentRequest{}
+	for rows.Next() {
+		var i PaymentRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequesterAccountID,
+			&i.Payer,
+			&i.Amount,
+			&i.Currency,
+			&i.Note,
+			&i.Status,
+			&i.TransferID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LinkTokenHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePaymentRequestStatus = `-- name: UpdatePaymentRequestStatus :one
+UPDATE payment_requests
+SET status = $1,
+  transfer_id = $2,
+  payer = COALESCE(payer, $3),
+  updated_at = now()
+WHERE id = $4
+RETURNING id, requester_account_id, payer, amount, currency, note, status, transfer_id, expires_at, created_at, updated_at, link_token_hash
+`
+
+type UpdatePaymentRequestStatusParams struct {
+	Status     string      `json:"status"`
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	Payer      pgtype.Text `json:"payer"`
+	ID         int64       `json:"id"`
+}
+
+func (q *Queries) UpdatePaymentRequestStatus(ctx context.Context, arg UpdatePaymentRequestStatusParams) (PaymentRequest, error) {
+	row := q.db.QueryRow(ctx, updatePaymentRequestStatus,
+		arg.Status,
+		arg.TransferID,
+		arg.Payer,
+		arg.ID,
+	)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RequesterAccountID,
+		&i.Payer,
+		&i.Amount,
+		&i.Currency,
+		&i.Note,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LinkTokenHash,
+	)
+	return i, err
+}
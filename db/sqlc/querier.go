@@ -1,28 +1,134 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.15.0
+//   sqlc v1.18.0
 
 package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AddAccountAvailableBalance(ctx context.Context, arg AddAccountAvailableBalanceParams) (Account, error)
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	ClaimOutboxEventsForWebhooks(ctx context.Context, limit int32) ([]Outbox, error)
+	ClaimTasks(ctx context.Context, arg ClaimTasksParams) ([]Task, error)
+	CompleteTask(ctx context.Context, id int64) error
+	CountTasks(ctx context.Context) ([]CountTasksRow, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
+	CreateBackupCode(ctx context.Context, arg CreateBackupCodeParams) (MfaBackupCode, error)
+	CreateBulkTransfer(ctx context.Context, arg CreateBulkTransferParams) (BulkTransfer, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error)
+	CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error)
+	CreateQueuedAuditLog(ctx context.Context, arg CreateQueuedAuditLogParams) (AuditQueue, error)
+	CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferLimit(ctx context.Context, arg CreateTransferLimitParams) (TransferLimit, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
+	DeactivateWebhookEndpoint(ctx context.Context, arg DeactivateWebhookEndpointParams) (WebhookEndpoint, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteBackupCodes(ctx context.Context, username string) error
+	DeletePayee(ctx context.Context, arg DeletePayeeParams) (int64, error)
+	DeleteQueuedAuditLogs(ctx context.Context, ids []int64) error
+	DeleteTransferLimit(ctx context.Context, id int64) error
+	EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (User, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByNumber(ctx context.Context, number string) (Account, error)
+	GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error)
+	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetDailyTransferTotals(ctx context.Context, fromAccountID int64) (GetDailyTransferTotalsRow, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetFeeRule(ctx context.Context, currency string) (FeeRule, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetLastAuditLogHash(ctx context.Context) (string, error)
+	GetOutboxEvent(ctx context.Context, id int64) (Outbox, error)
+	GetOwnerDailyTransferTotals(ctx context.Context, arg GetOwnerDailyTransferTotalsParams) (GetOwnerDailyTransferTotalsRow, error)
+	GetPasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error)
+	GetPayeeAccount(ctx context.Context, arg GetPayeeAccountParams) (Account, error)
+	GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error)
+	GetPaymentRequestByLinkToken(ctx context.Context, linkTokenHash string) (PaymentRequest, error)
+	GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error)
+	GetStepUpRule(ctx context.Context, currency string) (StepUpRule, error)
+	GetTask(ctx context.Context, id int64) (Task, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferLimit(ctx context.Context, id int64) (TransferLimit, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserForUpdate(ctx context.Context, username string) (User, error)
+	GetUserPasswordChangedAt(ctx context.Context, username string) (time.Time, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error)
+	InvalidatePasswordResets(ctx context.Context, username string) error
+	KillTask(ctx context.Context, arg KillTaskParams) error
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListApplicableTransferLimits(ctx context.Context, arg ListApplicableTransferLimitsParams) ([]TransferLimit, error)
+	ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error)
+	ListBulkTransferItems(ctx context.Context, bulkTransferID pgtype.Int8) ([]Transfer, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListExistingAccountIDs(ctx context.Context, ids []int64) ([]int64, error)
+	ListExpiredHolds(ctx context.Context, limit int32) ([]Hold, error)
+	ListFeeRules(ctx context.Context) ([]FeeRule, error)
+	ListHolds(ctx context.Context, arg ListHoldsParams) ([]Hold, error)
+	// The account id stays internal; payees are shown by account number.
+	ListPayees(ctx context.Context, arg ListPayeesParams) ([]ListPayeesRow, error)
+	// Lists the requests the user sent from one of their accounts, received as
+	// the payer, or both.
+	ListPaymentRequests(ctx context.Context, arg ListPaymentRequestsParams) ([]PaymentRequest, error)
+	ListQueuedAuditLogs(ctx context.Context, limit int32) ([]AuditQueue, error)
+	ListStepUpRules(ctx context.Context) ([]StepUpRule, error)
+	ListTasks(ctx context.Context, arg ListTasksParams) ([]Task, error)
+	ListTransferLimits(ctx context.Context) ([]TransferLimit, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListUnpublishedOutboxEvents(ctx context.Context, limit int32) ([]Outbox, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookEndpoints(ctx context.Context, owner string) ([]WebhookEndpoint, error)
+	ListWebhookEndpointsForEvent(ctx context.Context, arg ListWebhookEndpointsForEventParams) ([]WebhookEndpoint, error)
+	// Serialises appends so that every row chains onto the latest one. The
+	// lock is held until the surrounding transaction ends.
+	LockAuditLog(ctx context.Context) error
+	// Only one relay publishes at a time, so that events leave in id order.
+	LockOutbox(ctx context.Context) error
+	// Serialises the transfers of one owner, whose accounts share the limits
+	// that are not tied to an account. The lock is held until the surrounding
+	// transaction ends.
+	LockOwnerTransferLimits(ctx context.Context, owner string) error
+	LockUser(ctx context.Context, arg LockUserParams) error
+	MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) (User, error)
+	MarkOutboxEventsPublished(ctx context.Context, ids []int64) error
+	// Postgres holds the notification back until the transaction commits.
+	NotifyAccountUpdate(ctx context.Context, arg NotifyAccountUpdateParams) error
+	RecordFailedLogin(ctx context.Context, username string) (int32, error)
+	RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) (WebhookDelivery, error)
+	RequeueDeadTask(ctx context.Context, id int64) (Task, error)
+	ResetFailedLogins(ctx context.Context, username string) error
+	ResetFailedWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	RetryTask(ctx context.Context, arg RetryTaskParams) error
+	SetBulkTransferSucceeded(ctx context.Context, arg SetBulkTransferSucceededParams) (BulkTransfer, error)
+	SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateHoldStatus(ctx context.Context, arg UpdateHoldStatusParams) (Hold, error)
+	UpdatePaymentRequestStatus(ctx context.Context, arg UpdatePaymentRequestStatusParams) (PaymentRequest, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpsertFeeRule(ctx context.Context, arg UpsertFeeRuleParams) (FeeRule, error)
+	UpsertStepUpRule(ctx context.Context, arg UpsertStepUpRuleParams) (StepUpRule, error)
+	UseBackupCode(ctx context.Context, arg UseBackupCodeParams) (MfaBackupCode, error)
+	UsePasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error)
+	// Moves the last accepted step forward. Nothing is returned when step is not
+	// past it, so each code can only be used once.
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (User, error)
+	UseVerifyEmail(ctx context.Context, arg UseVerifyEmailParams) (VerifyEmail, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ResetPasswordTxParams struct {
+	TokenHash      string       `json:"token_hash"`
+	HashedPassword string       `json:"hashed_password"`
+	Audit          AuditContext `json:"-"`
+}
+
+type ResetPasswordTxResult struct {
+	User          User          `json:"user"`
+	PasswordReset PasswordReset `json:"password_reset"`
+}
+
+// ResetPasswordTx uses up the reset token, along with any others the user
+// still holds, and sets the new password. Bumping password_changed_at
+// revokes every access token issued before the reset. It fails with
+// ErrRecordNotFound when the token is wrong, used or expired.
+func (s *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+		result.PasswordReset, err = q.UsePasswordReset(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidatePasswordResets(ctx, result.PasswordReset.Username)
+		if err != nil {
+			return err
+		}
+
+		before, err := q.GetUserForUpdate(ctx, result.PasswordReset.Username)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username:          result.PasswordReset.Username,
+			HashedPassword:    pgtype.Text{String: arg.HashedPassword, Valid: true},
+			PasswordChangedAt: pgtype.Timestamp{Time: time.Now().UTC(), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		return auditTokensRevoked(ctx, q, arg.Audit, before, result.User)
+	})
+
+	return result, err
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPasswordReset(t *testing.T, user User, duration time.Duration) (PasswordReset, string) {
+	token := util.RandomString(32)
+	arg := CreatePasswordResetParams{
+		Username:  user.Username,
+		TokenHash: util.HashToken(token),
+		ExpiredAt: time.Now().Add(duration),
+	}
+
+	passwordReset, err := testQueries.CreatePasswordReset(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, passwordReset.Username)
+	require.Equal(t, arg.TokenHash, passwordReset.TokenHash)
+	require.False(t, passwordReset.IsUsed)
+	require.WithinDuration(t, arg.ExpiredAt, passwordReset.ExpiredAt, time.Second)
+
+	return passwordReset, token
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := creatRandomUser(t)
+	_, token := createRandomPasswordReset(t, user, 15*time.Minute)
+	_, otherToken := createRandomPasswordReset(t, user, 15*time.Minute)
+
+	arg := ResetPasswordTxParams{
+		TokenHash:      util.HashToken("wrong"),
+		HashedPassword: "new-hash",
+	}
+	_, err := store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	arg.TokenHash = util.HashToken(token)
+	result, err := store.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.PasswordReset.IsUsed)
+	require.Equal(t, "new-hash", result.User.HashedPassword)
+	require.True(t, result.User.PasswordChangedAt.After(user.PasswordChangedAt))
+
+	// A token can only be used once, and using one burns the rest.
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	arg.TokenHash = util.HashToken(otherToken)
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestResetPasswordTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user := creatRandomUser(t)
+	_, token := createRandomPasswordReset(t, user, -time.Minute)
+
+	_, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      util.HashToken(token),
+		HashedPassword: "new-hash",
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	got, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.HashedPassword, got.HashedPassword)
+}
+
+func TestGetPasswordReset(t *testing.T) {
+	user := creatRandomUser(t)
+	reset, token := createRandomPasswordReset(t, user, 15*time.Minute)
+	_, expiredToken := createRandomPasswordReset(t, user, -time.Minute)
+
+	got, err := testQueries.GetPasswordReset(context.Background(), util.HashToken(token))
+	require.NoError(t, err)
+	require.Equal(t, reset.ID, got.ID)
+
+	_, err = testQueries.GetPasswordReset(context.Background(), util.HashToken(expiredToken))
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
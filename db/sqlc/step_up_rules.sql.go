@@ -0,0 +1,71 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: step_up_rules.sql
+
+package db
+
+import (
+	"context"
+)
+
+const getStepUpRule = `-- name: GetStepUpRule :one
+SELECT currency, threshold, updated_at FROM step_up_rules
+WHERE currency = $1 LIMIT 1
+`
+
+func (q *Queries) GetStepUpRule(ctx context.Context, currency string) (StepUpRule, error) {
+	row := q.db.QueryRow(ctx, getStepUpRule, currency)
+	var i StepUpRule
+	err := row.Scan(&i.Currency, &i.Threshold, &i.UpdatedAt)
+	return i, err
+}
+
+const listStepUpRules = `-- name: ListStepUpRules :many
+SELECT currency, threshold, updated_at FROM step_up_rules
+ORDER BY currency
+`
+
+func (q *Queries) ListStepUpRules(ctx context.Context) ([]StepUpRule, error) {
+	rows, err := q.db.Query(ctx, listStepUpRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StepUpRule{}
+	for rows.Next() {
+		var i StepUpRule
+		if err := rows.Scan(&i.Currency, &i.Threshold, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertStepUpRule = `-- name: UpsertStepUpRule :one
+INSERT INTO step_up_rules (
+  currency, threshold
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (currency) DO UPDATE
+SET threshold = EXCLUDED.threshold,
+  updated_at = now()
+RETURNING currency, threshold, updated_at
+`
+
+type UpsertStepUpRuleParams struct {
+	Currency  string `json:"currency"`
+	Threshold int64  `json:"threshold"`
+}
+
+func (q *Queries) UpsertStepUpRule(ctx context.Context, arg UpsertStepUpRuleParams) (StepUpRule, error) {
+	row := q.db.QueryRow(ctx, upsertStepUpRule, arg.Currency, arg.Threshold)
+	var i StepUpRule
+	err := row.Scan(&i.Currency, &i.Threshold, &i.UpdatedAt)
+	return i, err
+}
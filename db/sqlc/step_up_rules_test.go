@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func upsertRandomStepUpRule(t *testing.T) StepUpRule {
+	args := UpsertStepUpRuleParams{
+		Currency:  util.RandomCurrency(),
+		Threshold: int64(util.RandomInt(1000, 100000)),
+	}
+
+	rule, err := testQueries.UpsertStepUpRule(context.Background(), args)
+	require.NoError(t, err)
+	require.Equal(t, args.Currency, rule.Currency)
+	require.Equal(t, args.Threshold, rule.Threshold)
+	require.NotZero(t, rule.UpdatedAt)
+
+	return rule
+}
+
+func TestUpsertStepUpRule(t *testing.T) {
+	rule1 := upsertRandomStepUpRule(t)
+
+	rule2, err := testQueries.UpsertStepUpRule(context.Background(), UpsertStepUpRuleParams{
+		Currency:  rule1.Currency,
+		Threshold: rule1.Threshold + 1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, rule1.Threshold+1, rule2.Threshold)
+}
+
+func TestGetStepUpRule(t *testing.T) {
+	rule1 := upsertRandomStepUpRule(t)
+	rule2, err := testQueries.GetStepUpRule(context.Background(), rule1.Currency)
+
+	require.NoError(t, err)
+	require.Equal(t, rule1.Currency, rule2.Currency)
+	require.Equal(t, rule1.Threshold, rule2.Threshold)
+}
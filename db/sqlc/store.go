@@ -2,43 +2,136 @@ package db
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
+	"math/rand"
+	"simplebank/metrics"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Store interface {
 	Querier
-	TransferTx(ctx context.Context, arg CreateTransferParams) (TransferTxResult, error)
+	Ping(ctx context.Context) error
+	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	QuoteFee(ctx context.Context, currency string, amount int64) (FeeQuote, error)
+	SetFeeRule(ctx context.Context, arg UpsertFeeRuleParams) (FeeRule, error)
+	CreateHoldTx(ctx context.Context, arg CreateHoldTxParams) (HoldTxResult, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error)
+	ReleaseHoldTx(ctx context.Context, holdID int64) (HoldTxResult, error)
+	ExpireHoldsTx(ctx context.Context, limit int32) (int, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	EnrollTOTPTx(ctx context.Context, arg EnrollTOTPTxParams) (EnrollTOTPTxResult, error)
+	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (Account, error)
+	AppendAuditTx(ctx context.Context, audit AuditContext, event AuditEvent) (AuditLog, error)
+	VerifyAuditLog(ctx context.Context) (AuditVerification, error)
+	SealAuditLogTx(ctx context.Context, limit int32) (int, error)
+	DispatchWebhookEventsTx(ctx context.Context, arg DispatchWebhookEventsTxParams) (int, error)
+	ReplayWebhookDeliveryTx(ctx context.Context, arg ReplayWebhookDeliveryTxParams) (WebhookDelivery, error)
+	RelayOutboxTx(ctx context.Context, arg RelayOutboxTxParams) (int, error)
+	BulkTransferTx(ctx context.Context, arg BulkTransferTxParams) (BulkTransferTxResult, error)
+	AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error)
+	ClosePaymentRequestTx(ctx context.Context, arg ClosePaymentRequestTxParams) (PaymentRequest, error)
 }
 
 type SQLStore struct {
 	*Queries
-	db *sql.DB
+	connPool *pgxpool.Pool
 }
 
-func NewStore(db *sql.DB) Store {
+func NewStore(connPool *pgxpool.Pool) Store {
 	return &SQLStore{
-		db:      db,
-		Queries: New(db),
+		connPool: connPool,
+		Queries:  New(newTracedDB(connPool)),
 	}
 }
 
-func (s *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := s.db.BeginTx(ctx, nil)
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.connPool.Ping(ctx)
+}
+
+const (
+	maxTxRetries   = 5
+	txRetryBackoff = 10 * time.Millisecond
+)
+
+// execTx runs fn in a transaction with the given options. Transactions that
+// fail with a serialization failure or a deadlock are rolled back and run
+// again after a jittered backoff, so fn must not have side effects outside
+// of q.
+func (s *SQLStore) execTx(ctx context.Context, opts pgx.TxOptions, fn func(*Queries) error) error {
+	ctx, span := tracer.Start(ctx, "execTx", trace.WithAttributes(
+		attribute.String("db.tx.isolation", string(opts.IsoLevel)),
+	))
+	defer span.End()
+
+	for attempt := 0; ; attempt++ {
+		err := s.runTx(ctx, opts, fn)
+		code, retryable := retryableTxError(err)
+		if !retryable || attempt >= maxTxRetries {
+			span.SetAttributes(attribute.Int("db.tx.attempts", attempt+1))
+			recordError(span, err)
+			return err
+		}
+
+		metrics.TxRetries.WithLabelValues(code).Inc()
+		span.AddEvent("retry", trace.WithAttributes(attribute.String("db.sqlstate", code)))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(txRetryDelay(attempt)):
+		}
+	}
+}
+
+func (s *SQLStore) runTx(ctx context.Context, opts pgx.TxOptions, fn func(*Queries) error) error {
+	tx, err := s.connPool.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
 
-	q := New(tx)
+	q := New(newTracedDB(tx))
 	err = fn(q)
 
 	if err != nil {
-		if rberr := tx.Rollback(); rberr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rberr)
+		if rberr := tx.Rollback(ctx); rberr != nil {
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rberr)
 		}
 		return err
 	}
-	return tx.Commit()
+	return tx.Commit(ctx)
+}
+
+func retryableTxError(err error) (string, bool) {
+	switch code := ErrorCode(err); code {
+	case SerializationFailure, DeadlockDetected:
+		return code, true
+	}
+	return "", false
+}
+
+// txRetryDelay picks a random delay below an exponentially growing ceiling.
+func txRetryDelay(attempt int) time.Duration {
+	ceiling := txRetryBackoff << attempt
+	return time.Duration(rand.Int63n(int64(ceiling))) + txRetryBackoff/2
+}
+
+type TransferTxParams struct {
+	FromAccountID int64        `json:"from_account_id"`
+	ToAccountID   int64        `json:"to_account_id"`
+	Amount        int64        `json:"amount"`
+	Quote         FeeQuote     `json:"quote"`
+	Reference     string       `json:"reference"`
+	Audit         AuditContext `json:"-"`
 }
 
 type TransferTxResult struct {
@@ -47,87 +140,225 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account_id"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	FeeEntry    *Entry   `json:"fee_entry,omitempty"`
+
+	Allowance TransferAllowance `json:"allowance"`
 }
 
-func (s *SQLStore) TransferTx(ctx context.Context, arg CreateTransferParams) (TransferTxResult, error) {
+func (s *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
-	err := s.execTx(ctx, func(q *Queries) error {
+	start := time.Now()
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
 		var err error
-		result.Transfer, err = q.CreateTransfer(ctx, arg)
+		result, err = transferTx(ctx, q, arg)
 		if err != nil {
 			return err
 		}
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+		return notifyAccountUpdates(ctx, q, result)
+	})
+	metrics.TransferTxDuration.Observe(time.Since(start).Seconds())
+	observeTransfer(result, err)
+
+	return result, err
+}
+
+func observeTransfer(result TransferTxResult, err error) {
+	if err != nil {
+		metrics.TransferTxErrors.WithLabelValues(transferErrorType(err)).Inc()
+		return
+	}
+	metrics.Transfers.WithLabelValues(result.FromAccount.Currency).Inc()
+	metrics.TransferVolume.WithLabelValues(result.FromAccount.Currency).Add(float64(result.Transfer.Amount))
+}
+
+func transferErrorType(err error) string {
+	var limitErr *TransferLimitError
+	switch {
+	case errors.As(err, &limitErr):
+		return "limit_exceeded"
+	case errors.Is(err, ErrInsufficientFunds):
+		return "insufficient_funds"
+	case errors.Is(err, ErrHoldNotPending), errors.Is(err, ErrHoldExpired):
+		return "invalid_hold"
+	case errors.Is(err, ErrPaymentRequestNotPending), errors.Is(err, ErrPaymentRequestExpired):
+		return "invalid_payment_request"
+	case errors.Is(err, ErrFeeAccountCurrencyMismatch):
+		return "fee_account_currency"
+	case errors.Is(err, ErrRecordNotFound):
+		return "not_found"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	}
+	switch ErrorCode(err) {
+	case SerializationFailure:
+		return "serialization_failure"
+	case DeadlockDetected:
+		return "deadlock"
+	case ForeignKeyViolation:
+		return "foreign_key_violation"
+	case "":
+		return "unknown"
+	}
+	return "database"
+}
+
+func transferTx(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	locked, err := lockTransferAccounts(ctx, q, arg)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	return applyTransfer(ctx, q, locked, arg, pgtype.Int8{})
+}
+
+func lockTransferAccounts(ctx context.Context, q *Queries, arg TransferTxParams) (map[int64]Account, error) {
+	ids := []int64{arg.FromAccountID, arg.ToAccountID}
+	if arg.Quote.Fee > 0 {
+		ids = append(ids, arg.Quote.FeeAccountID)
+	}
+	return lockAccounts(ctx, q, ids)
+}
+
+// applyTransfer moves the money of a transfer whose accounts are already
+// locked, and updates locked with their new state so that it can be reused
+// by a following transfer in the same transaction. Money reserved by holds
+// is not spendable, so the amount and fee must fit the available balance.
+func applyTransfer(ctx context.Context, q *Queries, locked map[int64]Account, arg TransferTxParams, bulkTransferID pgtype.Int8) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	fee := arg.Quote.Fee
+	if fee > 0 && locked[arg.Quote.FeeAccountID].Currency != locked[arg.FromAccountID].Currency {
+		return result, ErrFeeAccountCurrencyMismatch
+	}
+	if locked[arg.FromAccountID].AvailableBalance < arg.Amount+fee {
+		return result, ErrInsufficientFunds
+	}
+	result.Allowance, err = checkTransferLimits(ctx, q, locked[arg.FromAccountID], arg.Amount)
+	if err != nil {
+		return result, err
+	}
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID:  arg.FromAccountID,
+		ToAccountID:    arg.ToAccountID,
+		Amount:         arg.Amount,
+		Fee:            fee,
+		Reference:      arg.Reference,
+		BulkTransferID: bulkTransferID,
+	})
+	if err != nil {
+		return result, err
+	}
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount:    -(arg.Amount + fee),
+	})
+	if err != nil {
+		return result, err
+	}
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount:    arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	updates := []AddAccountBalanceParams{
+		{ID: arg.FromAccountID, Amount: -(arg.Amount + fee)},
+		{ID: arg.ToAccountID, Amount: arg.Amount},
+	}
+
+	if fee > 0 {
+		feeEntry, err := q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: arg.Quote.FeeAccountID,
+			Amount:    fee,
 		})
 		if err != nil {
-			return err
+			return result, err
 		}
+		result.FeeEntry = &feeEntry
+		updates = append(updates, AddAccountBalanceParams{ID: arg.Quote.FeeAccountID, Amount: fee})
+	}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = sendMoney(ctx, q, SendMoneyParam{
-				SenderID:       arg.FromAccountID,
-				SenderAmount:   -arg.Amount,
-				RecieverID:     arg.ToAccountID,
-				RecieverAmount: arg.Amount})
-		} else {
-			result.ToAccount, result.FromAccount, err = sendMoney(ctx, q, SendMoneyParam{
-				SenderID:       arg.ToAccountID,
-				SenderAmount:   arg.Amount,
-				RecieverID:     arg.FromAccountID,
-				RecieverAmount: -arg.Amount})
-		}
+	accounts, err := addAccountBalances(ctx, q, updates)
+	if err != nil {
+		return result, err
+	}
+	result.FromAccount = accounts[arg.FromAccountID]
+	result.ToAccount = accounts[arg.ToAccountID]
 
-		// result.FromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		// 	ID:     arg.FromAccountID,
-		// 	Amount: -arg.Amount,
-		// })
-		// if err != nil {
-		// 	return err
-		// }
-
-		// result.ToAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		// 	ID:     arg.ToAccountID,
-		// 	Amount: arg.Amount,
-		// })
-		// if err != nil {
-		// 	return err
-		// }
+	if err := createOutboxEvents(ctx, q, transferEvents(result)...); err != nil {
+		return result, err
+	}
 
-		return err
+	err = queueAudit(ctx, q, arg.Audit, AuditEvent{
+		Action:   AuditTransferCreated,
+		Resource: TransferResource(result.Transfer.ID),
+		Before: auditTransfer{
+			FromAccount: locked[arg.FromAccountID],
+			ToAccount:   locked[arg.ToAccountID],
+		},
+		After: auditTransfer{
+			Transfer:    &result.Transfer,
+			FromAccount: result.FromAccount,
+			ToAccount:   result.ToAccount,
+		},
 	})
+	if err != nil {
+		return result, err
+	}
 
-	return result, err
+	for id, acc := range accounts {
+		locked[id] = acc
+	}
+	return result, nil
 }
 
-type SendMoneyParam struct {
-	SenderID       int64
-	SenderAmount   int64
-	RecieverID     int64
-	RecieverAmount int64
+type auditTransfer struct {
+	Transfer    *Transfer `json:"transfer,omitempty"`
+	FromAccount Account   `json:"from_account"`
+	ToAccount   Account   `json:"to_account"`
 }
 
-func sendMoney(ctx context.Context, q *Queries, s SendMoneyParam) (acc1 Account, acc2 Account, err error) {
-	acc1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     s.SenderID,
-		Amount: s.SenderAmount,
+// lockAccounts takes the row locks of the accounts in ascending ID order so
+// that concurrent transactions can never wait on each other in a cycle.
+func lockAccounts(ctx context.Context, q *Queries, ids []int64) (map[int64]Account, error) {
+	sorted := append([]int64(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
 	})
-	if err != nil {
-		return
+
+	accounts := make(map[int64]Account, len(sorted))
+	for _, id := range sorted {
+		if _, ok := accounts[id]; ok {
+			continue
+		}
+		acc, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		accounts[id] = acc
 	}
+	return accounts, nil
+}
 
-	acc2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     s.RecieverID,
-		Amount: s.RecieverAmount,
+// addAccountBalances applies the updates in ascending account ID order so
+// that concurrent transactions always take the row locks in the same order.
+func addAccountBalances(ctx context.Context, q *Queries, updates []AddAccountBalanceParams) (map[int64]Account, error) {
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].ID < updates[j].ID
 	})
-	return
+
+	accounts := make(map[int64]Account, len(updates))
+	for _, u := range updates {
+		acc, err := q.AddAccountBalance(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		accounts[u.ID] = acc
+	}
+	return accounts, nil
 }
@@ -2,17 +2,21 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	acc1 := creatRandomAccount(t)
-	acc2 := creatRandomAccount(t)
+	acc1 := createAccountInCurrency(t, "USD")
+	acc2 := createAccountInCurrency(t, "USD")
 	log.Println("==before==", acc1.Balance, acc2.Balance)
 
 	n := 5
@@ -24,7 +28,7 @@ func TestTransferTx(t *testing.T) {
 
 	for i := 0; i < n; i++ {
 		go func() {
-			result, err := store.TransferTx(context.Background(), CreateTransferParams{
+			result, err := store.TransferTx(context.Background(), TransferTxParams{
 				FromAccountID: acc1.ID,
 				ToAccountID:   acc2.ID,
 				Amount:        amount,
@@ -108,8 +112,8 @@ func TestTransferTx(t *testing.T) {
 func TestTransferTxDeadlock(t *testing.T) {
 	store := NewStore(testDB)
 
-	acc1 := creatRandomAccount(t)
-	acc2 := creatRandomAccount(t)
+	acc1 := createAccountInCurrency(t, "USD")
+	acc2 := createAccountInCurrency(t, "USD")
 	log.Println("==before==", acc1.Balance, acc2.Balance)
 
 	n := 10
@@ -125,7 +129,7 @@ func TestTransferTxDeadlock(t *testing.T) {
 			toAccountID = acc1.ID
 		}
 		go func() {
-			_, err := store.TransferTx(context.Background(), CreateTransferParams{
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
 				FromAccountID: fromAccountID,
 				ToAccountID:   toAccountID,
 				Amount:        amount,
@@ -150,3 +154,226 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, acc1.Balance, updatedAccount1.Balance)
 	require.Equal(t, acc2.Balance, updatedAccount2.Balance)
 }
+
+func TestTransferTxWithFee(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := creatRandomAccount(t)
+	acc2 := creatRandomAccount(t)
+	feeAccount := creatRandomAccount(t)
+
+	amount := int64(10)
+	quote := FeeQuote{
+		Currency:     acc1.Currency,
+		Amount:       amount,
+		Fee:          2,
+		Total:        amount + 2,
+		FeeAccountID: feeAccount.ID,
+	}
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        amount,
+		Quote:         quote,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.Equal(t, quote.Fee, result.Transfer.Fee)
+	require.Equal(t, -quote.Total, result.FromEntry.Amount)
+	require.Equal(t, amount, result.ToEntry.Amount)
+
+	require.NotNil(t, result.FeeEntry)
+	require.Equal(t, feeAccount.ID, result.FeeEntry.AccountID)
+	require.Equal(t, quote.Fee, result.FeeEntry.Amount)
+
+	require.Equal(t, acc1.Balance-quote.Total, result.FromAccount.Balance)
+	require.Equal(t, acc2.Balance+amount, result.ToAccount.Balance)
+
+	updatedFeeAccount, err := testQueries.GetAccount(context.Background(), feeAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, feeAccount.Balance+quote.Fee, updatedFeeAccount.Balance)
+}
+
+func TestTransferTxLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	acc1 := createAccountInCurrency(t, "USD")
+	acc2 := createAccountInCurrency(t, "USD")
+	createAccountTransferLimit(t, acc1, 50, 60, 2)
+
+	transfer := func(amount int64) (TransferTxResult, error) {
+		return store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: acc1.ID,
+			ToAccountID:   acc2.ID,
+			Amount:        amount,
+		})
+	}
+
+	_, err := transfer(51)
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitMaxAmount, limitErr.Limit)
+
+	result, err := transfer(40)
+	require.NoError(t, err)
+	require.Equal(t, int64(50), *result.Allowance.MaxAmount)
+	require.Equal(t, int64(20), *result.Allowance.RemainingAmount)
+	require.Equal(t, int64(1), *result.Allowance.RemainingCount)
+
+	_, err = transfer(30)
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitDailyAmount, limitErr.Limit)
+	require.Equal(t, int64(20), *limitErr.Allowance.RemainingAmount)
+
+	_, err = transfer(20)
+	require.NoError(t, err)
+
+	_, err = transfer(1)
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitDailyAmount, limitErr.Limit)
+}
+
+// createOwnerAccounts opens a USD and an EUR account for one new user, with
+// a daily limit on the user's USD transfers.
+func createOwnerAccounts(t *testing.T, dailyAmount, dailyCount int64) (Account, Account) {
+	user := creatRandomUser(t)
+
+	var accounts []Account
+	for _, currency := range []string{"USD", "EUR"} {
+		acc, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  1000,
+			Currency: currency,
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, acc)
+	}
+
+	_, err := testQueries.CreateTransferLimit(context.Background(), CreateTransferLimitParams{
+		Owner:       pgtype.Text{String: user.Username, Valid: true},
+		Currency:    "USD",
+		DailyAmount: dailyAmount,
+		DailyCount:  dailyCount,
+	})
+	require.NoError(t, err)
+
+	return accounts[0], accounts[1]
+}
+
+func TestTransferTxOwnerLimit(t *testing.T) {
+	store := NewStore(testDB)
+	usd, eur := createOwnerAccounts(t, 100, 0)
+	toUSD := createAccountInCurrency(t, "USD")
+	toEUR := createAccountInCurrency(t, "EUR")
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: usd.ID,
+		ToAccountID:   toUSD.ID,
+		Amount:        60,
+	})
+	require.NoError(t, err)
+
+	// EUR transfers neither count towards nor are held to a USD limit.
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: eur.ID,
+		ToAccountID:   toEUR.ID,
+		Amount:        60,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: usd.ID,
+		ToAccountID:   toUSD.ID,
+		Amount:        60,
+	})
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitDailyAmount, limitErr.Limit)
+	require.Equal(t, int64(40), *limitErr.Allowance.RemainingAmount)
+}
+
+func TestTransferTxOwnerLimitConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+	usd, _ := createOwnerAccounts(t, 0, 1)
+	to := createAccountInCurrency(t, "USD")
+
+	errs := make(chan error)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: usd.ID,
+				ToAccountID:   to.ID,
+				Amount:        10,
+			})
+			errs <- err
+		}()
+	}
+
+	var failed int
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			var limitErr *TransferLimitError
+			require.ErrorAs(t, err, &limitErr)
+			require.Equal(t, LimitDailyCount, limitErr.Limit)
+			failed++
+		}
+	}
+	require.Equal(t, 1, failed)
+}
+
+func TestRetryableTxError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		code      string
+		retryable bool
+	}{
+		{name: "SerializationFailure", err: &pgconn.PgError{Code: SerializationFailure}, code: SerializationFailure, retryable: true},
+		{name: "DeadlockDetected", err: &pgconn.PgError{Code: DeadlockDetected}, code: DeadlockDetected, retryable: true},
+		{name: "Wrapped", err: fmt.Errorf("tx err: %w, rb err: %v", &pgconn.PgError{Code: SerializationFailure}, pgx.ErrTxClosed), code: SerializationFailure, retryable: true},
+		{name: "UniqueViolation", err: ErrUniqueViolation},
+		{name: "NoRows", err: ErrRecordNotFound},
+		{name: "Nil"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, retryable := retryableTxError(tc.err)
+			require.Equal(t, tc.code, code)
+			require.Equal(t, tc.retryable, retryable)
+		})
+	}
+}
+
+func TestTxRetryDelay(t *testing.T) {
+	for attempt := 0; attempt < maxTxRetries; attempt++ {
+		delay := txRetryDelay(attempt)
+		require.GreaterOrEqual(t, delay, txRetryBackoff/2)
+		require.Less(t, delay, txRetryBackoff<<attempt+txRetryBackoff/2)
+	}
+}
+
+func TestTransferErrorType(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "LimitExceeded", err: &TransferLimitError{Limit: LimitMaxAmount}, want: "limit_exceeded"},
+		{name: "InsufficientFunds", err: ErrInsufficientFunds, want: "insufficient_funds"},
+		{name: "NotFound", err: ErrRecordNotFound, want: "not_found"},
+		{name: "Canceled", err: context.Canceled, want: "canceled"},
+		{name: "SerializationFailure", err: &pgconn.PgError{Code: SerializationFailure}, want: "serialization_failure"},
+		{name: "Deadlock", err: &pgconn.PgError{Code: DeadlockDetected}, want: "deadlock"},
+		{name: "Database", err: ErrUniqueViolation, want: "database"},
+		{name: "Unknown", err: fmt.Errorf("boom"), want: "unknown"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, transferErrorType(tc.err))
+		})
+	}
+}
@@ -0,0 +1,303 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: tasks.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const claimTasks = `-- name: ClaimTasks :many
+UPDATE tasks
+SET status = 'running',
+    attempts = attempts + 1,
+    locked_until = $1,
+    updated_at = now()
+WHERE id IN (
+  SELECT t.id FROM tasks t
+  WHERE t.queue = $2
+    AND (
+      (t.status = 'pending' AND t.run_at <= now())
+      OR (t.status = 'running' AND t.locked_until < now())
+    )
+  ORDER BY t.run_at, t.id
+  LIMIT $3
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, queue, type, payload, status, attempts, max_attempts, last_error, run_at, locked_until, created_at, updated_at
+`
+
+type ClaimTasksParams struct {
+	LockedUntil time.Time `json:"locked_until"`
+	Queue       string    `json:"queue"`
+	Limit       int32     `json:"limit"`
+}
+
+func (q *Queries) ClaimTasks(ctx context.Context, arg ClaimTasksParams) ([]Task, error) {
+	rows, err := q.db.Query(ctx, claimTasks, arg.LockedUntil, arg.Queue, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Task{}
+	for rows.Next() {
+		var i Task
+		if err := rows.Scan(
+			&i.ID,
+			&i.Queue,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.LastError,
+			&i.RunAt,
+			&i.LockedUntil,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeTask = `-- name: CompleteTask :exec
+UPDATE tasks
+SET status = 'completed',
+    last_error = '',
+    updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) CompleteTask(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, completeTask, id)
+	return err
+}
+
+const countTasks = `-- name: CountTasks :many
+SELECT queue, status, count(*) AS count FROM tasks
+GROUP BY queue, status
+ORDER BY queue, status
+`
+
+type CountTasksRow struct {
+	Queue  string `json:"queue"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+func (q *Queries) CountTasks(ctx context.Context) ([]CountTasksRow, error) {
+	rows, err := q.db.Query(ctx, countTasks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountTasksRow{}
+	for rows.Next() {
+		var i CountTasksRow
+		if err := rows.Scan(&i.Queue, &i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createTask = `-- name: CreateTask :one
+INSERT INTO tasks (
+  queue, type, payload, max_attempts, run_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING id, queue, type, payload, status, attempts, max_attempts, last_error, run_at, locked_until, created_at, updated_at
+`
+
+type CreateTaskParams struct {
+	Queue       string    `json:"queue"`
+	Type        string    `json:"type"`
+	Payload     []byte    `json:"payload"`
+	MaxAttempts int32     `json:"max_attempts"`
+	RunAt       time.Time `json:"run_at"`
+}
+
+func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error) {
+	row := q.db.QueryRow(ctx, createTask,
+		arg.Queue,
+		arg.Type,
+		arg.Payload,
+		arg.MaxAttempts,
+		arg.RunAt,
+	)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.Queue,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.LastError,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getTask = `-- name: GetTask :one
+SELECT id, queue, type, payload, status, attempts, max_attempts, last_error, run_at, locked_until, created_at, updated_at FROM tasks
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetTask(ctx context.Context, id int64) (Task, error) {
+	row := q.db.QueryRow(ctx, getTask, id)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.Queue,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.LastError,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const killTask = `-- name: KillTask :exec
+UPDATE tasks
+SET status = 'dead',
+    last_error = $1,
+    updated_at = now()
+WHERE id = $2
+`
+
+type KillTaskParams struct {
+	LastError string `json:"last_error"`
+	ID        int64  `json:"id"`
+}
+
+func (q *Queries) KillTask(ctx context.Context, arg KillTaskParams) error {
+	_, err := q.db.Exec(ctx, killTask, arg.LastError, arg.ID)
+	return err
+}
+
+const listTasks = `-- name: ListTasks :many
+SELECT id, queue, type, payload, status, attempts, max_attempts, last_error, run_at, locked_until, created_at, updated_at FROM tasks
+WHERE queue = $1 AND status = $2
+ORDER BY id DESC
+LIMIT $3
+OFFSET $4
+`
+
+type ListTasksParams struct {
+	Queue  string `json:"queue"`
+	Status string `json:"status"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (q *Queries) ListTasks(ctx context.Context, arg ListTasksParams) ([]Task, error) {
+	rows, err := q.db.Query(ctx, listTasks,
+		arg.Queue,
+		arg.Status,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Task{}
+	for rows.Next() {
+		var i Task
+		if err := rows.Scan(
+			&i.ID,
+			&i.Queue,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.LastError,
+			&i.RunAt,
+			&i.LockedUntil,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const requeueDeadTask = `-- name: RequeueDeadTask :one
+UPDATE tasks
+SET status = 'pending',
+    attempts = 0,
+    run_at = now(),
+    updated_at = now()
+WHERE id = $1 AND status = 'dead'
+RETURNING id, queue, type, payload, status, attempts, max_attempts, last_error, run_at, locked_until, created_at, updated_at
+`
+
+func (q *Queries) RequeueDeadTask(ctx context.Context, id int64) (Task, error) {
+	row := q.db.QueryRow(ctx, requeueDeadTask, id)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.Queue,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.LastError,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const retryTask = `-- name: RetryTask :exec
+UPDATE tasks
+SET status = 'pending',
+    run_at = $1,
+    last_error = $2,
+    updated_at = now()
+WHERE id = $3
+`
+
+type RetryTaskParams struct {
+	RunAt     time.Time `json:"run_at"`
+	LastError string    `json:"last_error"`
+	ID        int64     `json:"id"`
+}
+
+func (q *Queries) RetryTask(ctx context.Context, arg RetryTaskParams) error {
+	_, err := q.db.Exec(ctx, retryTask, arg.RunAt, arg.LastError, arg.ID)
+	return err
+}
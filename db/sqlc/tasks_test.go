@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTask(t *testing.T, queue string) Task {
+	arg := CreateTaskParams{
+		Queue:       queue,
+		Type:        "test:" + util.RandomString(6),
+		Payload:     []byte(`{"n":1}`),
+		MaxAttempts: 3,
+		RunAt:       time.Now().Add(-time.Second),
+	}
+
+	task, err := testQueries.CreateTask(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Queue, task.Queue)
+	require.Equal(t, arg.Type, task.Type)
+	require.JSONEq(t, string(arg.Payload), string(task.Payload))
+	require.Equal(t, "pending", task.Status)
+	require.Zero(t, task.Attempts)
+
+	return task
+}
+
+func TestClaimTasks(t *testing.T) {
+	queue := "test-" + util.RandomString(8)
+	task1 := createRandomTask(t, queue)
+	task2 := createRandomTask(t, queue)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	tasks, err := testQueries.ClaimTasks(context.Background(), ClaimTasksParams{
+		Queue:       queue,
+		LockedUntil: lockedUntil,
+		Limit:       1,
+	})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, task1.ID, tasks[0].ID)
+	require.Equal(t, "running", tasks[0].Status)
+	require.Equal(t, int32(1), tasks[0].Attempts)
+	require.WithinDuration(t, lockedUntil, tasks[0].LockedUntil, time.Second)
+
+	// The running task is leased, so only the second one is left.
+	tasks, err = testQueries.ClaimTasks(context.Background(), ClaimTasksParams{
+		Queue:       queue,
+		LockedUntil: lockedUntil,
+		Limit:       10,
+	})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, task2.ID, tasks[0].ID)
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	queue := "test-" + util.RandomString(8)
+	task := createRandomTask(t, queue)
+
+	err := testQueries.RetryTask(context.Background(), RetryTaskParams{
+		ID:        task.ID,
+		RunAt:     time.Now().Add(time.Hour),
+		LastError: "boom",
+	})
+	require.NoError(t, err)
+
+	tasks, err := testQueries.ClaimTasks(context.Background(), ClaimTasksParams{
+		Queue:       queue,
+		LockedUntil: time.Now().Add(time.Minute),
+		Limit:       10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, tasks)
+
+	err = testQueries.KillTask(context.Background(), KillTaskParams{ID: task.ID, LastError: "gave up"})
+	require.NoError(t, err)
+
+	dead, err := testQueries.ListTasks(context.Background(), ListTasksParams{
+		Queue:  queue,
+		Status: "dead",
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	require.Equal(t, "gave up", dead[0].LastError)
+
+	task, err = testQueries.RequeueDeadTask(context.Background(), task.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pending", task.Status)
+	require.Zero(t, task.Attempts)
+
+	_, err = testQueries.RequeueDeadTask(context.Background(), task.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	err = testQueries.CompleteTask(context.Background(), task.ID)
+	require.NoError(t, err)
+
+	task, err = testQueries.GetTask(context.Background(), task.ID)
+	require.NoError(t, err)
+	require.Equal(t, "completed", task.Status)
+
+	counts, err := testQueries.CountTasks(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, counts)
+}
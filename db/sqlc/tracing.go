@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("simplebank/db")
+
+// tracedDB wraps a DBTX so that every sqlc query runs in its own span, named
+// after the query.
+type tracedDB struct {
+	db DBTX
+}
+
+func newTracedDB(db DBTX) DBTX {
+	return &tracedDB{db: db}
+}
+
+func (t *tracedDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := startQuerySpan(ctx, sql)
+	defer span.End()
+
+	tag, err := t.db.Exec(ctx, sql, args...)
+	span.SetAttributes(attribute.Int64("db.rows_affected", tag.RowsAffected()))
+	recordError(span, err)
+	return tag, err
+}
+
+func (t *tracedDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := startQuerySpan(ctx, sql)
+
+	rows, err := t.db.Query(ctx, sql, args...)
+	if err != nil {
+		recordError(span, err)
+		span.End()
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (t *tracedDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, span := startQuerySpan(ctx, sql)
+	return &tracedRow{row: t.db.QueryRow(ctx, sql, args...), span: span}
+}
+
+// tracedRows ends its span once the rows are closed, so the span covers the
+// time spent reading results as well.
+type tracedRows struct {
+	pgx.Rows
+	span trace.Span
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	if r.span == nil {
+		return
+	}
+	r.span.SetAttributes(attribute.Int64("db.rows_affected", r.Rows.CommandTag().RowsAffected()))
+	recordError(r.span, r.Rows.Err())
+	r.span.End()
+	r.span = nil
+}
+
+type tracedRow struct {
+	row  pgx.Row
+	span trace.Span
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	defer r.span.End()
+
+	err := r.row.Scan(dest...)
+	if !errors.Is(err, ErrRecordNotFound) {
+		recordError(r.span, err)
+	}
+	return err
+}
+
+func startQuerySpan(ctx context.Context, sql string) (context.Context, trace.Span) {
+	name := queryName(sql)
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation(name),
+			semconv.DBStatement(sql),
+		),
+	)
+}
+
+// queryName extracts the sqlc query name from the "-- name: X :kind" header.
+func queryName(sql string) string {
+	const prefix = "-- name: "
+	if !strings.HasPrefix(sql, prefix) {
+		return "query"
+	}
+	fields := strings.Fields(sql[len(prefix):])
+	if len(fields) == 0 {
+		return "query"
+	}
+	return fields[0]
+}
+
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type fakeRow struct {
+	err error
+}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	return r.err
+}
+
+type fakeDBTX struct {
+	rowErr error
+}
+
+func (f fakeDBTX) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (f fakeDBTX) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, ErrUniqueViolation
+}
+
+func (f fakeDBTX) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return fakeRow{err: f.rowErr}
+}
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "GetAccount", queryName(getAccount))
+	require.Equal(t, "ListAccounts", queryName(listAccounts))
+	require.Equal(t, "query", queryName("SELECT 1"))
+	require.Equal(t, "query", queryName("-- name: "))
+}
+
+func TestTracedDB(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer provider.Shutdown(context.Background())
+
+	q := New(newTracedDB(fakeDBTX{rowErr: ErrRecordNotFound}))
+
+	_, err := q.GetAccount(context.Background(), 1)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	_, err = q.ListAccounts(context.Background(), ListAccountsParams{Limit: 5})
+	require.Error(t, err)
+	require.NoError(t, q.DeleteAccount(context.Background(), 1))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	require.Equal(t, "GetAccount", spans[0].Name())
+	require.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	require.Equal(t, "ListAccounts", spans[1].Name())
+	require.Equal(t, codes.Error, spans[1].Status().Code)
+
+	require.Equal(t, "DeleteAccount", spans[2].Name())
+	require.Equal(t, codes.Unset, spans[2].Status().Code)
+}
@@ -0,0 +1,172 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: transfer_limits.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createTransferLimit = `-- name: CreateTransferLimit :one
+INSERT INTO transfer_limits (
+  owner, account_id, currency, max_amount, daily_amount, daily_count
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, owner, account_id, currency, max_amount, daily_amount, daily_count, created_at
+`
+
+type CreateTransferLimitParams struct {
+	Owner       pgtype.Text `json:"owner"`
+	AccountID   pgtype.Int8 `json:"account_id"`
+	Currency    string      `json:"currency"`
+	MaxAmount   int64       `json:"max_amount"`
+	DailyAmount int64       `json:"daily_amount"`
+	DailyCount  int64       `json:"daily_count"`
+}
+
+func (q *Queries) CreateTransferLimit(ctx context.Context, arg CreateTransferLimitParams) (TransferLimit, error) {
+	row := q.db.QueryRow(ctx, createTransferLimit,
+		arg.Owner,
+		arg.AccountID,
+		arg.Currency,
+		arg.MaxAmount,
+		arg.DailyAmount,
+		arg.DailyCount,
+	)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.AccountID,
+		&i.Currency,
+		&i.MaxAmount,
+		&i.DailyAmount,
+		&i.DailyCount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteTransferLimit = `-- name: DeleteTransferLimit :exec
+DELETE FROM transfer_limits
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTransferLimit(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteTransferLimit, id)
+	return err
+}
+
+const getTransferLimit = `-- name: GetTransferLimit :one
+SELECT id, owner, account_id, currency, max_amount, daily_amount, daily_count, created_at FROM transfer_limits
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetTransferLimit(ctx context.Context, id int64) (TransferLimit, error) {
+	row := q.db.QueryRow(ctx, getTransferLimit, id)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.AccountID,
+		&i.Currency,
+		&i.MaxAmount,
+		&i.DailyAmount,
+		&i.DailyCount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listApplicableTransferLimits = `-- name: ListApplicableTransferLimits :many
+SELECT id, owner, account_id, currency, max_amount, daily_amount, daily_count, created_at FROM transfer_limits
+WHERE (owner IS NULL OR owner = $1::varchar)
+  AND (account_id IS NULL OR account_id = $2::bigint)
+  AND currency = $3::varchar
+ORDER BY id
+`
+
+type ListApplicableTransferLimitsParams struct {
+	Owner     string `json:"owner"`
+	AccountID int64  `json:"account_id"`
+	Currency  string `json:"currency"`
+}
+
+func (q *Queries) ListApplicableTransferLimits(ctx context.Context, arg ListApplicableTransferLimitsParams) ([]TransferLimit, error) {
+	rows, err := q.db.Query(ctx, listApplicableTransferLimits, arg.Owner, arg.AccountID, arg.Currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferLimit{}
+	for rows.Next() {
+		var i TransferLimit
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.AccountID,
+			&i.Currency,
+			&i.MaxAmount,
+			&i.DailyAmount,
+			&i.DailyCount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferLimits = `-- name: ListTransferLimits :many
+SELECT id, owner, account_id, currency, max_amount, daily_amount, daily_count, created_at FROM transfer_limits
+ORDER BY id
+`
+
+func (q *Queries) ListTransferLimits(ctx context.Context) ([]TransferLimit, error) {
+	rows, err := q.db.Query(ctx, listTransferLimits)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferLimit{}
+	for rows.Next() {
+		var i TransferLimit
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.AccountID,
+			&i.Currency,
+			&i.MaxAmount,
+			&i.DailyAmount,
+			&i.DailyCount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockOwnerTransferLimits = `-- name: LockOwnerTransferLimits :exec
+SELECT pg_advisory_xact_lock(hashtext('transfer_limits:' || $1::varchar))
+`
+
+// Serialises the transfers of one owner, whose accounts share the limits
+// that are not tied to an account. The lock is held until the surrounding
+// transaction ends.
+func (q *Queries) LockOwnerTransferLimits(ctx context.Context, owner string) error {
+	_, err := q.db.Exec(ctx, lockOwnerTransferLimits, owner)
+	return err
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createAccountTransferLimit(t *testing.T, acc Account, maxAmount, dailyAmount, dailyCount int64) TransferLimit {
+	args := CreateTransferLimitParams{
+		AccountID:   pgtype.Int8{Int64: acc.ID, Valid: true},
+		Currency:    acc.Currency,
+		MaxAmount:   maxAmount,
+		DailyAmount: dailyAmount,
+		DailyCount:  dailyCount,
+	}
+
+	limit, err := testQueries.CreateTransferLimit(context.Background(), args)
+	require.NoError(t, err)
+	require.NotZero(t, limit.ID)
+	require.Equal(t, args.AccountID, limit.AccountID)
+	require.False(t, limit.Owner.Valid)
+	require.Equal(t, acc.Currency, limit.Currency)
+	require.Equal(t, args.MaxAmount, limit.MaxAmount)
+	require.Equal(t, args.DailyAmount, limit.DailyAmount)
+	require.Equal(t, args.DailyCount, limit.DailyCount)
+	require.NotZero(t, limit.CreatedAt)
+
+	return limit
+}
+
+func TestCreateTransferLimit(t *testing.T) {
+	acc := creatRandomAccount(t)
+	createAccountTransferLimit(t, acc, 100, 1000, 10)
+}
+
+func TestListApplicableTransferLimits(t *testing.T) {
+	acc := creatRandomAccount(t)
+	other := creatRandomAccount(t)
+	limit := createAccountTransferLimit(t, acc, 100, 1000, 10)
+	createAccountTransferLimit(t, other, 100, 1000, 10)
+
+	limits, err := testQueries.ListApplicableTransferLimits(context.Background(), ListApplicableTransferLimitsParams{
+		Owner:     acc.Owner,
+		AccountID: acc.ID,
+		Currency:  acc.Currency,
+	})
+	require.NoError(t, err)
+
+	var found bool
+	for _, l := range limits {
+		require.False(t, l.AccountID.Valid && l.AccountID.Int64 != acc.ID)
+		if l.ID == limit.ID {
+			found = true
+		}
+	}
+	require.True(t, found)
+}
+
+func TestDeleteTransferLimit(t *testing.T) {
+	acc := creatRandomAccount(t)
+	limit := createAccountTransferLimit(t, acc, 100, 1000, 10)
+
+	err := testQueries.DeleteTransferLimit(context.Background(), limit.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetTransferLimit(context.Background(), limit.ID)
+	require.EqualError(t, err, ErrRecordNotFound.Error())
+}
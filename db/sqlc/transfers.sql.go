@@ -1,30 +1,42 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.15.0
+//   sqlc v1.18.0
 // source: transfers.sql
 
 package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
-  from_account_id, to_account_id, amount
+  from_account_id, to_account_id, amount, fee, reference, bulk_transfer_id
 ) VALUES (
-  $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, from_account_id, to_account_id, amount, created_at, fee, reference, bulk_transfer_id
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID  int64       `json:"from_account_id"`
+	ToAccountID    int64       `json:"to_account_id"`
+	Amount         int64       `json:"amount"`
+	Fee            int64       `json:"fee"`
+	Reference      string      `json:"reference"`
+	BulkTransferID pgtype.Int8 `json:"bulk_transfer_id"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Fee,
+		arg.Reference,
+		arg.BulkTransferID,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -32,17 +44,69 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Fee,
+		&i.Reference,
+		&i.BulkTransferID,
 	)
 	return i, err
 }
 
+const getDailyTransferTotals = `-- name: GetDailyTransferTotals :one
+SELECT
+  COALESCE(SUM(amount), 0)::bigint AS total_amount,
+  COUNT(*) AS total_count
+FROM transfers
+WHERE from_account_id = $1
+  AND created_at >= date_trunc('day', now())
+`
+
+type GetDailyTransferTotalsRow struct {
+	TotalAmount int64 `json:"total_amount"`
+	TotalCount  int64 `json:"total_count"`
+}
+
+func (q *Queries) GetDailyTransferTotals(ctx context.Context, fromAccountID int64) (GetDailyTransferTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getDailyTransferTotals, fromAccountID)
+	var i GetDailyTransferTotalsRow
+	err := row.Scan(&i.TotalAmount, &i.TotalCount)
+	return i, err
+}
+
+const getOwnerDailyTransferTotals = `-- name: GetOwnerDailyTransferTotals :one
+SELECT
+  COALESCE(SUM(transfers.amount), 0)::bigint AS total_amount,
+  COUNT(*) AS total_count
+FROM transfers
+JOIN accounts ON accounts.id = transfers.from_account_id
+WHERE accounts.owner = $1
+  AND accounts.currency = $2
+  AND transfers.created_at >= date_trunc('day', now())
+`
+
+type GetOwnerDailyTransferTotalsParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+type GetOwnerDailyTransferTotalsRow struct {
+	TotalAmount int64 `json:"total_amount"`
+	TotalCount  int64 `json:"total_count"`
+}
+
+func (q *Queries) GetOwnerDailyTransferTotals(ctx context.Context, arg GetOwnerDailyTransferTotalsParams) (GetOwnerDailyTransferTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getOwnerDailyTransferTotals, arg.Owner, arg.Currency)
+	var i GetOwnerDailyTransferTotalsRow
+	err := row.Scan(&i.TotalAmount, &i.TotalCount)
+	return i, err
+}
+
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, fee, reference, bulk_transfer_id FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, getTransfer, id)
+	row := q.db.QueryRow(ctx, getTransfer, id)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -50,12 +114,15 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Fee,
+		&i.Reference,
+		&i.BulkTransferID,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, fee, reference, bulk_transfer_id FROM transfers
 WHERE from_account_id = $1 OR to_account_id = $2
 ORDER BY id
 LIMIT $3
@@ -70,7 +137,7 @@ type ListTransfersParams struct {
 }
 
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
-	rows, err := q.db.QueryContext(ctx, listTransfers,
+	rows, err := q.db.Query(ctx, listTransfers,
 		arg.FromAccountID,
 		arg.ToAccountID,
 		arg.Limit,
@@ -89,14 +156,14 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Fee,
+			&i.Reference,
+			&i.BulkTransferID,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
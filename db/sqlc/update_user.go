@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type UpdateUserTxParams struct {
+	UpdateUserParams
+	// AfterUpdate runs inside the transaction with q bound to it.
+	AfterUpdate func(q Querier, user User) error
+	Audit       AuditContext
+}
+
+type UpdateUserTxResult struct {
+	User User `json:"user"`
+}
+
+func (s *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		before, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+
+		_, err = appendAudit(ctx, q, arg.Audit, AuditEvent{
+			Action:   AuditUserUpdated,
+			Resource: UserResource(result.User.Username),
+			Before:   newAuditUser(before),
+			After:    newAuditUser(result.User),
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.PasswordChangedAt.Valid {
+			err = auditTokensRevoked(ctx, q, arg.Audit, before, result.User)
+			if err != nil {
+				return err
+			}
+		}
+
+		if arg.AfterUpdate == nil {
+			return nil
+		}
+		return arg.AfterUpdate(q, result.User)
+	})
+
+	return result, err
+}
+
+// auditTokensRevoked records that a password change invalidated every
+// access token the user was issued before it.
+func auditTokensRevoked(ctx context.Context, q *Queries, audit AuditContext, before, after User) error {
+	type revocation struct {
+		PasswordChangedAt time.Time `json:"password_changed_at"`
+	}
+
+	_, err := appendAudit(ctx, q, audit, AuditEvent{
+		Action:   AuditTokensRevoked,
+		Resource: UserResource(after.Username),
+		Before:   revocation{PasswordChangedAt: before.PasswordChangedAt},
+		After:    revocation{PasswordChangedAt: after.PasswordChangedAt},
+	})
+	return err
+}
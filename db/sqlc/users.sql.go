@@ -1,12 +1,15 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.15.0
+//   sqlc v1.18.0
 // source: users.sql
 
 package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -14,7 +17,7 @@ INSERT INTO users (
   username, hashed_password , fullname, email
 ) VALUES (
   $1, $2, $3, $4
-) RETURNING username, hashed_password, fullname, email, password_changed_at, created_at
+) RETURNING username, hashed_password, fullname, email, password_changed_at, created_at, failed_login_attempts, locked_until, is_email_verified, totp_secret, totp_enabled, role, totp_last_step
 `
 
 type CreateUserParams struct {
@@ -25,7 +28,7 @@ type CreateUserParams struct {
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser,
+	row := q.db.QueryRow(ctx, createUser,
 		arg.Username,
 		arg.HashedPassword,
 		arg.Fullname,
@@ -39,17 +42,316 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :one
+UPDATE users
+SET totp_enabled = true,
+    totp_last_step = $2
+WHERE username = $1
+  AND totp_secret <> ''
+RETURNING username, hashed_password, fullname, email, password_changed_at, created_at, failed_login_attempts, locked_until, is_email_verified, totp_secret, totp_enabled, role, totp_last_step
+`
+
+type EnableUserTOTPParams struct {
+	Username     string `json:"username"`
+	TotpLastStep int64  `json:"totp_last_step"`
+}
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (User, error) {
+	row := q.db.QueryRow(ctx, enableUserTOTP, arg.Username, arg.TotpLastStep)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Fullname,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, fullname, email, password_changed_at, created_at FROM users
+SELECT username, hashed_password, fullname, email, password_changed_at, created_at, failed_login_attempts, locked_until, is_email_verified, totp_secret, totp_enabled, role, totp_last_step FROM users
 WHERE username = $1 LIMIT 1
 `
 
 func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUser, username)
+	row := q.db.QueryRow(ctx, getUser, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Fullname,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, fullname, email, password_changed_at, created_at, failed_login_attempts, locked_until, is_email_verified, totp_secret, totp_enabled, role, totp_last_step FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Fullname,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT username, hashed_password, fullname, email, password_changed_at, created_at, failed_login_attempts, locked_until, is_email_verified, totp_secret, totp_enabled, role, totp_last_step FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserForUpdate, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Fullname,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserPasswordChangedAt = `-- name: GetUserPasswordChangedAt :one
+SELECT password_changed_at FROM users
+WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserPasswordChangedAt(ctx context.Context, username string) (time.Time, error) {
+	row := q.db.QueryRow(ctx, getUserPasswordChangedAt, username)
+	var password_changed_at time.Time
+	err := row.Scan(&password_changed_at)
+	return password_changed_at, err
+}
+
+const lockUser = `-- name: LockUser :exec
+UPDATE users
+SET locked_until = GREATEST(locked_until, $1)
+WHERE username = $2
+`
+
+type LockUserParams struct {
+	LockedUntil time.Time `json:"locked_until"`
+	Username    string    `json:"username"`
+}
+
+func (q *Queries) LockUser(ctx context.Context, arg LockUserParams) error {
+	_, err := q.db.Exec(ctx, lockUser, arg.LockedUntil, arg.Username)
+	return err
+}
+
+const markEmailVerified = `-- name: MarkEmailVerified :one
+UPDATE users
+SET is_email_verified = true
+WHERE username = $1 AND email = $2
+RETURNING username, hashed_password, fullname, email, password_changed_at, created_at, failed_login_attempts, locked_until, is_email_verified, totp_secret, totp_enabled, role, totp_last_step
+`
+
+type MarkEmailVerifiedParams struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+func (q *Queries) MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) (User, error) {
+	row := q.db.QueryRow(ctx, markEmailVerified, arg.Username, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Fullname,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const recordFailedLogin = `-- name: RecordFailedLogin :one
+UPDATE users
+SET failed_login_attempts = failed_login_attempts + 1
+WHERE username = $1
+RETURNING failed_login_attempts
+`
+
+func (q *Queries) RecordFailedLogin(ctx context.Context, username string) (int32, error) {
+	row := q.db.QueryRow(ctx, recordFailedLogin, username)
+	var failed_login_attempts int32
+	err := row.Scan(&failed_login_attempts)
+	return failed_login_attempts, err
+}
+
+const resetFailedLogins = `-- name: ResetFailedLogins :exec
+UPDATE users
+SET failed_login_attempts = 0,
+    locked_until = '0001-01-01 00:00:00Z'
+WHERE username = $1
+`
+
+func (q *Queries) ResetFailedLogins(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, resetFailedLogins, username)
+	return err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = $2
+WHERE username = $1
+  AND totp_enabled = false
+RETURNING username, hashed_password, fullname, email, password_changed_at, created_at, failed_login_attempts, locked_until, is_email_verified, totp_secret, totp_enabled, role, totp_last_step
+`
+
+type SetUserTOTPSecretParams struct {
+	Username   string `json:"username"`
+	TotpSecret string `json:"totp_secret"`
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserTOTPSecret, arg.Username, arg.TotpSecret)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Fullname,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET
+  hashed_password = COALESCE($1, hashed_password),
+  password_changed_at = COALESCE($2, password_changed_at),
+  fullname = COALESCE($3, fullname),
+  email = COALESCE($4, email),
+  is_email_verified = is_email_verified AND COALESCE($4 = email, true)
+WHERE username = $5
+RETURNING username, hashed_password, fullname, email, password_changed_at, created_at, failed_login_attempts, locked_until, is_email_verified, totp_secret, totp_enabled, role, totp_last_step
+`
+
+type UpdateUserParams struct {
+	HashedPassword    pgtype.Text      `json:"hashed_password"`
+	PasswordChangedAt pgtype.Timestamp `json:"password_changed_at"`
+	Fullname          pgtype.Text      `json:"fullname"`
+	Email             pgtype.Text      `json:"email"`
+	Username          string           `json:"username"`
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUser,
+		arg.HashedPassword,
+		arg.PasswordChangedAt,
+		arg.Fullname,
+		arg.Email,
+		arg.Username,
+	)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.Fullname,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :one
+UPDATE users
+SET totp_last_step = $1
+WHERE username = $2
+  AND totp_last_step < $1
+RETURNING username, hashed_password, fullname, email, password_changed_at, created_at, failed_login_attempts, locked_until, is_email_verified, totp_secret, totp_enabled, role, totp_last_step
+`
+
+type UseTOTPStepParams struct {
+	Step     int64  `json:"step"`
+	Username string `json:"username"`
+}
+
+// Moves the last accepted step forward. Nothing is returned when step is not
+// past it, so each code can only be used once.
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (User, error) {
+	row := q.db.QueryRow(ctx, useTOTPStep, arg.Step, arg.Username)
 	var i User
 	err := row.Scan(
 		&i.Username,
@@ -58,6 +360,13 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.Role,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
@@ -25,6 +26,7 @@ func creatRandomUser(t *testing.T) User {
 	require.Equal(t, args.Email, user.Email)
 	require.True(t, user.PasswordChangedAt.IsZero())
 	require.NotZero(t, user.CreatedAt)
+	require.Equal(t, util.DepositorRole, user.Role)
 
 	return user
 }
@@ -46,3 +48,102 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user1.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 }
+
+func TestRecordFailedLogin(t *testing.T) {
+	user := creatRandomUser(t)
+	require.Zero(t, user.FailedLoginAttempts)
+	require.True(t, user.LockedUntil.IsZero())
+
+	for i := 1; i <= 3; i++ {
+		attempts, err := testQueries.RecordFailedLogin(context.Background(), user.Username)
+		require.NoError(t, err)
+		require.Equal(t, int32(i), attempts)
+	}
+
+	lockedUntil := time.Now().Add(time.Minute)
+	err := testQueries.LockUser(context.Background(), LockUserParams{
+		Username:    user.Username,
+		LockedUntil: lockedUntil,
+	})
+	require.NoError(t, err)
+
+	// A shorter lock never shortens an existing one.
+	err = testQueries.LockUser(context.Background(), LockUserParams{
+		Username:    user.Username,
+		LockedUntil: time.Now(),
+	})
+	require.NoError(t, err)
+
+	user, err = testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), user.FailedLoginAttempts)
+	require.WithinDuration(t, lockedUntil, user.LockedUntil, time.Second)
+
+	err = testQueries.ResetFailedLogins(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	user, err = testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, user.FailedLoginAttempts)
+	require.True(t, user.LockedUntil.IsZero())
+}
+
+func TestUpdateUserOnlyFullname(t *testing.T) {
+	oldUser := creatRandomUser(t)
+
+	newFullname := util.RandomOwner()
+	updatedUser, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Username: oldUser.Username,
+		Fullname: pgtype.Text{String: newFullname, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newFullname, updatedUser.Fullname)
+	require.Equal(t, oldUser.Email, updatedUser.Email)
+	require.Equal(t, oldUser.HashedPassword, updatedUser.HashedPassword)
+	require.Equal(t, oldUser.IsEmailVerified, updatedUser.IsEmailVerified)
+}
+
+func TestUpdateUserEmail(t *testing.T) {
+	oldUser := creatRandomUser(t)
+	_, err := testQueries.MarkEmailVerified(context.Background(), MarkEmailVerifiedParams{
+		Username: oldUser.Username,
+		Email:    oldUser.Email,
+	})
+	require.NoError(t, err)
+
+	// Setting the same address keeps it verified.
+	updatedUser, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Username: oldUser.Username,
+		Email:    pgtype.Text{String: oldUser.Email, Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, updatedUser.IsEmailVerified)
+
+	newEmail := util.RandomEmail()
+	updatedUser, err = testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Username: oldUser.Username,
+		Email:    pgtype.Text{String: newEmail, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newEmail, updatedUser.Email)
+	require.False(t, updatedUser.IsEmailVerified)
+	require.Equal(t, oldUser.Fullname, updatedUser.Fullname)
+}
+
+func TestUpdateUserPassword(t *testing.T) {
+	oldUser := creatRandomUser(t)
+
+	changedAt := time.Now().UTC()
+	updatedUser, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Username:          oldUser.Username,
+		HashedPassword:    pgtype.Text{String: "654321", Valid: true},
+		PasswordChangedAt: pgtype.Timestamp{Time: changedAt, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "654321", updatedUser.HashedPassword)
+	require.WithinDuration(t, changedAt, updatedUser.PasswordChangedAt, time.Second)
+
+	passwordChangedAt, err := testQueries.GetUserPasswordChangedAt(context.Background(), oldUser.Username)
+	require.NoError(t, err)
+	require.WithinDuration(t, changedAt, passwordChangedAt, time.Second)
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomVerifyEmail(t *testing.T, duration time.Duration) VerifyEmail {
+	user := creatRandomUser(t)
+
+	arg := CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: util.RandomString(32),
+		ExpiredAt:  time.Now().Add(duration),
+	}
+
+	verifyEmail, err := testQueries.CreateVerifyEmail(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, verifyEmail.Username)
+	require.Equal(t, arg.Email, verifyEmail.Email)
+	require.Equal(t, arg.SecretCode, verifyEmail.SecretCode)
+	require.False(t, verifyEmail.IsUsed)
+	require.WithinDuration(t, arg.ExpiredAt, verifyEmail.ExpiredAt, time.Second)
+
+	return verifyEmail
+}
+
+func TestCreateVerifyEmail(t *testing.T) {
+	createRandomVerifyEmail(t, 15*time.Minute)
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+	verifyEmail := createRandomVerifyEmail(t, 15*time.Minute)
+
+	arg := VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: "wrong",
+	}
+	_, err := store.VerifyEmailTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	arg.SecretCode = verifyEmail.SecretCode
+	result, err := store.VerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.VerifyEmail.IsUsed)
+	require.True(t, result.User.IsEmailVerified)
+
+	// A code can only be used once.
+	_, err = store.VerifyEmailTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestVerifyEmailTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	verifyEmail := createRandomVerifyEmail(t, -time.Minute)
+
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	user, err := testQueries.GetUser(context.Background(), verifyEmail.Username)
+	require.NoError(t, err)
+	require.False(t, user.IsEmailVerified)
+}
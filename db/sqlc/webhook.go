@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// WebhookEventTypes lists every event an endpoint can subscribe to.
+var WebhookEventTypes = []string{
+	EventTransferCreated,
+	EventAccountCredited,
+	EventAccountDebited,
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+var ErrWebhookDeliveryNotFailed = errors.New("only failed deliveries can be replayed")
+
+func isWebhookEventType(eventType string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type DispatchWebhookEventsTxParams struct {
+	Limit int32
+	// AfterCreate runs inside the transaction for every delivery, so that
+	// the task that sends it is queued exactly when the event is claimed.
+	AfterCreate func(q Querier, delivery WebhookDelivery) error
+}
+
+// DispatchWebhookEventsTx claims up to Limit outbox events and creates a
+// delivery for every active endpoint subscribed to each of them. Events of a
+// type endpoints cannot subscribe to are claimed without a delivery. It
+// returns how many events it claimed.
+func (s *SQLStore) DispatchWebhookEventsTx(ctx context.Context, arg DispatchWebhookEventsTxParams) (int, error) {
+	var n int
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		events, err := q.ClaimOutboxEventsForWebhooks(ctx, arg.Limit)
+		if err != nil {
+			return err
+		}
+		n = len(events)
+
+		for _, event := range events {
+			if !isWebhookEventType(event.Type) {
+				continue
+			}
+			endpoints, err := q.ListWebhookEndpointsForEvent(ctx, ListWebhookEndpointsForEventParams{
+				AccountID: event.AccountID,
+				Type:      event.Type,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, endpoint := range endpoints {
+				delivery, err := q.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams{
+					EndpointID: endpoint.ID,
+					EventID:    event.ID,
+				})
+				if err != nil {
+					return err
+				}
+				if arg.AfterCreate != nil {
+					if err := arg.AfterCreate(q, delivery); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+
+	return n, err
+}
+
+type ReplayWebhookDeliveryTxParams struct {
+	ID int64
+	// AfterReplay runs inside the transaction, like AfterCreate above.
+	AfterReplay func(q Querier, delivery WebhookDelivery) error
+}
+
+// ReplayWebhookDeliveryTx puts a failed delivery back to pending. It fails
+// with ErrWebhookDeliveryNotFailed for any other status.
+func (s *SQLStore) ReplayWebhookDeliveryTx(ctx context.Context, arg ReplayWebhookDeliveryTxParams) (WebhookDelivery, error) {
+	var result WebhookDelivery
+
+	err := s.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+		result, err = q.ResetFailedWebhookDelivery(ctx, arg.ID)
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return ErrWebhookDeliveryNotFailed
+			}
+			return err
+		}
+
+		if arg.AfterReplay == nil {
+			return nil
+		}
+		return arg.AfterReplay(q, result)
+	})
+
+	return result, err
+}
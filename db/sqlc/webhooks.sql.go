@@ -0,0 +1,329 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: webhooks.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+  endpoint_id, event_id
+) VALUES (
+  $1, $2
+) RETURNING id, endpoint_id, event_id, status, attempts, response_status, last_error, created_at, updated_at
+`
+
+type CreateWebhookDeliveryParams struct {
+	EndpointID int64 `json:"endpoint_id"`
+	EventID    int64 `json:"event_id"`
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, createWebhookDelivery, arg.EndpointID, arg.EventID)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createWebhookEndpoint = `-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+  owner, url, secret, event_types
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, owner, url, secret, event_types, is_active, created_at
+`
+
+type CreateWebhookEndpointParams struct {
+	Owner      string   `json:"owner"`
+	Url        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, createWebhookEndpoint,
+		arg.Owner,
+		arg.Url,
+		arg.Secret,
+		arg.EventTypes,
+	)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.IsActive,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deactivateWebhookEndpoint = `-- name: DeactivateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET is_active = false
+WHERE id = $1 AND owner = $2
+RETURNING id, owner, url, secret, event_types, is_active, created_at
+`
+
+type DeactivateWebhookEndpointParams struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+}
+
+func (q *Queries) DeactivateWebhookEndpoint(ctx context.Context, arg DeactivateWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, deactivateWebhookEndpoint, arg.ID, arg.Owner)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.IsActive,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, endpoint_id, event_id, status, attempts, response_status, last_error, created_at, updated_at FROM webhook_deliveries
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, getWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getWebhookEndpoint = `-- name: GetWebhookEndpoint :one
+SELECT id, owner, url, secret, event_types, is_active, created_at FROM webhook_endpoints
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, getWebhookEndpoint, id)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.IsActive,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, endpoint_id, event_id, status, attempts, response_status, last_error, created_at, updated_at FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	EndpointID int64 `json:"endpoint_id"`
+	Limit      int32 `json:"limit"`
+	Offset     int32 `json:"offset"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries, arg.EndpointID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.EndpointID,
+			&i.EventID,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookEndpoints = `-- name: ListWebhookEndpoints :many
+SELECT id, owner, url, secret, event_types, is_active, created_at FROM webhook_endpoints
+WHERE owner = $1 AND is_active
+ORDER BY id
+`
+
+func (q *Queries) ListWebhookEndpoints(ctx context.Context, owner string) ([]WebhookEndpoint, error) {
+	rows, err := q.db.Query(ctx, listWebhookEndpoints, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEndpoint{}
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Url,
+			&i.Secret,
+			&i.EventTypes,
+			&i.IsActive,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookEndpointsForEvent = `-- name: ListWebhookEndpointsForEvent :many
+SELECT e.id, e.owner, e.url, e.secret, e.event_types, e.is_active, e.created_at FROM webhook_endpoints e
+JOIN accounts a ON a.owner = e.owner
+WHERE a.id = $1
+  AND e.is_active
+  AND (cardinality(e.event_types) = 0 OR $2::varchar = ANY(e.event_types))
+ORDER BY e.id
+`
+
+type ListWebhookEndpointsForEventParams struct {
+	AccountID int64  `json:"account_id"`
+	Type      string `json:"type"`
+}
+
+func (q *Queries) ListWebhookEndpointsForEvent(ctx context.Context, arg ListWebhookEndpointsForEventParams) ([]WebhookEndpoint, error) {
+	rows, err := q.db.Query(ctx, listWebhookEndpointsForEvent, arg.AccountID, arg.Type)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEndpoint{}
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Url,
+			&i.Secret,
+			&i.EventTypes,
+			&i.IsActive,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordWebhookAttempt = `-- name: RecordWebhookAttempt :one
+UPDATE webhook_deliveries
+SET status = $1,
+    attempts = attempts + 1,
+    response_status = $2,
+    last_error = $3,
+    updated_at = now()
+WHERE id = $4
+RETURNING id, endpoint_id, event_id, status, attempts, response_status, last_error, created_at, updated_at
+`
+
+type RecordWebhookAttemptParams struct {
+	Status         string `json:"status"`
+	ResponseStatus int32  `json:"response_status"`
+	LastError      string `json:"last_error"`
+	ID             int64  `json:"id"`
+}
+
+func (q *Queries) RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, recordWebhookAttempt,
+		arg.Status,
+		arg.ResponseStatus,
+		arg.LastError,
+		arg.ID,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const resetFailedWebhookDelivery = `-- name: ResetFailedWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending',
+    updated_at = now()
+WHERE id = $1 AND status = 'failed'
+RETURNING id, endpoint_id, event_id, status, attempts, response_status, last_error, created_at, updated_at
+`
+
+func (q *Queries) ResetFailedWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, resetFailedWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
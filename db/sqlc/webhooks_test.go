@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhookEndpoint(t *testing.T, owner string, eventTypes []string) WebhookEndpoint {
+	arg := CreateWebhookEndpointParams{
+		Owner:      owner,
+		Url:        "https://example.com/hooks",
+		Secret:     "secret",
+		EventTypes: eventTypes,
+	}
+
+	endpoint, err := testQueries.CreateWebhookEndpoint(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Owner, endpoint.Owner)
+	require.Equal(t, arg.EventTypes, endpoint.EventTypes)
+	require.True(t, endpoint.IsActive)
+
+	return endpoint
+}
+
+// dispatchAll drains the outbox and returns the deliveries created for
+// endpoint.
+func dispatchAll(t *testing.T, store Store, endpoint WebhookEndpoint) []WebhookDelivery {
+	var deliveries []WebhookDelivery
+	for {
+		n, err := store.DispatchWebhookEventsTx(context.Background(), DispatchWebhookEventsTxParams{
+			Limit: 100,
+			AfterCreate: func(q Querier, delivery WebhookDelivery) error {
+				if delivery.EndpointID == endpoint.ID {
+					deliveries = append(deliveries, delivery)
+				}
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		if n == 0 {
+			return deliveries
+		}
+	}
+}
+
+func TestTransferTxWritesWebhookEvents(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := creatRandomAccount(t)
+	acc2 := creatRandomAccount(t)
+
+	all := createRandomWebhookEndpoint(t, acc1.Owner, []string{})
+	credits := createRandomWebhookEndpoint(t, acc2.Owner, []string{EventAccountCredited})
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	deliveries := dispatchAll(t, store, all)
+	require.Len(t, deliveries, 2)
+
+	var types []string
+	for _, delivery := range deliveries {
+		require.Equal(t, WebhookDeliveryPending, delivery.Status)
+
+		event, err := testQueries.GetOutboxEvent(context.Background(), delivery.EventID)
+		require.NoError(t, err)
+		require.True(t, event.WebhookDispatchedAt.Valid)
+		require.Equal(t, acc1.ID, event.AccountID)
+		types = append(types, event.Type)
+
+		if event.Type == EventAccountDebited {
+			var movement AccountMovement
+			require.NoError(t, json.Unmarshal(event.Payload, &movement))
+			require.Equal(t, result.Transfer.ID, movement.TransferID)
+			require.Equal(t, int64(-10), movement.Amount)
+			require.Equal(t, result.FromAccount.Balance, movement.Balance)
+		}
+	}
+	require.ElementsMatch(t, []string{EventTransferCreated, EventAccountDebited}, types)
+
+	// Events are claimed once, so the credit has already been dispatched.
+	require.Empty(t, dispatchAll(t, store, credits))
+	deliveries, err = testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		EndpointID: credits.ID,
+		Limit:      10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+}
+
+func TestHoldTxWritesNoWebhookEvents(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := creatRandomAccount(t)
+	acc2 := creatRandomAccount(t)
+	endpoint := createRandomWebhookEndpoint(t, acc1.Owner, []string{})
+
+	_, err := store.CreateHoldTx(context.Background(), CreateHoldTxParams{
+		AccountID:   acc1.ID,
+		ToAccountID: acc2.ID,
+		Amount:      10,
+		Duration:    time.Minute,
+	})
+	require.NoError(t, err)
+
+	// Hold events reach the outbox stream but not webhook endpoints, which
+	// cannot subscribe to them.
+	require.Empty(t, dispatchAll(t, store, endpoint))
+}
+
+func TestReplayWebhookDeliveryTx(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := creatRandomAccount(t)
+	acc2 := creatRandomAccount(t)
+	endpoint := createRandomWebhookEndpoint(t, acc1.Owner, []string{EventAccountDebited})
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	deliveries := dispatchAll(t, store, endpoint)
+	require.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+
+	_, err = store.ReplayWebhookDeliveryTx(context.Background(), ReplayWebhookDeliveryTxParams{ID: delivery.ID})
+	require.ErrorIs(t, err, ErrWebhookDeliveryNotFailed)
+
+	failed, err := testQueries.RecordWebhookAttempt(context.Background(), RecordWebhookAttemptParams{
+		ID:             delivery.ID,
+		Status:         WebhookDeliveryFailed,
+		ResponseStatus: 500,
+		LastError:      "endpoint answered 500",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failed.Attempts)
+
+	var replayed int64
+	delivery, err = store.ReplayWebhookDeliveryTx(context.Background(), ReplayWebhookDeliveryTxParams{
+		ID: delivery.ID,
+		AfterReplay: func(q Querier, delivery WebhookDelivery) error {
+			replayed = delivery.ID
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryPending, delivery.Status)
+	require.Equal(t, delivery.ID, replayed)
+}
@@ -0,0 +1,38 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	db "simplebank/db/sqlc"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const userAgentMetadataKey = "user-agent"
+
+// auditContext describes the call for the audit log. actor is empty for
+// anonymous calls.
+func auditContext(ctx context.Context, actor string) db.AuditContext {
+	audit := db.AuditContext{Actor: actor}
+	audit.RequestID, _ = ctx.Value(requestIDKey{}).(string)
+
+	audit.IP = clientIP(ctx)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(userAgentMetadataKey); len(values) > 0 {
+			audit.UserAgent = values[0]
+		}
+	}
+	return audit
+}
+
+// clientIP is the address of the peer that made the call, or an empty
+// string when it is unknown.
+func clientIP(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+	}
+	return ""
+}
@@ -0,0 +1,28 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestAuditContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "request-1")
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 51234}})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(userAgentMetadataKey, "grpc-go/1.57"))
+
+	audit := auditContext(ctx, "alice")
+	require.Equal(t, "alice", audit.Actor)
+	require.Equal(t, "203.0.113.7", audit.IP)
+	require.Equal(t, "grpc-go/1.57", audit.UserAgent)
+	require.Equal(t, "request-1", audit.RequestID)
+
+	audit = auditContext(context.Background(), "")
+	require.Empty(t, audit.Actor)
+	require.Empty(t, audit.IP)
+	require.Empty(t, audit.RequestID)
+}
@@ -0,0 +1,61 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	authorizationHeader = "authorization"
+	authorizationBearer = "bearer"
+)
+
+// authorizeUser mirrors the HTTP auth middleware: the bearer token must be
+// valid and issued after the user's last password change. The payload is
+// recorded on ctx for the call's log line.
+func (server *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, errors.New("missing metadata")
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return nil, errors.New("missing authorization header")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) < 2 {
+		return nil, errors.New("invalid authorization header format")
+	}
+
+	authType := strings.ToLower(fields[0])
+	if authType != authorizationBearer {
+		return nil, fmt.Errorf("unsupported authorization type %s", authType)
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %w", err)
+	}
+
+	passwordChangedAt, err := server.store.GetUserPasswordChangedAt(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("cannot check token: %w", err)
+	}
+	if payload.IssuedAt.Before(passwordChangedAt) {
+		return nil, errors.New("token was issued before the last password change")
+	}
+
+	setCaller(ctx, payload)
+	return payload, nil
+}
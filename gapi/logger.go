@@ -0,0 +1,137 @@
+package gapi
+
+import (
+	"context"
+	"simplebank/token"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	requestIDMetadataKey = "x-request-id"
+	maxRequestIDLength   = 128
+)
+
+type requestIDKey struct{}
+
+type callerKey struct{}
+
+// caller is filled in by authorizeUser once the call is authenticated, so
+// that the log line written after the handler returns can name the user.
+type caller struct {
+	payload *token.Payload
+}
+
+func withCaller(ctx context.Context) (context.Context, *caller) {
+	c := &caller{}
+	return context.WithValue(ctx, callerKey{}, c), c
+}
+
+// setCaller records the authenticated user of the call, if it is logged.
+func setCaller(ctx context.Context, payload *token.Payload) {
+	if c, ok := ctx.Value(callerKey{}).(*caller); ok {
+		c.payload = payload
+	}
+}
+
+// GrpcLogger writes one structured line per unary call with the request ID
+// taken from the x-request-id metadata, or generated when it is missing.
+// Request and response messages are never logged.
+func GrpcLogger(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	id := incomingRequestID(ctx)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, id))
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	ctx, c := withCaller(ctx)
+
+	start := time.Now()
+	result, err := handler(ctx, req)
+	logCall(ctx, c, id, info.FullMethod, err, time.Since(start))
+
+	return result, err
+}
+
+// GrpcStreamLogger is GrpcLogger for streaming calls. The line is written
+// when the stream ends.
+func GrpcStreamLogger(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx := stream.Context()
+	id := incomingRequestID(ctx)
+	_ = stream.SetHeader(metadata.Pairs(requestIDMetadataKey, id))
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	ctx, c := withCaller(ctx)
+
+	start := time.Now()
+	err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	logCall(ctx, c, id, info.FullMethod, err, time.Since(start))
+
+	return err
+}
+
+// contextStream replaces the context of a stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func logCall(ctx context.Context, c *caller, id, method string, err error, duration time.Duration) {
+	statusCode := codes.Unknown
+	if st, ok := status.FromError(err); ok {
+		statusCode = st.Code()
+	}
+
+	var event *zerolog.Event
+	switch statusCode {
+	case codes.OK:
+		event = log.Info()
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		event = log.Error().Err(err)
+	default:
+		event = log.Warn().Err(err)
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		event = event.Str("trace_id", sc.TraceID().String())
+	}
+	if c.payload != nil {
+		event = event.Str("username", c.payload.Username)
+	}
+
+	event.
+		Str("protocol", "grpc").
+		Str("request_id", id).
+		Str("method", method).
+		Int("status_code", int(statusCode)).
+		Str("status_text", statusCode.String()).
+		Dur("duration", duration).
+		Msg("received a gRPC request")
+}
+
+func incomingRequestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestIDMetadataKey); len(ids) > 0 && ids[0] != "" && len(ids[0]) <= maxRequestIDLength {
+			return ids[0]
+		}
+	}
+	return uuid.NewString()
+}
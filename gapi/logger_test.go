@@ -0,0 +1,78 @@
+package gapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	mockdb "simplebank/db/mock"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestGrpcLoggerUsername(t *testing.T) {
+	username := util.RandomOwner()
+
+	testCases := []struct {
+		name      string
+		authorize bool
+		check     func(t *testing.T, line map[string]interface{})
+	}{
+		{
+			name:      "Authorized",
+			authorize: true,
+			check: func(t *testing.T, line map[string]interface{}) {
+				require.Equal(t, username, line["username"])
+			},
+		},
+		{
+			name: "Anonymous",
+			check: func(t *testing.T, line map[string]interface{}) {
+				require.NotContains(t, line, "username")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(username)).
+				AnyTimes().
+				Return(time.Time{}, nil)
+			server := newTestServer(t, store)
+
+			var buf bytes.Buffer
+			logger := log.Logger
+			log.Logger = zerolog.New(&buf)
+			defer func() { log.Logger = logger }()
+
+			ctx := newContextWithBearerToken(t, server.tokenMaker, username, time.Minute)
+			info := &grpc.UnaryServerInfo{FullMethod: "/pb.SimpleBank/Test"}
+			_, err := GrpcLogger(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				if tc.authorize {
+					_, err := server.authorizeUser(ctx)
+					return nil, err
+				}
+				return nil, nil
+			})
+			require.NoError(t, err)
+
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+			require.Equal(t, info.FullMethod, line["method"])
+			tc.check(t, line)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package gapi
+
+import (
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"simplebank/watch"
+	"simplebank/worker"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		TokenKey:      util.RandomString(32),
+		TokenDuration: time.Minute,
+		StepUpMaxAge:  5 * time.Minute,
+	}
+
+	server, err := NewServer(config, store, worker.NewTaskDistributor(store), watch.NewHub())
+	require.NoError(t, err)
+
+	return server
+}
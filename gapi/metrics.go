@@ -0,0 +1,44 @@
+package gapi
+
+import (
+	"context"
+	"simplebank/metrics"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+func GrpcMetrics(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	start := time.Now()
+	result, err := handler(ctx, req)
+	observeCall(info.FullMethod, err, time.Since(start))
+
+	return result, err
+}
+
+// GrpcStreamMetrics counts a streaming call when it ends. Its duration is
+// how long the stream stayed open.
+func GrpcStreamMetrics(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	start := time.Now()
+	err := handler(srv, stream)
+	observeCall(info.FullMethod, err, time.Since(start))
+
+	return err
+}
+
+func observeCall(method string, err error, duration time.Duration) {
+	code := status.Code(err)
+	metrics.GRPCRequests.WithLabelValues(method, code.String()).Inc()
+	metrics.GRPCDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
@@ -0,0 +1,145 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/util"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxBulkTransferItems = 500
+	maxReferenceLength   = 140
+)
+
+func (server *Server) BulkTransfer(ctx context.Context, req *pb.BulkTransferRequest) (*pb.BulkTransferResponse, error) {
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "unauthorized: %v", err)
+	}
+
+	total, err := validateBulkTransferRequest(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	fromAccount, err := server.store.GetAccount(ctx, req.GetFromAccountId())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "cannot get account: %v", err)
+	}
+	if fromAccount.Owner != payload.Username {
+		return nil, status.Error(codes.PermissionDenied, "from account doesn't belong to the authenticated user")
+	}
+	if fromAccount.Currency != req.GetCurrency() {
+		return nil, status.Errorf(codes.InvalidArgument, "account currency mismatch: %v vs %v", fromAccount.Currency, req.GetCurrency())
+	}
+
+	if err := server.checkStepUp(ctx, payload, req.GetCurrency(), total); err != nil {
+		return nil, err
+	}
+
+	arg := db.BulkTransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		Mode:          req.GetMode(),
+		Items:         make([]db.BulkTransferItem, len(req.GetItems())),
+		Audit:         auditContext(ctx, payload.Username),
+	}
+	for i, item := range req.GetItems() {
+		quote, err := server.store.QuoteFee(ctx, req.GetCurrency(), item.GetAmount())
+		if err != nil {
+			if errors.Is(err, db.ErrFeeAccountCurrencyMismatch) {
+				log.Error().Err(err).Str("currency", req.GetCurrency()).Msg("fee rule is misconfigured")
+			}
+			return nil, status.Errorf(codes.Internal, "cannot quote fee: %v", err)
+		}
+		arg.Items[i] = db.BulkTransferItem{
+			ToAccountID: item.GetToAccountId(),
+			Amount:      item.GetAmount(),
+			Reference:   item.GetReference(),
+			Quote:       quote,
+		}
+	}
+
+	result, err := server.store.BulkTransferTx(ctx, arg)
+	if err != nil {
+		var itemErr *db.BulkTransferItemError
+		if errors.As(err, &itemErr) {
+			return nil, status.Error(bulkItemErrorCode(itemErr.Err), itemErr.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "cannot run bulk transfer: %v", err)
+	}
+
+	rsp := &pb.BulkTransferResponse{
+		BulkTransferId: result.BulkTransfer.ID,
+		Succeeded:      result.BulkTransfer.Succeeded,
+		FromAccount:    convertAccount(result.FromAccount),
+		Items:          make([]*pb.BulkTransferItemResult, len(result.Items)),
+	}
+	for i, item := range result.Items {
+		rsp.Items[i] = &pb.BulkTransferItemResult{
+			Index:  int32(item.Index),
+			Status: item.Status,
+			Error:  item.Error,
+		}
+		if item.Transfer != nil {
+			rsp.Items[i].Transfer = convertTransfer(*item.Transfer)
+		}
+	}
+	return rsp, nil
+}
+
+// validateBulkTransferRequest returns the total amount of the items.
+func validateBulkTransferRequest(req *pb.BulkTransferRequest) (int64, error) {
+	if req.GetFromAccountId() <= 0 {
+		return 0, errors.New("from_account_id must be positive")
+	}
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		return 0, errors.New("currency is not supported")
+	}
+	if req.GetMode() != db.BulkModeAtomic && req.GetMode() != db.BulkModePerItem {
+		return 0, fmt.Errorf("mode must be %s or %s", db.BulkModeAtomic, db.BulkModePerItem)
+	}
+	if len(req.GetItems()) == 0 || len(req.GetItems()) > maxBulkTransferItems {
+		return 0, fmt.Errorf("items must hold between 1 and %d transfers", maxBulkTransferItems)
+	}
+
+	var total int64
+	for i, item := range req.GetItems() {
+		if item.GetToAccountId() <= 0 {
+			return 0, fmt.Errorf("item %d: to_account_id must be positive", i)
+		}
+		if item.GetAmount() <= 0 {
+			return 0, fmt.Errorf("item %d: amount must be positive", i)
+		}
+		if len(item.GetReference()) > maxReferenceLength {
+			return 0, fmt.Errorf("item %d: reference must be at most %d characters", i, maxReferenceLength)
+		}
+		total += item.GetAmount()
+		if total < 0 {
+			return 0, errors.New("total amount of the items is too large")
+		}
+	}
+	return total, nil
+}
+
+func bulkItemErrorCode(err error) codes.Code {
+	var limitErr *db.TransferLimitError
+	switch {
+	case errors.As(err, &limitErr), errors.Is(err, db.ErrInsufficientFunds):
+		return codes.PermissionDenied
+	case errors.Is(err, db.ErrRecordNotFound):
+		return codes.NotFound
+	case errors.Is(err, db.ErrCurrencyMismatch), errors.Is(err, db.ErrSameAccount):
+		return codes.InvalidArgument
+	}
+	return codes.Internal
+}
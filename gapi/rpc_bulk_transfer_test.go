@@ -0,0 +1,259 @@
+package gapi
+
+import (
+	"context"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBulkTransfer(t *testing.T) {
+	owner := util.RandomOwner()
+	account := randomAccount(owner)
+	account.Currency = util.USD
+	transfer := db.Transfer{ID: 7, FromAccountID: account.ID, ToAccountID: account.ID + 1, Amount: 100, Reference: "salary"}
+
+	validReq := func(mode string) *pb.BulkTransferRequest {
+		return &pb.BulkTransferRequest{
+			FromAccountId: account.ID,
+			Currency:      util.USD,
+			Mode:          mode,
+			Items: []*pb.BulkTransferItem{
+				{ToAccountId: account.ID + 1, Amount: 100, Reference: "salary"},
+				{ToAccountId: account.ID + 2, Amount: 200, Reference: "salary"},
+			},
+		}
+	}
+
+	allowUser := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(owner)).
+			AnyTimes().
+			Return(time.Time{}, nil)
+		store.EXPECT().
+			GetStepUpRule(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(db.StepUpRule{}, db.ErrRecordNotFound)
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.BulkTransferRequest
+		buildContext  func(t *testing.T, tokenMaker token.TokenMaker) context.Context
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.BulkTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  validReq(db.BulkModePerItem),
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				allowUser(store)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Eq(util.USD), gomock.Any()).
+					Times(2).
+					Return(db.FeeQuote{}, nil)
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.BulkTransferTxParams) (db.BulkTransferTxResult, error) {
+						require.Equal(t, db.BulkModePerItem, arg.Mode)
+						require.Len(t, arg.Items, 2)
+						require.Equal(t, "salary", arg.Items[1].Reference)
+						require.Equal(t, owner, arg.Audit.Actor)
+						return db.BulkTransferTxResult{
+							BulkTransfer: db.BulkTransfer{ID: 3, Succeeded: 1},
+							FromAccount:  account,
+							Items: []db.BulkTransferItemResult{
+								{Index: 0, Status: db.BulkItemSucceeded, Transfer: &transfer},
+								{Index: 1, Status: db.BulkItemFailed, Error: db.ErrCurrencyMismatch.Error()},
+							},
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.BulkTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(3), res.GetBulkTransferId())
+				require.Equal(t, int32(1), res.GetSucceeded())
+				require.Len(t, res.GetItems(), 2)
+				require.Equal(t, transfer.ID, res.GetItems()[0].GetTransfer().GetId())
+				require.Equal(t, "salary", res.GetItems()[0].GetTransfer().GetReference())
+				require.Nil(t, res.GetItems()[1].GetTransfer())
+				require.Equal(t, db.ErrCurrencyMismatch.Error(), res.GetItems()[1].GetError())
+			},
+		},
+		{
+			name: "AtomicItemFailed",
+			req:  validReq(db.BulkModeAtomic),
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				allowUser(store)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					QuoteFee(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.FeeQuote{}, nil)
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BulkTransferTxResult{}, &db.BulkTransferItemError{Index: 1, Err: &db.TransferLimitError{Limit: db.LimitMaxAmount}})
+			},
+			checkResponse: func(t *testing.T, res *pb.BulkTransferResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+				require.Contains(t, st.Message(), "item 1")
+			},
+		},
+		{
+			name: "InvalidMode",
+			req:  validReq("some"),
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				allowUser(store)
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.BulkTransferResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "InvalidItem",
+			req: &pb.BulkTransferRequest{
+				FromAccountId: account.ID,
+				Currency:      util.USD,
+				Mode:          db.BulkModeAtomic,
+				Items:         []*pb.BulkTransferItem{{ToAccountId: account.ID + 1, Amount: 0}},
+			},
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				allowUser(store)
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.BulkTransferResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+				require.Contains(t, st.Message(), "item 0")
+			},
+		},
+		{
+			name: "NotOwner",
+			req:  validReq(db.BulkModeAtomic),
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				allowUser(store)
+				other := account
+				other.Owner = util.RandomOwner()
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(other, nil)
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.BulkTransferResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "StepUpRequired",
+			req:  validReq(db.BulkModeAtomic),
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(owner)).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					GetStepUpRule(gomock.Any(), gomock.Eq(util.USD)).
+					Times(1).
+					Return(db.StepUpRule{Currency: util.USD, Threshold: 250}, nil)
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.BulkTransferResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  validReq(db.BulkModeAtomic),
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BulkTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.BulkTransferResponse, err error) {
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.BulkTransfer(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"simplebank/util"
+	"simplebank/worker"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ForgotPassword(ctx context.Context, req *pb.ForgotPasswordRequest) (*pb.ForgotPasswordResponse, error) {
+	if _, err := mail.ParseAddress(req.GetEmail()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "email is invalid")
+	}
+
+	// Every request mails someone, so limit both who asks and whose inbox
+	// it lands in.
+	if err := allowResetRequest("ip:"+clientIP(ctx), server.resetIPLimiter); err != nil {
+		return nil, err
+	}
+	if err := allowResetRequest("email:"+strings.ToLower(req.GetEmail()), server.resetEmailLimiter); err != nil {
+		return nil, err
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.GetEmail())
+	if err != nil {
+		// Answer the same for unknown addresses so that the RPC does not
+		// reveal who has an account.
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return &pb.ForgotPasswordResponse{}, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+
+	payload := &worker.PayloadSendPasswordReset{Username: user.Username}
+	err = server.distributor.DistributeTaskSendPasswordReset(
+		ctx, nil, payload,
+		worker.Queue(worker.QueueCritical),
+		worker.MaxAttempts(10),
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to send reset email: %v", err)
+	}
+
+	return &pb.ForgotPasswordResponse{}, nil
+}
+
+func allowResetRequest(key string, limiter ratelimit.Limiter) error {
+	if ok, retryAfter := limiter.Allow(key); !ok {
+		return status.Errorf(codes.ResourceExhausted, "too many password resets requested, retry after %s", retryAfter.Round(time.Second))
+	}
+	return nil
+}
+
+func (server *Server) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+	if err := server.passwordPolicy.Validate(req.GetPassword()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	hashedPassword, err := server.passwordHasher.Hash(req.GetPassword())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to hash password: %v", err)
+	}
+
+	result, err := server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:      util.HashToken(req.GetToken()),
+		HashedPassword: hashedPassword,
+		Audit:          auditContext(ctx, ""),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired reset token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to reset password: %v", err)
+	}
+
+	return &pb.ResetPasswordResponse{User: convertUser(result.User)}, nil
+}
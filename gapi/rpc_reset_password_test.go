@@ -0,0 +1,214 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"simplebank/util"
+	"simplebank/worker"
+	mockwk "simplebank/worker/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestForgotPasswordAPI(t *testing.T) {
+	user := db.User{Username: util.RandomOwner(), Email: util.RandomEmail()}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ForgotPasswordRequest
+		buildStubs    func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor)
+		checkResponse func(t *testing.T, res *pb.ForgotPasswordResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.ForgotPasswordRequest{Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+				payload := &worker.PayloadSendPasswordReset{Username: user.Username}
+				distributor.EXPECT().
+					DistributeTaskSendPasswordReset(gomock.Any(), gomock.Nil(), gomock.Eq(payload), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.ForgotPasswordResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			req:  &pb.ForgotPasswordRequest{Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+				distributor.EXPECT().
+					DistributeTaskSendPasswordReset(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.ForgotPasswordResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			req:  &pb.ForgotPasswordRequest{Email: "invalid-email"},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.ForgotPasswordResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
+			tc.buildStubs(store, distributor)
+
+			server := newTestServer(t, store)
+			server.distributor = distributor
+			res, err := server.ForgotPassword(context.Background(), tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestForgotPasswordRateLimit(t *testing.T) {
+	user := db.User{Username: util.RandomOwner(), Email: util.RandomEmail()}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	distributor := mockwk.NewMockTaskDistributor(ctrl)
+	store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(2).Return(user, nil)
+	distributor.EXPECT().
+		DistributeTaskSendPasswordReset(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(nil)
+
+	server := newTestServer(t, store)
+	server.distributor = distributor
+	server.resetIPLimiter = ratelimit.NewTokenBucket(3, time.Hour)
+	server.resetEmailLimiter = ratelimit.NewTokenBucket(1, time.Hour)
+
+	_, err := server.ForgotPassword(context.Background(), &pb.ForgotPasswordRequest{Email: user.Email})
+	require.NoError(t, err)
+
+	_, err = server.ForgotPassword(context.Background(), &pb.ForgotPasswordRequest{Email: user.Email})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = server.ForgotPassword(context.Background(), &pb.ForgotPasswordRequest{Email: util.RandomEmail()})
+	require.NoError(t, err)
+
+	_, err = server.ForgotPassword(context.Background(), &pb.ForgotPasswordRequest{Email: util.RandomEmail()})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestResetPasswordAPI(t *testing.T) {
+	user := db.User{Username: util.RandomOwner(), Email: util.RandomEmail()}
+	token := util.RandomString(32)
+	newPassword := util.RandomString(8)
+
+	testCases := []struct {
+		name          string
+		req           *pb.ResetPasswordRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.ResetPasswordResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.ResetPasswordRequest{Token: token, Password: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+						require.Equal(t, util.HashToken(token), arg.TokenHash)
+						require.NoError(t, util.CompareHashAndPassword(arg.HashedPassword, newPassword))
+						return db.ResetPasswordTxResult{User: user}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.ResetPasswordResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, res.GetUser().GetUsername())
+			},
+		},
+		{
+			name: "InvalidToken",
+			req:  &pb.ResetPasswordRequest{Token: "wrong", Password: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResetPasswordResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "PasswordTooShort",
+			req:  &pb.ResetPasswordRequest{Token: token, Password: "123"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResetPasswordResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.ResetPasswordRequest{Token: token, Password: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResetPasswordResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			res, err := server.ResetPassword(context.Background(), tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
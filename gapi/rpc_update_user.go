@@ -0,0 +1,186 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	db "simplebank/db/sqlc"
+	"simplebank/metrics"
+	"simplebank/pb"
+	"simplebank/token"
+	"simplebank/util"
+	"simplebank/worker"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "unauthorized: %v", err)
+	}
+
+	if err := validateUpdateUserRequest(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.Password != nil {
+		if err := server.passwordPolicy.Validate(req.GetPassword()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	if payload.Username != req.GetUsername() {
+		return nil, status.Error(codes.PermissionDenied, "cannot update another user's profile")
+	}
+	// A new email address can reset the password, so it needs the same
+	// proof as changing the password directly.
+	if req.Password != nil || req.Email != nil {
+		if err := server.checkCurrentPassword(ctx, payload, req.GetCurrentPassword()); err != nil {
+			return nil, err
+		}
+	}
+
+	arg := db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{Username: req.GetUsername()},
+		Audit:            auditContext(ctx, payload.Username),
+		AfterUpdate: func(q db.Querier, user db.User) error {
+			// Only a new address loses its verified flag.
+			if req.Email == nil || user.IsEmailVerified {
+				return nil
+			}
+			payload := &worker.PayloadSendVerifyEmail{Username: user.Username}
+			return server.distributor.DistributeTaskSendVerifyEmail(
+				ctx, q, payload,
+				worker.Queue(worker.QueueCritical),
+				worker.MaxAttempts(10),
+			)
+		},
+	}
+
+	if req.FullName != nil {
+		arg.Fullname = pgtype.Text{String: req.GetFullName(), Valid: true}
+	}
+	if req.Email != nil {
+		arg.Email = pgtype.Text{String: req.GetEmail(), Valid: true}
+	}
+	if req.Password != nil {
+		hashedPassword, err := server.passwordHasher.Hash(req.GetPassword())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to hash password: %v", err)
+		}
+		arg.HashedPassword = pgtype.Text{String: hashedPassword, Valid: true}
+		arg.PasswordChangedAt = pgtype.Timestamp{Time: time.Now().UTC(), Valid: true}
+	}
+
+	result, err := server.store.UpdateUserTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		if db.ErrorCode(err) == db.UniqueViolation {
+			return nil, status.Error(codes.AlreadyExists, "email is already in use")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update user: %v", err)
+	}
+
+	res := &pb.UpdateUserResponse{User: convertUser(result.User)}
+	// The password change revoked every token issued before it, the
+	// caller's included.
+	if arg.PasswordChangedAt.Valid {
+		res.AccessToken, err = server.tokenMaker.CreateToken(result.User.Username, server.config.TokenDuration)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to create access token: %v", err)
+		}
+	}
+	return res, nil
+}
+
+// checkCurrentPassword mirrors the HTTP check: a password or email change
+// needs a fresh step-up proof or the current password. Wrong passwords are
+// rate limited and count towards the login lockout like a failed step-up.
+func (server *Server) checkCurrentPassword(ctx context.Context, payload *token.Payload, currentPassword string) error {
+	if payload.SteppedUpWithin(server.config.StepUpMaxAge) {
+		return nil
+	}
+	if currentPassword == "" {
+		return status.Error(codes.PermissionDenied, "changing the password or email needs current_password or a recent step-up")
+	}
+	if ok, retryAfter := server.loginUsernameLimiter.Allow("user:" + payload.Username); !ok {
+		metrics.Logins.WithLabelValues(metrics.LoginRateLimited).Inc()
+		return status.Errorf(codes.ResourceExhausted, "too many login attempts, retry after %s", retryAfter.Round(time.Second))
+	}
+
+	user, err := server.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return status.Error(codes.NotFound, "user not found")
+		}
+		return status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+	if user.LockedUntil.After(time.Now()) {
+		return status.Error(codes.Unauthenticated, "current password is incorrect")
+	}
+	if util.CompareHashAndPassword(user.HashedPassword, currentPassword) != nil {
+		if err := server.recordFailedLogin(ctx, user.Username, db.AuditStepUpFailed); err != nil {
+			return status.Errorf(codes.Internal, "failed to record failed login: %v", err)
+		}
+		return status.Error(codes.Unauthenticated, "current password is incorrect")
+	}
+	return nil
+}
+
+// recordFailedLogin counts a wrong password, locks the user once the
+// failures reach the threshold and audits the attempt.
+func (server *Server) recordFailedLogin(ctx context.Context, username, action string) error {
+	failures, err := server.store.RecordFailedLogin(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	lockout := util.LockoutDuration(
+		failures,
+		server.config.LoginLockoutThreshold,
+		server.config.LoginLockoutDuration,
+		server.config.LoginLockoutMaxDuration,
+	)
+
+	var lockedUntil time.Time
+	if lockout > 0 {
+		lockedUntil = time.Now().Add(lockout)
+		err = server.store.LockUser(ctx, db.LockUserParams{
+			Username:    username,
+			LockedUntil: lockedUntil,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = server.store.AppendAuditTx(ctx, auditContext(ctx, username), db.AuditEvent{
+		Action:   action,
+		Resource: db.UserResource(username),
+		After: map[string]interface{}{
+			"failed_login_attempts": failures,
+			"locked_until":          lockedUntil,
+		},
+	})
+	return err
+}
+
+func validateUpdateUserRequest(req *pb.UpdateUserRequest) error {
+	if req.GetUsername() == "" {
+		return errors.New("username is required")
+	}
+	if req.FullName != nil && req.GetFullName() == "" {
+		return errors.New("full_name must not be empty")
+	}
+	if req.Email != nil {
+		if _, err := mail.ParseAddress(req.GetEmail()); err != nil {
+			return errors.New("email is invalid")
+		}
+	}
+	return nil
+}
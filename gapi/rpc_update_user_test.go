@@ -0,0 +1,435 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newContextWithBearerToken(t *testing.T, tokenMaker token.TokenMaker, username string, duration time.Duration) context.Context {
+	accessToken, err := tokenMaker.CreateToken(username, duration)
+	require.NoError(t, err)
+
+	md := metadata.MD{
+		authorizationHeader: []string{fmt.Sprintf("%s %s", authorizationBearer, accessToken)},
+	}
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestUpdateUserAPI(t *testing.T) {
+	password := util.RandomString(8)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user := db.User{
+		Username:        util.RandomOwner(),
+		Fullname:        util.RandomOwner(),
+		Email:           util.RandomEmail(),
+		HashedPassword:  hashedPassword,
+		IsEmailVerified: true,
+	}
+	newFullname := util.RandomOwner()
+	newPassword := util.RandomString(8)
+	wrongPassword := "incorrect"
+
+	testCases := []struct {
+		name          string
+		req           *pb.UpdateUserRequest
+		buildContext  func(t *testing.T, tokenMaker token.TokenMaker) context.Context
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.UpdateUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.UpdateUserRequest{
+				Username:        user.Username,
+				FullName:        &newFullname,
+				Password:        &newPassword,
+				CurrentPassword: &password,
+			},
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				updated := user
+				updated.Fullname = newFullname
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, newFullname, arg.Fullname.String)
+						require.False(t, arg.Email.Valid)
+						require.NoError(t, util.CompareHashAndPassword(arg.HashedPassword.String, newPassword))
+						require.True(t, arg.PasswordChangedAt.Valid)
+						return db.UpdateUserTxResult{User: updated}, arg.AfterUpdate(store, updated)
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, res.GetUser().GetUsername())
+				require.Equal(t, newFullname, res.GetUser().GetFullName())
+				require.NotEmpty(t, res.GetAccessToken())
+			},
+		},
+		{
+			name: "PasswordChangedSteppedUp",
+			req:  &pb.UpdateUserRequest{Username: user.Username, Password: &newPassword},
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				stepUpToken, err := tokenMaker.CreateStepUpToken(user.Username, time.Minute)
+				require.NoError(t, err)
+
+				md := metadata.MD{
+					authorizationHeader: []string{fmt.Sprintf("%s %s", authorizationBearer, stepUpToken)},
+				}
+				return metadata.NewIncomingContext(context.Background(), md)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.True(t, arg.PasswordChangedAt.Valid)
+						return db.UpdateUserTxResult{User: user}, arg.AfterUpdate(store, user)
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.GetAccessToken())
+			},
+		},
+		{
+			name: "PasswordChangeWithoutCurrentPassword",
+			req:  &pb.UpdateUserRequest{Username: user.Username, Password: &newPassword},
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "PasswordChangeWrongCurrentPassword",
+			req: &pb.UpdateUserRequest{
+				Username:        user.Username,
+				Password:        &newPassword,
+				CurrentPassword: &wrongPassword,
+			},
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RecordFailedLogin(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(int32(1), nil)
+				store.EXPECT().
+					AppendAuditTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, audit db.AuditContext, event db.AuditEvent) (db.AuditLog, error) {
+						require.Equal(t, user.Username, audit.Actor)
+						require.Equal(t, db.AuditStepUpFailed, event.Action)
+						return db.AuditLog{}, nil
+					})
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "EmailChangeWithoutCurrentPassword",
+			req: func() *pb.UpdateUserRequest {
+				email := util.RandomEmail()
+				return &pb.UpdateUserRequest{Username: user.Username, Email: &email}
+			}(),
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "EmailChangeWithCurrentPassword",
+			req: func() *pb.UpdateUserRequest {
+				email := util.RandomEmail()
+				return &pb.UpdateUserRequest{Username: user.Username, Email: &email, CurrentPassword: &password}
+			}(),
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.True(t, arg.Email.Valid)
+						require.False(t, arg.PasswordChangedAt.Valid)
+						return db.UpdateUserTxResult{User: user}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.GetAccessToken())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.UpdateUserRequest{Username: user.Username, FullName: &newFullname},
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "TokenBeforePasswordChange",
+			req:  &pb.UpdateUserRequest{Username: user.Username, FullName: &newFullname},
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(time.Now().Add(time.Second), nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "OtherUser",
+			req:  &pb.UpdateUserRequest{Username: "someoneelse", FullName: &newFullname},
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidEmail",
+			req: func() *pb.UpdateUserRequest {
+				email := "invalid-email"
+				return &pb.UpdateUserRequest{Username: user.Username, Email: &email}
+			}(),
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "CommonPassword",
+			req: func() *pb.UpdateUserRequest {
+				password := "letmein123"
+				return &pb.UpdateUserRequest{Username: user.Username, Password: &password}
+			}(),
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+				require.Contains(t, status.Convert(err).Message(), "too common")
+			},
+		},
+		{
+			name: "UserNotFound",
+			req:  &pb.UpdateUserRequest{Username: user.Username, FullName: &newFullname},
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.UpdateUser(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestUpdateUserCurrentPasswordGuessing(t *testing.T) {
+	password := util.RandomString(8)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user := db.User{Username: util.RandomOwner(), HashedPassword: hashedPassword}
+	newPassword := util.RandomString(8)
+	wrongPassword := "incorrect"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(time.Time{}, nil)
+	gomock.InOrder(
+		store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil),
+		store.EXPECT().RecordFailedLogin(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(int32(1), nil),
+		store.EXPECT().AppendAuditTx(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(db.AuditLog{}, nil),
+		store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil),
+		store.EXPECT().RecordFailedLogin(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(int32(2), nil),
+		// The second failure reaches the lockout threshold.
+		store.EXPECT().
+			LockUser(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(ctx context.Context, arg db.LockUserParams) error {
+				require.Equal(t, user.Username, arg.Username)
+				require.WithinDuration(t, time.Now().Add(time.Minute), arg.LockedUntil, time.Second)
+				return nil
+			}),
+		store.EXPECT().AppendAuditTx(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(db.AuditLog{}, nil),
+	)
+	store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	server.config.LoginLockoutThreshold = 2
+	server.config.LoginLockoutDuration = time.Minute
+	server.loginUsernameLimiter = ratelimit.NewTokenBucket(2, time.Hour)
+
+	ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, time.Minute)
+	req := &pb.UpdateUserRequest{
+		Username:        user.Username,
+		Password:        &newPassword,
+		CurrentPassword: &wrongPassword,
+	}
+	for i := 0; i < 2; i++ {
+		_, err = server.UpdateUser(ctx, req)
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	// Even the right password is refused once the guesses run out.
+	req.CurrentPassword = &password
+	_, err = server.UpdateUser(ctx, req)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
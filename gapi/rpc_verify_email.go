@@ -0,0 +1,66 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/worker"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	if req.GetEmailId() <= 0 || req.GetSecretCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email_id and secret_code are required")
+	}
+
+	result, err := server.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		EmailID:    req.GetEmailId(),
+		SecretCode: req.GetSecretCode(),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired verification code")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to verify email: %v", err)
+	}
+
+	return &pb.VerifyEmailResponse{IsVerified: result.User.IsEmailVerified}, nil
+}
+
+func (server *Server) ResendVerifyEmail(ctx context.Context, req *pb.ResendVerifyEmailRequest) (*pb.ResendVerifyEmailResponse, error) {
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "unauthorized: %v", err)
+	}
+
+	if ok, retryAfter := server.verifyEmailLimiter.Allow("user:" + payload.Username); !ok {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many verification emails requested, retry after %s", retryAfter.Round(time.Second))
+	}
+
+	user, err := server.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
+	}
+
+	if user.IsEmailVerified {
+		return nil, status.Error(codes.FailedPrecondition, "email address is already verified")
+	}
+
+	err = server.distributor.DistributeTaskSendVerifyEmail(
+		ctx, nil, &worker.PayloadSendVerifyEmail{Username: user.Username},
+		worker.Queue(worker.QueueCritical),
+		worker.MaxAttempts(10),
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to send verify email: %v", err)
+	}
+
+	return &pb.ResendVerifyEmailResponse{}, nil
+}
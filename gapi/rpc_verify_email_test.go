@@ -0,0 +1,256 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"simplebank/token"
+	"simplebank/util"
+	"simplebank/worker"
+	mockwk "simplebank/worker/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestVerifyEmailAPI(t *testing.T) {
+	secretCode := util.RandomString(32)
+
+	testCases := []struct {
+		name          string
+		req           *pb.VerifyEmailRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.VerifyEmailResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.VerifyEmailRequest{EmailId: 1, SecretCode: secretCode},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.VerifyEmailTxParams{EmailID: 1, SecretCode: secretCode}
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.VerifyEmailTxResult{User: db.User{IsEmailVerified: true}}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.VerifyEmailResponse, err error) {
+				require.NoError(t, err)
+				require.True(t, res.GetIsVerified())
+			},
+		},
+		{
+			name: "InvalidCode",
+			req:  &pb.VerifyEmailRequest{EmailId: 1, SecretCode: "wrong"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, res *pb.VerifyEmailResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "MissingCode",
+			req:  &pb.VerifyEmailRequest{EmailId: 1},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.VerifyEmailResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.VerifyEmailRequest{EmailId: 1, SecretCode: secretCode},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, res *pb.VerifyEmailResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			res, err := server.VerifyEmail(context.Background(), tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestResendVerifyEmailAPI(t *testing.T) {
+	user := db.User{
+		Username: util.RandomOwner(),
+		Fullname: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+	}
+
+	testCases := []struct {
+		name          string
+		buildContext  func(t *testing.T, tokenMaker token.TokenMaker) context.Context
+		buildStubs    func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor)
+		checkResponse func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				payload := &worker.PayloadSendVerifyEmail{Username: user.Username}
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Nil(), gomock.Eq(payload), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(time.Time{}, nil)
+				verified := user
+				verified.IsEmailVerified = true
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(verified, nil)
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "NoAuthorization",
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "DistributeError",
+			buildContext: func(t *testing.T, tokenMaker token.TokenMaker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(time.Time{}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
+			tc.buildStubs(store, distributor)
+
+			server := newTestServer(t, store)
+			server.distributor = distributor
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.ResendVerifyEmail(ctx, &pb.ResendVerifyEmailRequest{})
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestResendVerifyEmailRateLimit(t *testing.T) {
+	user := db.User{Username: util.RandomOwner(), Email: util.RandomEmail()}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	distributor := mockwk.NewMockTaskDistributor(ctrl)
+	store.EXPECT().
+		GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(time.Time{}, nil)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(user, nil)
+	distributor.EXPECT().
+		DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil)
+
+	server := newTestServer(t, store)
+	server.distributor = distributor
+	server.verifyEmailLimiter = ratelimit.NewTokenBucket(1, time.Hour)
+
+	ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, time.Minute)
+	_, err := server.ResendVerifyEmail(ctx, &pb.ResendVerifyEmailRequest{})
+	require.NoError(t, err)
+
+	_, err = server.ResendVerifyEmail(ctx, &pb.ResendVerifyEmailRequest{})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
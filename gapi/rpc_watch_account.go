@@ -0,0 +1,61 @@
+package gapi
+
+import (
+	"errors"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WatchAccount streams the account's balances and new entries until the
+// client goes away. It subscribes before reading the account, so a transfer
+// that commits in between is sent rather than lost.
+func (server *Server) WatchAccount(req *pb.WatchAccountRequest, stream pb.SimpleBank_WatchAccountServer) error {
+	ctx := stream.Context()
+
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "unauthorized: %v", err)
+	}
+	if req.GetAccountId() <= 0 {
+		return status.Error(codes.InvalidArgument, "account_id must be positive")
+	}
+
+	sub := server.hub.Subscribe(req.GetAccountId())
+	defer sub.Close()
+
+	account, err := server.store.GetAccount(ctx, req.GetAccountId())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return status.Error(codes.NotFound, "account not found")
+		}
+		return status.Errorf(codes.Internal, "cannot get account: %v", err)
+	}
+	if account.Owner != payload.Username {
+		return status.Error(codes.PermissionDenied, "account doesn't belong to the authenticated user")
+	}
+
+	if err := stream.Send(&pb.WatchAccountResponse{Account: convertAccount(account)}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case update, ok := <-sub.C:
+			if !ok {
+				return status.Error(codes.Unavailable, sub.Err().Error())
+			}
+			res := &pb.WatchAccountResponse{Account: convertAccount(update.Account)}
+			if update.Entry != nil {
+				res.Entry = convertEntry(*update.Entry)
+			}
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package gapi
+
+import (
+	"context"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// watchStream hands every response the server sends to responses.
+type watchStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses chan *pb.WatchAccountResponse
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchStream) Send(res *pb.WatchAccountResponse) error {
+	s.responses <- res
+	return nil
+}
+
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID:               int64(util.RandomInt(1, 1000)),
+		Owner:            owner,
+		Balance:          int64(util.RandomAmount()),
+		AvailableBalance: int64(util.RandomAmount()),
+		Currency:         util.RandomCurrency(),
+	}
+}
+
+func TestWatchAccount(t *testing.T) {
+	owner := util.RandomOwner()
+	account := randomAccount(owner)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(owner)).Times(1).Return(time.Time{}, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+	server := newTestServer(t, store)
+	ctx, cancel := context.WithCancel(newContextWithBearerToken(t, server.tokenMaker, owner, time.Minute))
+	stream := &watchStream{ctx: ctx, responses: make(chan *pb.WatchAccountResponse)}
+
+	errs := make(chan error)
+	go func() {
+		errs <- server.WatchAccount(&pb.WatchAccountRequest{AccountId: account.ID}, stream)
+	}()
+
+	res := <-stream.responses
+	require.Equal(t, account.ID, res.GetAccount().GetId())
+	require.Equal(t, account.Balance, res.GetAccount().GetBalance())
+	require.Nil(t, res.GetEntry())
+
+	updated := account
+	updated.Balance += 10
+	server.hub.Broadcast(db.AccountUpdate{
+		Account: updated,
+		Entry:   &db.Entry{ID: 1, AccountID: account.ID, Amount: 10},
+	})
+
+	res = <-stream.responses
+	require.Equal(t, updated.Balance, res.GetAccount().GetBalance())
+	require.Equal(t, int64(10), res.GetEntry().GetAmount())
+
+	// A hold only moves the available balance and adds no entry.
+	held := updated
+	held.AvailableBalance -= 5
+	server.hub.Broadcast(db.AccountUpdate{Account: held})
+
+	res = <-stream.responses
+	require.Equal(t, held.AvailableBalance, res.GetAccount().GetAvailableBalance())
+	require.Nil(t, res.GetEntry())
+
+	cancel()
+	require.NoError(t, <-errs)
+}
+
+func TestWatchAccountHubClosed(t *testing.T) {
+	owner := util.RandomOwner()
+	account := randomAccount(owner)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).Times(1).Return(time.Time{}, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(account, nil)
+
+	server := newTestServer(t, store)
+	stream := &watchStream{
+		ctx:       newContextWithBearerToken(t, server.tokenMaker, owner, time.Minute),
+		responses: make(chan *pb.WatchAccountResponse, 1),
+	}
+
+	server.hub.Close()
+	err := server.WatchAccount(&pb.WatchAccountRequest{AccountId: account.ID}, stream)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestWatchAccountErrors(t *testing.T) {
+	owner := util.RandomOwner()
+	account := randomAccount(owner)
+
+	testCases := []struct {
+		name       string
+		req        *pb.WatchAccountRequest
+		username   string
+		buildStubs func(store *mockdb.MockStore)
+		code       codes.Code
+	}{
+		{
+			name:     "NotOwner",
+			req:      &pb.WatchAccountRequest{AccountId: account.ID},
+			username: "mallory",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).Times(1).Return(time.Time{}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name:     "NotFound",
+			req:      &pb.WatchAccountRequest{AccountId: account.ID},
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).Times(1).Return(time.Time{}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+			},
+			code: codes.NotFound,
+		},
+		{
+			name:     "InvalidAccountID",
+			req:      &pb.WatchAccountRequest{},
+			username: owner,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).Times(1).Return(time.Time{}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			stream := &watchStream{
+				ctx:       newContextWithBearerToken(t, server.tokenMaker, tc.username, time.Minute),
+				responses: make(chan *pb.WatchAccountResponse, 1),
+			}
+
+			err := server.WatchAccount(tc.req, stream)
+			require.Equal(t, tc.code, status.Code(err))
+		})
+	}
+
+	t.Run("NoAuthorization", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		server := newTestServer(t, mockdb.NewMockStore(ctrl))
+		stream := &watchStream{ctx: context.Background()}
+
+		err := server.WatchAccount(&pb.WatchAccountRequest{AccountId: account.ID}, stream)
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
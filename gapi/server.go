@@ -4,27 +4,50 @@ import (
 	"fmt"
 	db "simplebank/db/sqlc"
 	"simplebank/pb"
+	"simplebank/ratelimit"
 	"simplebank/token"
 	"simplebank/util"
+	"simplebank/watch"
+	"simplebank/worker"
 )
 
 type Server struct {
 	pb.UnimplementedSimpleBankServer
-	store      db.Store
-	tokenMaker token.TokenMaker
-	config     util.Config
+	store       db.Store
+	distributor worker.TaskDistributor
+	tokenMaker  token.TokenMaker
+	hub         *watch.Hub
+	config      util.Config
+
+	passwordHasher util.PasswordHasher
+	passwordPolicy util.PasswordPolicy
+
+	verifyEmailLimiter   ratelimit.Limiter
+	loginUsernameLimiter ratelimit.Limiter
+	resetIPLimiter       ratelimit.Limiter
+	resetEmailLimiter    ratelimit.Limiter
 }
 
-func NewServer(config util.Config, st db.Store) (*Server, error) {
+func NewServer(config util.Config, st db.Store, distributor worker.TaskDistributor, hub *watch.Hub) (*Server, error) {
 	tokenMaker, err := token.NewPasetoMaker(config.TokenKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %v", err)
 	}
 
 	server := Server{
-		store:      st,
-		tokenMaker: tokenMaker,
-		config:     config,
+		store:       st,
+		distributor: distributor,
+		tokenMaker:  tokenMaker,
+		hub:         hub,
+		config:      config,
+
+		passwordHasher: util.NewPasswordHasher(config),
+		passwordPolicy: util.NewPasswordPolicy(config),
+
+		verifyEmailLimiter:   ratelimit.NewTokenBucket(config.VerifyEmailResendLimit, config.VerifyEmailResendWindow),
+		loginUsernameLimiter: ratelimit.NewTokenBucket(config.LoginUsernameLimit, config.LoginLimitWindow),
+		resetIPLimiter:       ratelimit.NewTokenBucket(config.PasswordResetIPLimit, config.PasswordResetLimitWindow),
+		resetEmailLimiter:    ratelimit.NewTokenBucket(config.PasswordResetEmailLimit, config.PasswordResetLimitWindow),
 	}
 
 	return &server, nil
@@ -0,0 +1,28 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkStepUp mirrors the HTTP step-up check: amounts above the currency's
+// threshold need a token that was stepped up recently.
+func (server *Server) checkStepUp(ctx context.Context, payload *token.Payload, currency string, amount int64) error {
+	rule, err := server.store.GetStepUpRule(ctx, currency)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil
+		}
+		return status.Errorf(codes.Internal, "cannot get step-up rule: %v", err)
+	}
+
+	if amount <= rule.Threshold || payload.SteppedUpWithin(server.config.StepUpMaxAge) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "step-up authentication required above %d", rule.Threshold)
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrShuttingDown      = errors.New("server is shutting down")
+	ErrMigrationsPending = errors.New("database migrations are pending")
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type MigrationChecker interface {
+	Pending() (bool, error)
+}
+
+// Checker decides whether the service can take traffic. It is shared by the
+// HTTP and gRPC servers so that both report the same state.
+type Checker struct {
+	db         Pinger
+	migrations MigrationChecker
+	draining   atomic.Bool
+	drainOnce  sync.Once
+	drained    chan struct{}
+}
+
+func NewChecker(db Pinger, migrations MigrationChecker) *Checker {
+	return &Checker{
+		db:         db,
+		migrations: migrations,
+		drained:    make(chan struct{}),
+	}
+}
+
+// Ready returns nil when the database answers and every migration has been
+// applied, unless the checker is draining for shutdown.
+func (c *Checker) Ready(ctx context.Context) error {
+	if c.draining.Load() {
+		return ErrShuttingDown
+	}
+
+	if err := c.db.Ping(ctx); err != nil {
+		return fmt.Errorf("cannot ping db: %w", err)
+	}
+
+	if c.migrations != nil {
+		pending, err := c.migrations.Pending()
+		if err != nil {
+			return fmt.Errorf("cannot check migrations: %w", err)
+		}
+		if pending {
+			return ErrMigrationsPending
+		}
+	}
+
+	return nil
+}
+
+// Drain makes every later readiness check fail so that load balancers stop
+// routing new requests before the servers shut down.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+	c.drainOnce.Do(func() { close(c.drained) })
+}
+
+// Watch polls Ready every interval and calls update whenever the result
+// changes, starting with the first check. Draining triggers an immediate
+// check. It returns when ctx is done.
+func (c *Checker) Watch(ctx context.Context, interval time.Duration, update func(ready bool)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	drained := c.drained
+	first := true
+	var last bool
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		ready := c.Ready(checkCtx) == nil
+		cancel()
+
+		if first || ready != last {
+			update(ready)
+			first, last = false, ready
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-drained:
+			drained = nil
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (p *fakePinger) Ping(context.Context) error {
+	return p.err
+}
+
+type fakeMigrations struct {
+	pending bool
+	err     error
+}
+
+func (m *fakeMigrations) Pending() (bool, error) {
+	return m.pending, m.err
+}
+
+func TestReady(t *testing.T) {
+	testCases := []struct {
+		name       string
+		pinger     *fakePinger
+		migrations *fakeMigrations
+		drain      bool
+		check      func(t *testing.T, err error)
+	}{
+		{
+			name:       "OK",
+			pinger:     &fakePinger{},
+			migrations: &fakeMigrations{},
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:       "DBDown",
+			pinger:     &fakePinger{err: errors.New("connection refused")},
+			migrations: &fakeMigrations{},
+			check: func(t *testing.T, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:       "MigrationsPending",
+			pinger:     &fakePinger{},
+			migrations: &fakeMigrations{pending: true},
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrMigrationsPending)
+			},
+		},
+		{
+			name:       "MigrationsError",
+			pinger:     &fakePinger{},
+			migrations: &fakeMigrations{err: errors.New("no such table")},
+			check: func(t *testing.T, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:       "Draining",
+			pinger:     &fakePinger{},
+			migrations: &fakeMigrations{},
+			drain:      true,
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrShuttingDown)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewChecker(tc.pinger, tc.migrations)
+			if tc.drain {
+				checker.Drain()
+			}
+			tc.check(t, checker.Ready(context.Background()))
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	checker := NewChecker(&fakePinger{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan bool, 4)
+	go checker.Watch(ctx, time.Hour, func(ready bool) {
+		updates <- ready
+	})
+
+	require.True(t, <-updates)
+	checker.Drain()
+	require.False(t, <-updates)
+}
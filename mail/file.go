@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes every message to its own .eml file in dir, which is
+// handy for local runs where no SMTP server is available.
+type FileMailer struct {
+	dir  string
+	from string
+	seq  atomic.Int64
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("cannot create mail dir: %w", err)
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%d.eml", now.Format("20060102T150405.000000000"), m.seq.Add(1))
+	err := os.WriteFile(filepath.Join(m.dir, name), encode(m.from, msg, now), 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot write email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"simplebank/util"
+)
+
+const (
+	MailerFile = "file"
+	MailerSMTP = "smtp"
+)
+
+type Message struct {
+	To      []string
+	Subject string
+	// Body is HTML.
+	Body string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the Mailer selected by config. Local runs default to writing
+// messages to files instead of sending them.
+func New(config util.Config) (Mailer, error) {
+	switch config.Mailer {
+	case "", MailerFile:
+		return NewFileMailer(config.MailDir, config.MailFrom), nil
+	case MailerSMTP:
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom), nil
+	}
+	return nil, fmt.Errorf("unknown mailer %q", config.Mailer)
+}
@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := NewFileMailer(dir, "bank@example.com")
+
+	msg := VerifyEmailMessage("http://localhost:7070", "alice@example.com", "Alice", 42, "secret")
+	require.NoError(t, mailer.Send(context.Background(), msg))
+	require.NoError(t, mailer.Send(context.Background(), msg))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	content := string(data)
+	require.Contains(t, content, "From: bank@example.com\r\n")
+	require.Contains(t, content, "To: alice@example.com\r\n")
+	require.Contains(t, content, "Subject: Welcome to Simple Bank\r\n")
+	require.Contains(t, content, "http://localhost:7070/users/verify_email?email_id=42&amp;secret_code=secret")
+}
+
+func TestEncode(t *testing.T) {
+	msg := Message{
+		To:      []string{"a@example.com", "b@example.com"},
+		Subject: "Grüße",
+		Body:    "<p>hi</p>",
+	}
+	date := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	data := string(encode("bank@example.com", msg, date))
+	header, body, ok := strings.Cut(data, "\r\n\r\n")
+	require.True(t, ok)
+	require.Contains(t, header, "To: a@example.com, b@example.com")
+	require.Contains(t, header, "Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=")
+	require.Contains(t, header, "Date: Mon, 02 Jan 2023 03:04:05 +0000")
+	require.Equal(t, "<p>hi</p>", body)
+}
+
+func TestNew(t *testing.T) {
+	mailer, err := New(util.Config{Mailer: MailerSMTP, SMTPHost: "localhost", SMTPPort: 25})
+	require.NoError(t, err)
+	require.IsType(t, &SMTPMailer{}, mailer)
+
+	mailer, err = New(util.Config{})
+	require.NoError(t, err)
+	require.IsType(t, &FileMailer{}, mailer)
+
+	_, err = New(util.Config{Mailer: "pigeon"})
+	require.Error(t, err)
+}
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+)
+
+// VerifyEmailMessage builds the email that asks a new user to confirm their
+// address by opening the verification link.
+func VerifyEmailMessage(baseURL, to, fullname string, emailID int64, secretCode string) Message {
+	query := url.Values{}
+	query.Set("email_id", fmt.Sprint(emailID))
+	query.Set("secret_code", secretCode)
+	link := fmt.Sprintf("%s/users/verify_email?%s", baseURL, query.Encode())
+
+	body := fmt.Sprintf(`Hello %s,<br/>
+Thank you for registering with us!<br/>
+Please <a href="%s">click here</a> to verify your email address.<br/>`,
+		html.EscapeString(fullname), html.EscapeString(link))
+
+	return Message{
+		To:      []string{to},
+		Subject: "Welcome to Simple Bank",
+		Body:    body,
+	}
+}
+
+// PasswordResetMessage builds the email that carries a one-time link for
+// choosing a new password.
+func PasswordResetMessage(baseURL, to, fullname, token string) Message {
+	query := url.Values{}
+	query.Set("token", token)
+	link := fmt.Sprintf("%s/users/reset_password?%s", baseURL, query.Encode())
+
+	body := fmt.Sprintf(`Hello %s,<br/>
+We received a request to reset your password.<br/>
+Please <a href="%s">click here</a> to choose a new one. If you did not ask for this, you can ignore this email.<br/>`,
+		html.EscapeString(fullname), html.EscapeString(link))
+
+	return Message{
+		To:      []string{to},
+		Subject: "Reset your Simple Bank password",
+		Body:    body,
+	}
+}
@@ -1,61 +1,322 @@
 package main
 
 import (
-	"database/sql"
-	"log"
+	"context"
+	"errors"
+	"io"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"simplebank/admin"
+	"simplebank/api"
+	"simplebank/db/migration"
 	db "simplebank/db/sqlc"
 	"simplebank/gapi"
+	"simplebank/health"
+	"simplebank/mail"
+	"simplebank/metrics"
+	"simplebank/outbox"
 	"simplebank/pb"
+	"simplebank/tracing"
 	"simplebank/util"
+	"simplebank/watch"
+	"simplebank/worker"
+	"syscall"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 func main() {
 	config, err := util.LoadConfig(".")
 	if err != nil {
-		log.Fatalln("cannot load env variables", err)
+		log.Fatal().Err(err).Msg("cannot load env variables")
 	}
-	conn, err := sql.Open(config.DBDriver, config.DBSource)
+
+	if config.Environment == "development" {
+		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migration.RunCommand(config.DBSource, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal().Err(err).Msg("cannot run migrate command")
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot set up tracing")
+	}
+	defer shutdownTracing(context.Background())
+
+	if config.DBAutoMigrate {
+		runDBMigration(config.DBSource)
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "tasks":
+			runStoreCommand(config, "tasks", worker.RunCommand, os.Args[2:])
+			return
+		case "fees":
+			runStoreCommand(config, "fees", admin.RunFeesCommand, os.Args[2:])
+			return
+		case "limits":
+			runStoreCommand(config, "limits", admin.RunLimitsCommand, os.Args[2:])
+			return
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	connPool, err := newConnPool(ctx, config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot connect to db")
+	}
+	defer connPool.Close()
+	if err := metrics.RegisterPool(connPool); err != nil {
+		log.Fatal().Err(err).Msg("cannot register db pool metrics")
+	}
+
+	migrator, err := migration.New(config.DBSource)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create migrator")
+	}
+	defer migrator.Close()
+
+	mailer, err := mail.New(config)
 	if err != nil {
-		log.Fatalln(err)
+		log.Fatal().Err(err).Msg("cannot create mailer")
 	}
-	store := db.NewStore(conn)
-	runGrpcServer(config, store)
+
+	publisher, err := outbox.New(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create outbox publisher")
+	}
+	defer publisher.Close()
+
+	store := db.NewStore(connPool)
+	checker := health.NewChecker(store, migrator)
+
+	distributor := worker.NewTaskDistributor(store)
+	hub := watch.NewHub()
+	go hub.Listen(ctx, connPool)
+
+	go worker.NewHoldSweeper(store, config.HoldSweepInterval).Start(ctx)
+	go worker.NewWebhookDispatcher(store, distributor, config.WebhookDispatchInterval).Start(ctx)
+	go worker.NewOutboxRelay(store, publisher, config.OutboxRelayInterval).Start(ctx)
+	go worker.NewAuditSealer(store, config.AuditSealInterval).Start(ctx)
+	processorDone := runTaskProcessor(ctx, config, store, mailer)
+	httpServer := runGinServer(config, store, checker, distributor, hub)
+	grpcServer := runGrpcServer(config, store, checker, distributor, hub)
+	metricsServer := runMetricsServer(config)
+
+	<-ctx.Done()
+	log.Info().Msg("shutting down")
+
+	// Fail readiness first and give the orchestrator time to notice before
+	// the listeners go away.
+	checker.Drain()
+	time.Sleep(config.ShutdownDrainDelay)
+
+	// Watch streams never end on their own, so end them before waiting for
+	// in-flight requests.
+	hub.Close()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("cannot shut down HTTP server")
+	}
+	stopGrpcServer(shutdownCtx, grpcServer)
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("cannot shut down metrics server")
+		}
+	}
+
+	select {
+	case <-processorDone:
+	case <-shutdownCtx.Done():
+		log.Warn().Msg("task processor did not stop in time")
+	}
+	log.Info().Msg("servers stopped")
 }
 
-// func runGinServer(config util.Config, store db.Store) {
-// 	server, err := api.NewServer(config, store)
-// 	if err != nil {
-// 		log.Fatalln("cannot create server", err)
-// 	}
+// storeCommand runs a subcommand against the database and writes its report
+// to w.
+type storeCommand func(ctx context.Context, store db.Store, args []string, w io.Writer) error
 
-// 	err = server.Start(config.HTTPServerAddress)
-// 	if err != nil {
-// 		log.Fatalln("cannot start HTTP server", err)
-// 	}
-// }
+func runStoreCommand(config util.Config, name string, run storeCommand, args []string) {
+	ctx := context.Background()
+	connPool, err := newConnPool(ctx, config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot connect to db")
+	}
+	defer connPool.Close()
 
-func runGrpcServer(config util.Config, store db.Store) {
-	server, err := gapi.NewServer(config, store)
+	if err := run(ctx, db.NewStore(connPool), args, os.Stdout); err != nil {
+		log.Fatal().Err(err).Msgf("cannot run %s command", name)
+	}
+}
+
+// runTaskProcessor returns a channel that is closed once the processor has
+// finished its current tasks after ctx is cancelled.
+func runTaskProcessor(ctx context.Context, config util.Config, store db.Store, mailer mail.Mailer) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		log.Info().Int("concurrency", config.TaskConcurrency).Msg("started task processor")
+		worker.NewTaskProcessor(store, mailer, config).Start(ctx)
+	}()
+	return done
+}
+
+func runDBMigration(dbSource string) {
+	mg, err := migration.New(dbSource)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create migrator")
+	}
+	defer mg.Close()
+
+	if err := mg.Up(0); err != nil {
+		log.Fatal().Err(err).Msg("cannot run db migrations")
+	}
+	log.Info().Msg("db migrated successfully")
+}
+
+func newConnPool(ctx context.Context, config util.Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(config.DBSource)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.DBMaxConns > 0 {
+		poolConfig.MaxConns = config.DBMaxConns
+	}
+	if config.DBMinConns > 0 {
+		poolConfig.MinConns = config.DBMinConns
+	}
+	if config.DBMaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.DBMaxConnIdleTime
+	}
+	if config.DBMaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.DBMaxConnLifetime
+	}
+	if config.DBStatementCache > 0 {
+		poolConfig.ConnConfig.StatementCacheCapacity = config.DBStatementCache
+	}
+
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
+
+func runGinServer(config util.Config, store db.Store, checker *health.Checker, distributor worker.TaskDistributor, hub *watch.Hub) *api.Server {
+	server, err := api.NewServer(config, store, checker, distributor, hub)
 	if err != nil {
-		log.Fatalln("cannot create server", err)
+		log.Fatal().Err(err).Msg("cannot create server")
+	}
+
+	go func() {
+		log.Info().Msgf("started HTTP server at %s", config.HTTPServerAddress)
+		if err := server.Start(config.HTTPServerAddress); err != nil {
+			log.Fatal().Err(err).Msg("cannot start HTTP server")
+		}
+	}()
+
+	return server
+}
+
+// runMetricsServer serves the metrics on their own address, which is meant
+// to be reachable by the scraper only. It returns nil when no address is
+// configured.
+func runMetricsServer(config util.Config) *http.Server {
+	if config.MetricsServerAddress == "" {
+		log.Warn().Msg("METRICS_SERVER_ADDRESS is not set, metrics are not served")
+		return nil
 	}
 
-	grpcServer := grpc.NewServer()
+	server := metrics.NewServer(config.MetricsServerAddress)
+	go func() {
+		log.Info().Msgf("started metrics server at %s", config.MetricsServerAddress)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("cannot start metrics server")
+		}
+	}()
+
+	return server
+}
+
+func runGrpcServer(config util.Config, store db.Store, checker *health.Checker, distributor worker.TaskDistributor, hub *watch.Hub) *grpc.Server {
+	server, err := gapi.NewServer(config, store, distributor, hub)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create server")
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			otelgrpc.UnaryServerInterceptor(),
+			gapi.GrpcLogger,
+			gapi.GrpcMetrics,
+		),
+		grpc.ChainStreamInterceptor(
+			otelgrpc.StreamServerInterceptor(),
+			gapi.GrpcStreamLogger,
+			gapi.GrpcStreamMetrics,
+		),
+	)
 	pb.RegisterSimpleBankServer(grpcServer, server)
 	reflection.Register(grpcServer)
 
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go checker.Watch(context.Background(), config.HealthCheckInterval, func(ready bool) {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if ready {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		healthServer.SetServingStatus("", status)
+		healthServer.SetServingStatus(pb.SimpleBank_ServiceDesc.ServiceName, status)
+	})
+
 	listener, err := net.Listen("tcp", config.GRPCServerAddress)
 	if err != nil {
-		log.Fatalln("cannot create listener", err)
+		log.Fatal().Err(err).Msg("cannot create listener")
 	}
-	log.Printf("started gRPC server at %s\n", listener.Addr().String())
-	err = grpcServer.Serve(listener)
-	if err != nil {
-		log.Fatalln("cannot start GRPC server", err)
+
+	go func() {
+		log.Info().Msgf("started gRPC server at %s", listener.Addr().String())
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Fatal().Err(err).Msg("cannot start GRPC server")
+		}
+	}()
+
+	return grpcServer
+}
+
+// stopGrpcServer waits for in-flight RPCs until ctx expires, then closes
+// the remaining connections.
+func stopGrpcServer(ctx context.Context, grpcServer *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Warn().Msg("gRPC graceful stop timed out")
+		grpcServer.Stop()
 	}
 }
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "simplebank"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	GRPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+
+	GRPCDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC call latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	TransferTxDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "transfer_tx_duration_seconds",
+		Help:      "Latency of transfer transactions, retries included.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	TransferTxErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfer_tx_errors_total",
+		Help:      "Failed transfer transactions by error type.",
+	}, []string{"type"})
+
+	TxRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_tx_retries_total",
+		Help:      "Database transactions retried by SQLSTATE.",
+	}, []string{"code"})
+
+	Transfers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Completed transfers by currency.",
+	}, []string{"currency"})
+
+	TransferVolume = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfer_volume_total",
+		Help:      "Amount moved by completed transfers, in minor units, by currency.",
+	}, []string{"currency"})
+
+	Logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by result.",
+	}, []string{"result"})
+
+	AccountsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "accounts_created_total",
+		Help:      "Accounts opened by currency.",
+	}, []string{"currency"})
+
+	TasksProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_processed_total",
+		Help:      "Background task attempts by type and outcome.",
+	}, []string{"type", "result"})
+
+	TaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "task_duration_seconds",
+		Help:      "Time spent running background tasks, by type.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"type"})
+)
+
+const (
+	LoginSucceeded   = "succeeded"
+	LoginFailed      = "failed"
+	LoginRateLimited = "rate_limited"
+	LoginMFARequired = "mfa_required"
+)
+
+const (
+	TaskCompleted = "completed"
+	TaskRetried   = "retried"
+	TaskDead      = "dead"
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// NewServer serves Handler at /metrics on its own listener, so that the
+// metrics stay off the public API ports.
+func NewServer(address string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return &http.Server{
+		Addr:    address,
+		Handler: mux,
+	}
+}
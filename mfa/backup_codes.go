@@ -0,0 +1,40 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+const backupCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// GenerateBackupCodes returns n single-use codes of the form xxxxx-xxxxx.
+// The alphabet leaves out characters that are easy to misread.
+func GenerateBackupCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	max := big.NewInt(int64(len(backupCodeAlphabet)))
+
+	for i := range codes {
+		var sb strings.Builder
+		for j := 0; j < 10; j++ {
+			if j == 5 {
+				sb.WriteByte('-')
+			}
+			k, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, err
+			}
+			sb.WriteByte(backupCodeAlphabet[k.Int64()])
+		}
+		codes[i] = sb.String()
+	}
+
+	return codes, nil
+}
+
+// NormalizeBackupCode lets users type a code without the dash or in
+// upper case. Hash the normalized form.
+func NormalizeBackupCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
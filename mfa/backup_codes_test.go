@@ -0,0 +1,28 @@
+package mfa
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateBackupCodes(t *testing.T) {
+	codes, err := GenerateBackupCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		require.Len(t, code, 11)
+		require.Equal(t, byte('-'), code[5])
+		require.Len(t, NormalizeBackupCode(code), 10)
+		require.False(t, seen[code])
+		seen[code] = true
+	}
+}
+
+func TestNormalizeBackupCode(t *testing.T) {
+	require.Equal(t, "abcde23456", NormalizeBackupCode(" ABCDE-23456 "))
+	require.Equal(t, NormalizeBackupCode("abcde-23456"), NormalizeBackupCode(strings.ToUpper("abcde23456")))
+}
@@ -0,0 +1,61 @@
+package mfa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// SecretBox encrypts TOTP secrets at rest with AES-256-GCM. Each sealed
+// value is the base64 of nonce followed by ciphertext.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+func NewSecretBox(key string) (*SecretBox, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid key size, must be exactly 32 characters long")
+	}
+
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecretBox{aead: aead}, nil
+}
+
+func (b *SecretBox) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *SecretBox) Open(sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", fmt.Errorf("cannot decode secret: %w", err)
+	}
+
+	nonceSize := b.aead.NonceSize()
+	if len(data) < nonceSize {
+		return "", errors.New("sealed secret is too short")
+	}
+
+	plaintext, err := b.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
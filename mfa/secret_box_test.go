@@ -0,0 +1,39 @@
+package mfa
+
+import (
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretBox(t *testing.T) {
+	box, err := NewSecretBox(util.RandomString(32))
+	require.NoError(t, err)
+
+	secret := util.RandomString(32)
+	sealed1, err := box.Seal(secret)
+	require.NoError(t, err)
+	require.NotContains(t, sealed1, secret)
+
+	sealed2, err := box.Seal(secret)
+	require.NoError(t, err)
+	require.NotEqual(t, sealed1, sealed2)
+
+	opened, err := box.Open(sealed1)
+	require.NoError(t, err)
+	require.Equal(t, secret, opened)
+
+	otherBox, err := NewSecretBox(util.RandomString(32))
+	require.NoError(t, err)
+	_, err = otherBox.Open(sealed1)
+	require.Error(t, err)
+
+	_, err = box.Open("not base64!")
+	require.Error(t, err)
+}
+
+func TestNewSecretBoxInvalidKey(t *testing.T) {
+	_, err := NewSecretBox("short")
+	require.Error(t, err)
+}
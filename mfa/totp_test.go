@@ -0,0 +1,55 @@
+package mfa
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEnrollment(t *testing.T) {
+	enrollment, err := NewEnrollment("alice")
+	require.NoError(t, err)
+	require.NotEmpty(t, enrollment.Secret)
+
+	uri, err := url.Parse(enrollment.ProvisioningURI)
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, enrollment.Secret, uri.Query().Get("secret"))
+	require.Equal(t, issuer, uri.Query().Get("issuer"))
+
+	img, err := base64.StdEncoding.DecodeString(enrollment.QRCode)
+	require.NoError(t, err)
+	require.Equal(t, "\x89PNG", string(img[:4]))
+}
+
+func TestValidateCode(t *testing.T) {
+	enrollment, err := NewEnrollment("alice")
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := totp.GenerateCode(enrollment.Secret, now)
+	require.NoError(t, err)
+	step, ok := ValidateCode(enrollment.Secret, code)
+	require.True(t, ok)
+	require.InDelta(t, now.Unix()/period, step, 1)
+
+	// One step of drift is tolerated, more is not.
+	code, err = totp.GenerateCode(enrollment.Secret, now.Add(-30*time.Second))
+	require.NoError(t, err)
+	previous, ok := ValidateCode(enrollment.Secret, code)
+	require.True(t, ok)
+	require.Less(t, previous, step)
+
+	code, err = totp.GenerateCode(enrollment.Secret, now.Add(-5*time.Minute))
+	require.NoError(t, err)
+	_, ok = ValidateCode(enrollment.Secret, code)
+	require.False(t, ok)
+
+	_, ok = ValidateCode(enrollment.Secret, "abcdef")
+	require.False(t, ok)
+}
@@ -0,0 +1,73 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+const accountHeader = "Simplebank-Account"
+
+// NATSPublisher publishes to a JetStream stream, on the subject
+// "<subject>.<type>". JetStream acknowledges a message once it is stored and
+// drops a redelivery of the same event ID within its duplicate window.
+type NATSPublisher struct {
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+	subject string
+}
+
+// NewNATSPublisher connects to url and creates the stream when it does not
+// exist yet.
+func NewNATSPublisher(url, stream, subject string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("simplebank outbox relay"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to nats: %w", err)
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot open jetstream: %w", err)
+	}
+
+	_, err = js.StreamInfo(stream)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = js.AddStream(&nats.StreamConfig{
+			Name:     stream,
+			Subjects: []string{subject + ".>"},
+			Storage:  nats.FileStorage,
+		})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot set up stream %q: %w", stream, err)
+	}
+
+	return &NATSPublisher{conn: conn, js: js, subject: subject}, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("cannot marshal message: %w", err)
+	}
+
+	m := nats.NewMsg(p.subject + "." + msg.Type)
+	m.Data = data
+	m.Header.Set(nats.MsgIdHdr, strconv.FormatInt(msg.ID, 10))
+	m.Header.Set(accountHeader, strconv.FormatInt(msg.AccountID, 10))
+
+	if _, err := p.js.PublishMsg(m, nats.Context(ctx)); err != nil {
+		return fmt.Errorf("cannot publish message: %w", err)
+	}
+	return nil
+}
+
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,49 @@
+// Package outbox publishes the domain events that the store writes to the
+// outbox table to an external stream.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"simplebank/util"
+	"time"
+)
+
+const (
+	PublisherFile   = "file"
+	PublisherNATS   = "nats"
+	PublisherMemory = "memory"
+)
+
+// Message is one event as it leaves the bank. ID is unique and increases
+// with every event; consumers deduplicate redeliveries with it.
+type Message struct {
+	ID        int64           `json:"id"`
+	AccountID int64           `json:"account_id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// Publisher hands messages to a stream. Publish must not return until the
+// stream has durably accepted the message, and must keep messages in the
+// order it is called in.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// New returns the Publisher selected by config. Local runs default to
+// appending messages to a file.
+func New(config util.Config) (Publisher, error) {
+	switch config.OutboxPublisher {
+	case "", PublisherFile:
+		return NewFilePublisher(config.OutboxFile)
+	case PublisherNATS:
+		return NewNATSPublisher(config.NATSURL, config.NATSStream, config.NATSSubject)
+	case PublisherMemory:
+		return NewMemoryPublisher(), nil
+	}
+	return nil, fmt.Errorf("unknown outbox publisher %q", config.OutboxPublisher)
+}
@@ -0,0 +1,89 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomMessage(id int64) Message {
+	return Message{
+		ID:        id,
+		AccountID: int64(util.RandomInt(1, 1000)),
+		Type:      "account.credited",
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		Payload:   json.RawMessage(`{"amount":10}`),
+	}
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox", "events.jsonl")
+	publisher, err := NewFilePublisher(path)
+	require.NoError(t, err)
+
+	messages := []Message{randomMessage(1), randomMessage(2)}
+	for _, msg := range messages {
+		require.NoError(t, publisher.Publish(context.Background(), msg))
+	}
+	require.NoError(t, publisher.Close())
+
+	// Reopening appends rather than truncating.
+	publisher, err = NewFilePublisher(path)
+	require.NoError(t, err)
+	messages = append(messages, randomMessage(3))
+	require.NoError(t, publisher.Publish(context.Background(), messages[2]))
+	require.NoError(t, publisher.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var got []Message
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var msg Message
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		got = append(got, msg)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, got, len(messages))
+	for i := range messages {
+		require.Equal(t, messages[i].ID, got[i].ID)
+		require.Equal(t, messages[i].AccountID, got[i].AccountID)
+		require.True(t, messages[i].CreatedAt.Equal(got[i].CreatedAt))
+		require.JSONEq(t, string(messages[i].Payload), string(got[i].Payload))
+	}
+}
+
+func TestMemoryPublisher(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	msg := randomMessage(1)
+	require.NoError(t, publisher.Publish(context.Background(), msg))
+	require.Equal(t, []Message{msg}, publisher.Messages())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, publisher.Publish(ctx, randomMessage(2)), context.Canceled)
+	require.Len(t, publisher.Messages(), 1)
+}
+
+func TestNew(t *testing.T) {
+	publisher, err := New(util.Config{OutboxFile: filepath.Join(t.TempDir(), "events.jsonl")})
+	require.NoError(t, err)
+	require.IsType(t, &FilePublisher{}, publisher)
+	require.NoError(t, publisher.Close())
+
+	publisher, err = New(util.Config{OutboxPublisher: PublisherMemory})
+	require.NoError(t, err)
+	require.IsType(t, &MemoryPublisher{}, publisher)
+
+	_, err = New(util.Config{OutboxPublisher: "carrier-pigeon"})
+	require.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter decides whether a request identified by key may proceed. When it
+// may not, retryAfter says how long the caller should wait.
+type Limiter interface {
+	Allow(key string) (ok bool, retryAfter time.Duration)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// TokenBucket is an in-memory Limiter that allows limit requests per window
+// for every key, refilling continuously. It is local to one process.
+type TokenBucket struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	window    time.Duration
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewTokenBucket returns a Limiter that allows limit requests per window.
+// A non-positive limit or window disables limiting.
+func NewTokenBucket(limit int, window time.Duration) Limiter {
+	if limit <= 0 || window <= 0 {
+		return unlimited{}
+	}
+
+	return &TokenBucket{
+		rate:    float64(limit) / window.Seconds(),
+		burst:   float64(limit),
+		window:  window,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (tb *TokenBucket) Allow(key string) (bool, time.Duration) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := tb.now()
+	tb.sweep(now)
+
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &bucket{tokens: tb.burst, last: now}
+		tb.buckets[key] = b
+	}
+
+	b.tokens = math.Min(tb.burst, b.tokens+now.Sub(b.last).Seconds()*tb.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := (1 - b.tokens) / tb.rate
+		return false, time.Duration(math.Ceil(wait * float64(time.Second)))
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets that have refilled completely, since they behave
+// exactly like new ones. It runs at most once per window.
+func (tb *TokenBucket) sweep(now time.Time) {
+	if now.Sub(tb.lastSweep) < tb.window {
+		return
+	}
+	tb.lastSweep = now
+
+	for key, b := range tb.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*tb.rate >= tb.burst {
+			delete(tb.buckets, key)
+		}
+	}
+}
+
+type unlimited struct{}
+
+func (unlimited) Allow(string) (bool, time.Duration) {
+	return true, 0
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBucket(limit int, window time.Duration) (*TokenBucket, *time.Time) {
+	now := time.Now()
+	tb := NewTokenBucket(limit, window).(*TokenBucket)
+	tb.now = func() time.Time { return now }
+	return tb, &now
+}
+
+func TestTokenBucket(t *testing.T) {
+	tb, now := newTestBucket(3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		ok, _ := tb.Allow("a")
+		require.True(t, ok)
+	}
+
+	ok, retryAfter := tb.Allow("a")
+	require.False(t, ok)
+	require.InDelta(t, 20*time.Second, retryAfter, float64(time.Millisecond))
+
+	// Keys are limited independently.
+	ok, _ = tb.Allow("b")
+	require.True(t, ok)
+
+	*now = now.Add(20 * time.Second)
+	ok, _ = tb.Allow("a")
+	require.True(t, ok)
+
+	ok, _ = tb.Allow("a")
+	require.False(t, ok)
+}
+
+func TestTokenBucketSweep(t *testing.T) {
+	tb, now := newTestBucket(2, time.Minute)
+
+	tb.Allow("a")
+	tb.Allow("b")
+	tb.Allow("b")
+	require.Len(t, tb.buckets, 2)
+
+	*now = now.Add(time.Minute)
+	tb.Allow("c")
+	require.Len(t, tb.buckets, 1)
+	require.Contains(t, tb.buckets, "c")
+}
+
+func TestUnlimited(t *testing.T) {
+	limiter := NewTokenBucket(0, time.Minute)
+	for i := 0; i < 100; i++ {
+		ok, _ := limiter.Allow("a")
+		require.True(t, ok)
+	}
+}
@@ -23,15 +23,40 @@ func NewJWTMaker(secretKey string) (TokenMaker, error) {
 }
 
 func (m JWTMaker) CreateToken(username string, duration time.Duration) (string, error) {
+	return m.createToken(username, ScopeAccess, false, duration)
+}
+
+func (m JWTMaker) CreateStepUpToken(username string, duration time.Duration) (string, error) {
+	return m.createToken(username, ScopeAccess, true, duration)
+}
+
+func (m JWTMaker) CreateMFAToken(username string, duration time.Duration) (string, error) {
+	return m.createToken(username, ScopeMFA, false, duration)
+}
+
+func (m JWTMaker) createToken(username, scope string, stepUp bool, duration time.Duration) (string, error) {
 	payload, err := NewPayload(username, duration)
 	if err != nil {
 		return "", fmt.Errorf("payload error %v", err)
 	}
+	payload.Scope = scope
+	if stepUp {
+		payload.StepUpAt = payload.IssuedAt
+	}
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
 	return jwtToken.SignedString([]byte(m.secretKey))
 }
+
 func (m JWTMaker) VerifyToken(token string) (*Payload, error) {
+	return m.verifyToken(token, ScopeAccess)
+}
+
+func (m JWTMaker) VerifyMFAToken(token string) (*Payload, error) {
+	return m.verifyToken(token, ScopeMFA)
+}
+
+func (m JWTMaker) verifyToken(token, scope string) (*Payload, error) {
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
 		_, ok := token.Method.(*jwt.SigningMethodHMAC)
 		if !ok {
@@ -50,7 +75,7 @@ func (m JWTMaker) VerifyToken(token string) (*Payload, error) {
 	}
 
 	payload, ok := jwtToken.Claims.(*Payload)
-	if !ok {
+	if !ok || payload.Scope != scope {
 		return nil, ErrInvalidToken
 	}
 
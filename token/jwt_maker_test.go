@@ -63,3 +63,38 @@ func TestInvalidJWTTokenAlgNone(t *testing.T) {
 	require.EqualError(t, err, ErrInvalidToken.Error())
 	require.Nil(t, payload)
 }
+
+func TestJWTMFAToken(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	mfaToken, err := maker.CreateMFAToken(username, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyMFAToken(mfaToken)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, ScopeMFA, payload.Scope)
+
+	_, err = maker.VerifyToken(mfaToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	accessToken, err := maker.CreateToken(username, time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyMFAToken(accessToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestJWTStepUpToken(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	token, err := maker.CreateStepUpToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, ScopeAccess, payload.Scope)
+	require.WithinDuration(t, time.Now(), payload.StepUpAt, time.Second)
+	require.True(t, payload.SteppedUpWithin(time.Minute))
+}
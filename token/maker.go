@@ -5,4 +5,11 @@ import "time"
 type TokenMaker interface {
 	CreateToken(username string, duration time.Duration) (string, error)
 	VerifyToken(token string) (*Payload, error)
+	// CreateStepUpToken issues an access token stamped with a step-up
+	// proof, for callers that have just re-checked a second factor.
+	CreateStepUpToken(username string, duration time.Duration) (string, error)
+	// CreateMFAToken issues a challenge token for the second login step.
+	// VerifyToken rejects it, and VerifyMFAToken rejects access tokens.
+	CreateMFAToken(username string, duration time.Duration) (string, error)
+	VerifyMFAToken(token string) (*Payload, error)
 }
@@ -27,15 +27,39 @@ func NewPasetoMaker(symmetricKey string) (TokenMaker, error) {
 }
 
 func (p PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
+	return p.createToken(username, ScopeAccess, false, duration)
+}
+
+func (p PasetoMaker) CreateStepUpToken(username string, duration time.Duration) (string, error) {
+	return p.createToken(username, ScopeAccess, true, duration)
+}
+
+func (p PasetoMaker) CreateMFAToken(username string, duration time.Duration) (string, error) {
+	return p.createToken(username, ScopeMFA, false, duration)
+}
+
+func (p PasetoMaker) createToken(username, scope string, stepUp bool, duration time.Duration) (string, error) {
 	payload, err := NewPayload(username, duration)
 	if err != nil {
 		return "", fmt.Errorf("payload error %v", err)
 	}
+	payload.Scope = scope
+	if stepUp {
+		payload.StepUpAt = payload.IssuedAt
+	}
 
 	return p.paseto.Encrypt(p.symmetricKey, payload, nil)
 }
 
 func (p PasetoMaker) VerifyToken(token string) (*Payload, error) {
+	return p.verifyToken(token, ScopeAccess)
+}
+
+func (p PasetoMaker) VerifyMFAToken(token string) (*Payload, error) {
+	return p.verifyToken(token, ScopeMFA)
+}
+
+func (p PasetoMaker) verifyToken(token, scope string) (*Payload, error) {
 	payload := &Payload{}
 
 	err := p.paseto.Decrypt(token, p.symmetricKey, payload, nil)
@@ -48,5 +72,9 @@ func (p PasetoMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, err
 	}
 
+	if payload.Scope != scope {
+		return nil, ErrInvalidToken
+	}
+
 	return payload, nil
 }
@@ -45,3 +45,46 @@ func TestExpiredPasetoToken(t *testing.T) {
 	require.EqualError(t, err, ErrExpiredToken.Error())
 	require.Nil(t, payload)
 }
+
+func TestPasetoMFAToken(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	mfaToken, err := maker.CreateMFAToken(username, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyMFAToken(mfaToken)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, ScopeMFA, payload.Scope)
+
+	// Neither kind of token stands in for the other.
+	_, err = maker.VerifyToken(mfaToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	accessToken, err := maker.CreateToken(username, time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyMFAToken(accessToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestPasetoStepUpToken(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	token, err := maker.CreateToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.True(t, payload.StepUpAt.IsZero())
+	require.False(t, payload.SteppedUpWithin(time.Hour))
+
+	token, err = maker.CreateStepUpToken(util.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, ScopeAccess, payload.Scope)
+	require.WithinDuration(t, time.Now(), payload.StepUpAt, time.Second)
+	require.True(t, payload.SteppedUpWithin(time.Minute))
+}
@@ -12,11 +12,28 @@ var (
 	ErrExpiredToken = errors.New("token has expired")
 )
 
+// A token's scope says what it may be used for. Only access tokens
+// authorize API calls; an MFA token only proves the password was checked.
+const (
+	ScopeAccess = "access"
+	ScopeMFA    = "mfa"
+)
+
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
+	Scope     string    `json:"scope"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiresAt time.Time `json:"expires_at"`
+	// StepUpAt is when the user last re-proved who they are, for actions
+	// that need a recent second factor. It is zero otherwise.
+	StepUpAt time.Time `json:"step_up_at"`
+}
+
+// SteppedUpWithin reports whether the token carries a step-up proof no
+// older than maxAge.
+func (p *Payload) SteppedUpWithin(maxAge time.Duration) bool {
+	return !p.StepUpAt.IsZero() && time.Since(p.StepUpAt) <= maxAge
 }
 
 func NewPayload(username string, duration time.Duration) (*Payload, error) {
@@ -25,7 +42,7 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 		return nil, err
 	}
 
-	payload := Payload{ID: id, Username: username, IssuedAt: time.Now(), ExpiresAt: time.Now().Add(duration)}
+	payload := Payload{ID: id, Username: username, Scope: ScopeAccess, IssuedAt: time.Now(), ExpiresAt: time.Now().Add(duration)}
 
 	return &payload, nil
 }
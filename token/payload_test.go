@@ -0,0 +1,17 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSteppedUpWithin(t *testing.T) {
+	payload := &Payload{}
+	require.False(t, payload.SteppedUpWithin(time.Hour))
+
+	payload.StepUpAt = time.Now().Add(-2 * time.Minute)
+	require.True(t, payload.SteppedUpWithin(5*time.Minute))
+	require.False(t, payload.SteppedUpWithin(time.Minute))
+}
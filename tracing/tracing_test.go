@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestSetup(t *testing.T) {
+	testCases := []struct {
+		name     string
+		exporter string
+		ok       bool
+	}{
+		{name: "Default", exporter: "", ok: true},
+		{name: "None", exporter: ExporterNone, ok: true},
+		{name: "Stdout", exporter: ExporterStdout, ok: true},
+		{name: "Unknown", exporter: "zipkin"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := util.Config{TracingExporter: tc.exporter, TracingSampleRatio: 1}
+
+			shutdown, err := Setup(context.Background(), config)
+			if !tc.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, shutdown(context.Background()))
+		})
+	}
+}
+
+func TestPropagator(t *testing.T) {
+	_, err := Setup(context.Background(), util.Config{})
+	require.NoError(t, err)
+
+	carrier := propagation.MapCarrier{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+
+	out := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, out)
+	require.Equal(t, carrier["traceparent"], out["traceparent"])
+}
@@ -0,0 +1,31 @@
+package util
+
+import "fmt"
+
+// Account numbers are ten digits followed by two ISO 7064 MOD 97-10 check
+// digits, the scheme IBANs use. Any single mistyped digit and almost any
+// swapped pair of digits makes the number invalid.
+const accountNumberLength = 12
+
+// ValidAccountNumber reports whether number has the right length and check
+// digits. It does not tell whether the account exists.
+func ValidAccountNumber(number string) bool {
+	if len(number) != accountNumberLength {
+		return false
+	}
+
+	rem := 0
+	for _, c := range number {
+		if c < '0' || c > '9' {
+			return false
+		}
+		rem = (rem*10 + int(c-'0')) % 97
+	}
+	return rem == 1
+}
+
+// AccountNumber appends the check digits to a ten digit base. New accounts
+// get their number from the database, which computes it the same way.
+func AccountNumber(base int64) string {
+	return fmt.Sprintf("%010d%02d", base, 98-(base*100)%97)
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountNumber(t *testing.T) {
+	require.Equal(t, "000000000098", AccountNumber(0))
+	require.Equal(t, "123456789092", AccountNumber(1234567890))
+
+	for i := 0; i < 100; i++ {
+		number := RandomAccountNumber()
+		require.Len(t, number, accountNumberLength)
+		require.True(t, ValidAccountNumber(number), number)
+	}
+}
+
+func TestValidAccountNumberCatchesTypos(t *testing.T) {
+	number := RandomAccountNumber()
+
+	for i := 0; i < len(number); i++ {
+		for d := byte('0'); d <= '9'; d++ {
+			if number[i] == d {
+				continue
+			}
+			typo := []byte(number)
+			typo[i] = d
+			require.False(t, ValidAccountNumber(string(typo)), string(typo))
+		}
+	}
+
+	for i := 0; i+1 < len(number); i++ {
+		if number[i] == number[i+1] {
+			continue
+		}
+		swapped := []byte(number)
+		swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+		require.False(t, ValidAccountNumber(string(swapped)), string(swapped))
+	}
+}
+
+func TestValidAccountNumberFormat(t *testing.T) {
+	for _, number := range []string{"", "12345678909", "1234567890921", "12345678909a", " 23456789092"} {
+		require.False(t, ValidAccountNumber(number), number)
+	}
+}
@@ -7,12 +7,73 @@ import (
 )
 
 type Config struct {
-	DBDriver          string        `mapstructure:"DB_DRIVER"`
-	DBSource          string        `mapstructure:"DB_SOURCE"`
-	HTTPServerAddress string        `mapstructure:"HTTP_SERVER_ADDRESS"`
-	GRPCServerAddress string        `mapstructure:"GRPC_SERVER_ADDRESS"`
-	TokenKey          string        `mapstructure:"TOKEN_KEY"`
-	TokenDuration     time.Duration `mapstructure:"ACCESS_TONKEN_DURATION"`
+	Environment               string        `mapstructure:"ENVIRONMENT"`
+	DBSource                  string        `mapstructure:"DB_SOURCE"`
+	DBMaxConns                int32         `mapstructure:"DB_MAX_CONNS"`
+	DBMinConns                int32         `mapstructure:"DB_MIN_CONNS"`
+	DBMaxConnIdleTime         time.Duration `mapstructure:"DB_MAX_CONN_IDLE_TIME"`
+	DBMaxConnLifetime         time.Duration `mapstructure:"DB_MAX_CONN_LIFETIME"`
+	DBStatementCache          int           `mapstructure:"DB_STATEMENT_CACHE_CAPACITY"`
+	DBAutoMigrate             bool          `mapstructure:"DB_AUTO_MIGRATE"`
+	HTTPServerAddress         string        `mapstructure:"HTTP_SERVER_ADDRESS"`
+	GRPCServerAddress         string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	MetricsServerAddress      string        `mapstructure:"METRICS_SERVER_ADDRESS"`
+	TokenKey                  string        `mapstructure:"TOKEN_KEY"`
+	TokenDuration             time.Duration `mapstructure:"ACCESS_TONKEN_DURATION"`
+	MFATokenDuration          time.Duration `mapstructure:"MFA_TOKEN_DURATION"`
+	MFAEncryptionKey          string        `mapstructure:"MFA_ENCRYPTION_KEY"`
+	StepUpMaxAge              time.Duration `mapstructure:"STEP_UP_MAX_AGE"`
+	PasswordMinLength         int           `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMinCharClasses    int           `mapstructure:"PASSWORD_MIN_CHAR_CLASSES"`
+	PasswordHashAlgorithm     string        `mapstructure:"PASSWORD_HASH_ALGORITHM"`
+	PasswordBcryptCost        int           `mapstructure:"PASSWORD_BCRYPT_COST"`
+	PasswordArgon2Memory      uint32        `mapstructure:"PASSWORD_ARGON2_MEMORY"`
+	PasswordArgon2Iterations  uint32        `mapstructure:"PASSWORD_ARGON2_ITERATIONS"`
+	PasswordArgon2Parallelism uint8         `mapstructure:"PASSWORD_ARGON2_PARALLELISM"`
+	HoldDuration              time.Duration `mapstructure:"HOLD_DURATION"`
+	HoldSweepInterval         time.Duration `mapstructure:"HOLD_SWEEP_INTERVAL"`
+	PaymentRequestDuration    time.Duration `mapstructure:"PAYMENT_REQUEST_DURATION"`
+	WebhookDispatchInterval   time.Duration `mapstructure:"WEBHOOK_DISPATCH_INTERVAL"`
+	WebhookTimeout            time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+	OutboxPublisher           string        `mapstructure:"OUTBOX_PUBLISHER"`
+	OutboxFile                string        `mapstructure:"OUTBOX_FILE"`
+	OutboxRelayInterval       time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"`
+	AuditSealInterval         time.Duration `mapstructure:"AUDIT_SEAL_INTERVAL"`
+	NATSURL                   string        `mapstructure:"NATS_URL"`
+	NATSStream                string        `mapstructure:"NATS_STREAM"`
+	NATSSubject               string        `mapstructure:"NATS_SUBJECT"`
+	TrustedProxies            []string      `mapstructure:"TRUSTED_PROXIES"`
+	LoginIPLimit              int           `mapstructure:"LOGIN_IP_LIMIT"`
+	LoginUsernameLimit        int           `mapstructure:"LOGIN_USERNAME_LIMIT"`
+	LoginLimitWindow          time.Duration `mapstructure:"LOGIN_LIMIT_WINDOW"`
+	LoginLockoutThreshold     int32         `mapstructure:"LOGIN_LOCKOUT_THRESHOLD"`
+	LoginLockoutDuration      time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	LoginLockoutMaxDuration   time.Duration `mapstructure:"LOGIN_LOCKOUT_MAX_DURATION"`
+	AppBaseURL                string        `mapstructure:"APP_BASE_URL"`
+	VerifyEmailDuration       time.Duration `mapstructure:"VERIFY_EMAIL_DURATION"`
+	VerifyEmailResendLimit    int           `mapstructure:"VERIFY_EMAIL_RESEND_LIMIT"`
+	VerifyEmailResendWindow   time.Duration `mapstructure:"VERIFY_EMAIL_RESEND_WINDOW"`
+	PasswordResetDuration     time.Duration `mapstructure:"PASSWORD_RESET_DURATION"`
+	PasswordResetIPLimit      int           `mapstructure:"PASSWORD_RESET_IP_LIMIT"`
+	PasswordResetEmailLimit   int           `mapstructure:"PASSWORD_RESET_EMAIL_LIMIT"`
+	PasswordResetLimitWindow  time.Duration `mapstructure:"PASSWORD_RESET_LIMIT_WINDOW"`
+	Mailer                    string        `mapstructure:"MAILER"`
+	MailFrom                  string        `mapstructure:"MAIL_FROM"`
+	MailDir                   string        `mapstructure:"MAIL_DIR"`
+	SMTPHost                  string        `mapstructure:"SMTP_HOST"`
+	SMTPPort                  int           `mapstructure:"SMTP_PORT"`
+	SMTPUsername              string        `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword              string        `mapstructure:"SMTP_PASSWORD"`
+	TaskConcurrency           int           `mapstructure:"TASK_CONCURRENCY"`
+	TaskPollInterval          time.Duration `mapstructure:"TASK_POLL_INTERVAL"`
+	TaskLease                 time.Duration `mapstructure:"TASK_LEASE"`
+	HealthCheckInterval       time.Duration `mapstructure:"HEALTH_CHECK_INTERVAL"`
+	ShutdownDrainDelay        time.Duration `mapstructure:"SHUTDOWN_DRAIN_DELAY"`
+	ShutdownTimeout           time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	TracingExporter           string        `mapstructure:"TRACING_EXPORTER"`
+	TracingOTLPEndpoint       string        `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TracingOTLPInsecure       bool          `mapstructure:"TRACING_OTLP_INSECURE"`
+	TracingSampleRatio        float64       `mapstructure:"TRACING_SAMPLE_RATIO"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -0,0 +1,11 @@
+package util
+
+const basisPoints = 10000
+
+func CalculateFee(amount, flatFee, percentBps, maxFee int64) int64 {
+	fee := flatFee + amount*percentBps/basisPoints
+	if maxFee > 0 && fee > maxFee {
+		return maxFee
+	}
+	return fee
+}
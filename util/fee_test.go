@@ -0,0 +1,32 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateFee(t *testing.T) {
+	testCases := []struct {
+		name       string
+		amount     int64
+		flatFee    int64
+		percentBps int64
+		maxFee     int64
+		fee        int64
+	}{
+		{name: "NoFee", amount: 1000},
+		{name: "FlatOnly", amount: 1000, flatFee: 25, fee: 25},
+		{name: "PercentOnly", amount: 1000, percentBps: 150, fee: 15},
+		{name: "FlatAndPercent", amount: 1000, flatFee: 25, percentBps: 150, fee: 40},
+		{name: "Capped", amount: 100000, flatFee: 25, percentBps: 150, maxFee: 500, fee: 500},
+		{name: "BelowCap", amount: 1000, flatFee: 25, percentBps: 150, maxFee: 500, fee: 40},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fee := CalculateFee(tc.amount, tc.flatFee, tc.percentBps, tc.maxFee)
+			require.Equal(t, tc.fee, fee)
+		})
+	}
+}
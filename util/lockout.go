@@ -0,0 +1,24 @@
+package util
+
+import "time"
+
+// LockoutDuration returns how long an account stays locked after failures
+// consecutive failed logins. Nothing happens below threshold; from there the
+// lock starts at base and doubles with every further failure, up to max.
+func LockoutDuration(failures, threshold int32, base, max time.Duration) time.Duration {
+	if threshold <= 0 || failures < threshold || base <= 0 {
+		return 0
+	}
+
+	d := base
+	for i := threshold; i < failures; i++ {
+		d *= 2
+		if max > 0 && d >= max {
+			return max
+		}
+	}
+	if max > 0 && d > max {
+		return max
+	}
+	return d
+}
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	testCases := []struct {
+		name      string
+		failures  int32
+		threshold int32
+		want      time.Duration
+	}{
+		{name: "BelowThreshold", failures: 4, threshold: 5, want: 0},
+		{name: "AtThreshold", failures: 5, threshold: 5, want: time.Minute},
+		{name: "Doubles", failures: 7, threshold: 5, want: 4 * time.Minute},
+		{name: "Capped", failures: 50, threshold: 5, want: time.Hour},
+		{name: "Disabled", failures: 50, threshold: 0, want: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := LockoutDuration(tc.failures, tc.threshold, time.Minute, time.Hour)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
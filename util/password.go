@@ -1,20 +1,121 @@
 package util
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("unable to hash password: %v", err)
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// ErrPasswordMismatch is returned for a wrong password whatever algorithm
+// produced the hash.
+var ErrPasswordMismatch = bcrypt.ErrMismatchedHashAndPassword
+
+// PasswordHasher hashes new passwords with the configured algorithm. Zero
+// fields fall back to defaults, so the zero value hashes with bcrypt at
+// bcrypt.DefaultCost.
+type PasswordHasher struct {
+	Algorithm         string
+	BcryptCost        int
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+}
+
+func NewPasswordHasher(config Config) PasswordHasher {
+	return PasswordHasher{
+		Algorithm:         config.PasswordHashAlgorithm,
+		BcryptCost:        config.PasswordBcryptCost,
+		Argon2Memory:      config.PasswordArgon2Memory,
+		Argon2Iterations:  config.PasswordArgon2Iterations,
+		Argon2Parallelism: config.PasswordArgon2Parallelism,
+	}
+}
+
+func (h PasswordHasher) withDefaults() PasswordHasher {
+	if h.Algorithm == "" {
+		h.Algorithm = HashBcrypt
+	}
+	if h.BcryptCost == 0 {
+		h.BcryptCost = bcrypt.DefaultCost
+	}
+	if h.Argon2Memory == 0 {
+		h.Argon2Memory = defaultArgon2.memory
+	}
+	if h.Argon2Iterations == 0 {
+		h.Argon2Iterations = defaultArgon2.iterations
+	}
+	if h.Argon2Parallelism == 0 {
+		h.Argon2Parallelism = defaultArgon2.parallelism
+	}
+	return h
+}
+
+func (h PasswordHasher) Hash(password string) (string, error) {
+	h = h.withDefaults()
+
+	switch h.Algorithm {
+	case HashBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("unable to hash password: %v", err)
+		}
+		return string(hash), nil
+	case HashArgon2id:
+		return hashArgon2id(password, h.argon2Params())
+	}
+	return "", fmt.Errorf("unknown password hash algorithm %q", h.Algorithm)
+}
+
+// NeedsRehash reports whether hash was made with another algorithm or other
+// parameters than h would use now.
+func (h PasswordHasher) NeedsRehash(hash string) bool {
+	h = h.withDefaults()
+
+	switch h.Algorithm {
+	case HashBcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.BcryptCost
+	case HashArgon2id:
+		params, _, _, err := decodeArgon2id(hash)
+		return err != nil || params != h.argon2Params()
 	}
-	return string(hash), nil
+	return false
 }
 
+func (h PasswordHasher) argon2Params() argon2Params {
+	return argon2Params{
+		memory:      h.Argon2Memory,
+		iterations:  h.Argon2Iterations,
+		parallelism: h.Argon2Parallelism,
+	}
+}
+
+// HashPassword hashes with the default parameters.
+func HashPassword(password string) (string, error) {
+	return PasswordHasher{}.Hash(password)
+}
+
+// CompareHashAndPassword checks a password against a bcrypt or Argon2id
+// hash, telling them apart by prefix.
 func CompareHashAndPassword(hash, password string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		return compareArgon2id(hash, password)
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+// HashToken returns the SHA-256 of a random, single-use token. Unlike
+// passwords such tokens carry enough entropy to be stored with a fast hash,
+// which keeps them searchable.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,97 @@
+package util
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxPasswordLength is bcrypt's limit; longer inputs would be truncated.
+const maxPasswordLength = 72
+
+const defaultPasswordMinLength = 8
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var commonPasswords = loadCommonPasswords(commonPasswordsFile)
+
+func loadCommonPasswords(file string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(file))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
+
+// PasswordPolicy decides which new passwords are acceptable. It is not
+// applied at login, so older passwords keep working.
+type PasswordPolicy struct {
+	// MinLength defaults to 8 when zero.
+	MinLength int
+	// MinCharClasses is how many of lower case, upper case, digits and
+	// symbols must appear. Zero disables the rule.
+	MinCharClasses int
+}
+
+func NewPasswordPolicy(config Config) PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:      config.PasswordMinLength,
+		MinCharClasses: config.PasswordMinCharClasses,
+	}
+}
+
+func (p PasswordPolicy) Validate(password string) error {
+	minLength := p.MinLength
+	if minLength <= 0 {
+		minLength = defaultPasswordMinLength
+	}
+
+	if n := len([]rune(password)); n < minLength {
+		return fmt.Errorf("password must be at least %d characters long", minLength)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Errorf("password must be at most %d bytes long", maxPasswordLength)
+	}
+
+	if p.MinCharClasses > 0 && charClasses(password) < p.MinCharClasses {
+		return fmt.Errorf("password must mix at least %d of lower case, upper case, digits and symbols", p.MinCharClasses)
+	}
+
+	if _, ok := commonPasswords[strings.ToLower(password)]; ok {
+		return fmt.Errorf("password is too common")
+	}
+
+	return nil
+}
+
+func charClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	n := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
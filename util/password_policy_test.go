@@ -0,0 +1,54 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 10, MinCharClasses: 3}
+
+	testCases := []struct {
+		name     string
+		password string
+		errMsg   string
+	}{
+		{name: "OK", password: "Correct-Horse7"},
+		{name: "Unicode", password: "Pässwörter-99"},
+		{name: "TooShort", password: "Ab1-", errMsg: "at least 10 characters"},
+		{name: "TooLong", password: strings.Repeat("Ab1-", 19), errMsg: "at most 72 bytes"},
+		{name: "TooFewClasses", password: "alllowercase99", errMsg: "at least 3 of"},
+		{name: "Common", password: "Password123", errMsg: "too common"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Validate(tc.password)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+}
+
+func TestPasswordPolicyDefaults(t *testing.T) {
+	var policy PasswordPolicy
+
+	require.ErrorContains(t, policy.Validate("abc1234"), "at least 8 characters")
+	require.NoError(t, policy.Validate("lowercaseonly"))
+	require.ErrorContains(t, policy.Validate("Qwerty123"), "too common")
+}
+
+func TestCommonPasswordsLoaded(t *testing.T) {
+	require.NotEmpty(t, commonPasswords)
+	for password := range commonPasswords {
+		require.Equal(t, strings.ToLower(password), password)
+		require.False(t, strings.HasPrefix(password, "#"))
+	}
+}
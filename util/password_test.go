@@ -25,3 +25,58 @@ func TestHashPassword(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEqual(t, hashed1, hashed2)
 }
+
+func TestHashToken(t *testing.T) {
+	token, err := RandomSecret(32)
+	require.NoError(t, err)
+
+	hashed := HashToken(token)
+	require.Len(t, hashed, 64)
+	require.NotEqual(t, token, hashed)
+	require.Equal(t, hashed, HashToken(token))
+	require.NotEqual(t, hashed, HashToken(token+"x"))
+}
+
+func TestPasswordHasherArgon2id(t *testing.T) {
+	hasher := PasswordHasher{Algorithm: HashArgon2id, Argon2Memory: 8 * 1024, Argon2Iterations: 1}
+	password := RandomString(12)
+
+	hashed, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.Contains(t, hashed, "$argon2id$v=19$m=8192,t=1,p=2$")
+
+	require.NoError(t, CompareHashAndPassword(hashed, password))
+	require.ErrorIs(t, CompareHashAndPassword(hashed, password+"x"), ErrPasswordMismatch)
+	require.Error(t, CompareHashAndPassword("$argon2id$garbage", password))
+}
+
+func TestPasswordHasherBcryptCost(t *testing.T) {
+	hasher := NewPasswordHasher(Config{PasswordBcryptCost: bcrypt.MinCost})
+
+	hashed, err := hasher.Hash(RandomString(12))
+	require.NoError(t, err)
+
+	cost, err := bcrypt.Cost([]byte(hashed))
+	require.NoError(t, err)
+	require.Equal(t, bcrypt.MinCost, cost)
+}
+
+func TestNeedsRehash(t *testing.T) {
+	password := RandomString(12)
+	bcryptHasher := PasswordHasher{BcryptCost: bcrypt.MinCost}
+	argon2Hasher := PasswordHasher{Algorithm: HashArgon2id, Argon2Memory: 8 * 1024, Argon2Iterations: 1}
+
+	bcryptHash, err := bcryptHasher.Hash(password)
+	require.NoError(t, err)
+	argon2Hash, err := argon2Hasher.Hash(password)
+	require.NoError(t, err)
+
+	require.False(t, bcryptHasher.NeedsRehash(bcryptHash))
+	require.True(t, PasswordHasher{BcryptCost: bcrypt.MinCost + 1}.NeedsRehash(bcryptHash))
+	require.True(t, bcryptHasher.NeedsRehash(argon2Hash))
+
+	require.False(t, argon2Hasher.NeedsRehash(argon2Hash))
+	require.True(t, argon2Hasher.NeedsRehash(bcryptHash))
+	argon2Hasher.Argon2Iterations = 2
+	require.True(t, argon2Hasher.NeedsRehash(argon2Hash))
+}
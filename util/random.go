@@ -1,6 +1,8 @@
 package util
 
 import (
+	crand "crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -46,3 +48,17 @@ func RandomCurrency() string {
 	n := len(currencies)
 	return currencies[rand.Intn(n)]
 }
+
+// RandomSecret returns n bytes from crypto/rand, hex encoded. Use it for
+// anything an attacker must not guess.
+func RandomSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func RandomAccountNumber() string {
+	return AccountNumber(rand.Int63n(10_000_000_000))
+}
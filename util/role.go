@@ -0,0 +1,7 @@
+package util
+
+const (
+	DepositorRole = "depositor"
+	// BankerRole may read the audit log.
+	BankerRole = "banker"
+)
@@ -0,0 +1,190 @@
+// Package watch pushes committed account updates to the clients watching
+// those accounts.
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	db "simplebank/db/sqlc"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// subscriptionBuffer is how many updates a watcher may fall behind by
+// before its oldest ones are discarded.
+const subscriptionBuffer = 32
+
+const (
+	listenRetryDelay    = time.Second
+	listenMaxRetryDelay = time.Minute
+)
+
+var ErrHubClosed = errors.New("server is shutting down")
+
+// Hub fans account updates out to the subscriptions of each account.
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[int64]map[*Subscription]struct{}
+	closed bool
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[int64]map[*Subscription]struct{})}
+}
+
+// Subscription receives the updates of one account on C. C is closed when
+// the subscription ends; Err then tells whether the hub ended it.
+type Subscription struct {
+	C <-chan db.AccountUpdate
+
+	c         chan db.AccountUpdate
+	hub       *Hub
+	accountID int64
+	err       error
+}
+
+func (h *Hub) Subscribe(accountID int64) *Subscription {
+	c := make(chan db.AccountUpdate, subscriptionBuffer)
+	sub := &Subscription{C: c, c: c, hub: h, accountID: accountID}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		sub.err = ErrHubClosed
+		close(c)
+		return sub
+	}
+	if h.subs[accountID] == nil {
+		h.subs[accountID] = make(map[*Subscription]struct{})
+	}
+	h.subs[accountID][sub] = struct{}{}
+	return sub
+}
+
+// Close ends the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s, nil)
+}
+
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+// remove must be called with h.mu held.
+func (h *Hub) remove(sub *Subscription, err error) {
+	subs := h.subs[sub.accountID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.accountID)
+	}
+	sub.err = err
+	close(sub.c)
+}
+
+// Close ends every subscription with ErrHubClosed and refuses new ones, so
+// that long-lived streams let the servers shut down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			h.remove(sub, ErrHubClosed)
+		}
+	}
+}
+
+// Broadcast hands update to every subscription of its account. A
+// subscription that has no room left loses its oldest update rather than
+// blocking the others. Every update carries the whole account, so a watcher
+// that falls behind, such as the sender of a large bulk transfer, misses
+// entries but still ends up with the current balances.
+func (h *Hub) Broadcast(update db.AccountUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs[update.Account.ID] {
+		for {
+			select {
+			case sub.c <- update:
+			default:
+				// Only the watcher receives from c, so if it took the
+				// oldest update first there is room on the next try.
+				select {
+				case <-sub.c:
+				default:
+				}
+				continue
+			}
+			break
+		}
+	}
+}
+
+// Listen broadcasts the updates announced on db.AccountUpdatesChannel until
+// ctx is cancelled. It holds one connection of pool for as long as it runs
+// and reconnects when it loses it. Updates committed while it is
+// reconnecting are not delivered.
+func (h *Hub) Listen(ctx context.Context, pool *pgxpool.Pool) {
+	delay := listenRetryDelay
+	for {
+		err := h.listen(ctx, pool, func() { delay = listenRetryDelay })
+		if ctx.Err() != nil {
+			return
+		}
+		log.Error().Err(err).Dur("retry_in", delay).Msg("lost account update listener")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > listenMaxRetryDelay {
+			delay = listenMaxRetryDelay
+		}
+	}
+}
+
+func (h *Hub) listen(ctx context.Context, pool *pgxpool.Pool, connected func()) error {
+	pooled, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection stays subscribed to the channel, so it must never go
+	// back to the pool.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+db.AccountUpdatesChannel); err != nil {
+		return err
+	}
+	connected()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var update db.AccountUpdate
+		if err := json.Unmarshal([]byte(notification.Payload), &update); err != nil {
+			log.Error().Err(err).Msg("cannot unmarshal account update")
+			continue
+		}
+		h.Broadcast(update)
+	}
+}
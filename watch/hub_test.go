@@ -0,0 +1,97 @@
+package watch
+
+import (
+	"testing"
+
+	db "simplebank/db/sqlc"
+
+	"github.com/stretchr/testify/require"
+)
+
+func update(accountID, balance int64) db.AccountUpdate {
+	return db.AccountUpdate{
+		Account: db.Account{ID: accountID, Balance: balance},
+		Entry:   &db.Entry{AccountID: accountID, Amount: balance},
+	}
+}
+
+func TestHubBroadcast(t *testing.T) {
+	hub := NewHub()
+	sub1 := hub.Subscribe(1)
+	sub2 := hub.Subscribe(1)
+	other := hub.Subscribe(2)
+	defer sub1.Close()
+	defer sub2.Close()
+	defer other.Close()
+
+	hub.Broadcast(update(1, 10))
+	hub.Broadcast(update(1, 20))
+
+	for _, sub := range []*Subscription{sub1, sub2} {
+		require.Equal(t, int64(10), (<-sub.C).Account.Balance)
+		require.Equal(t, int64(20), (<-sub.C).Account.Balance)
+	}
+	require.Empty(t, other.C)
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(1)
+
+	sub.Close()
+	sub.Close()
+
+	_, ok := <-sub.C
+	require.False(t, ok)
+	require.NoError(t, sub.Err())
+	require.Empty(t, hub.subs)
+
+	// Broadcasting to an account nobody watches any more is a no-op.
+	hub.Broadcast(update(1, 10))
+}
+
+func TestHubDiscardsStaleUpdates(t *testing.T) {
+	hub := NewHub()
+	slow := hub.Subscribe(1)
+	fast := hub.Subscribe(1)
+	defer slow.Close()
+	defer fast.Close()
+
+	// More updates than the buffer holds, as a large bulk transfer posts to
+	// its sender.
+	n := 10 * subscriptionBuffer
+	for i := 1; i <= n; i++ {
+		hub.Broadcast(update(1, int64(i)))
+		require.Equal(t, int64(i), (<-fast.C).Account.Balance)
+	}
+
+	// The slow watcher keeps its subscription and the newest updates.
+	require.Len(t, slow.C, subscriptionBuffer)
+	var last int64
+	for i := 0; i < subscriptionBuffer; i++ {
+		u := <-slow.C
+		require.Greater(t, u.Account.Balance, last)
+		last = u.Account.Balance
+	}
+	require.Equal(t, int64(n), last)
+	require.NoError(t, slow.Err())
+
+	hub.Broadcast(update(1, 1000))
+	require.Equal(t, int64(1000), (<-slow.C).Account.Balance)
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(1)
+
+	hub.Close()
+	_, ok := <-sub.C
+	require.False(t, ok)
+	require.ErrorIs(t, sub.Err(), ErrHubClosed)
+
+	late := hub.Subscribe(1)
+	_, ok = <-late.C
+	require.False(t, ok)
+	require.ErrorIs(t, late.Err(), ErrHubClosed)
+	late.Close()
+}
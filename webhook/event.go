@@ -0,0 +1,15 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event is the JSON body of every webhook request.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	AccountID int64           `json:"account_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
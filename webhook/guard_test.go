@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicIP(t *testing.T) {
+	testCases := []struct {
+		ip     string
+		public bool
+	}{
+		{ip: "93.184.216.34", public: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", public: true},
+		{ip: "127.0.0.1"},
+		{ip: "::1"},
+		{ip: "10.1.2.3"},
+		{ip: "172.16.0.1"},
+		{ip: "192.168.1.1"},
+		{ip: "169.254.169.254"},
+		{ip: "fe80::1"},
+		{ip: "fd00::1"},
+		{ip: "0.0.0.0"},
+		{ip: "100.64.0.1"},
+		{ip: "224.0.0.1"},
+		{ip: "::ffff:127.0.0.1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ip, func(t *testing.T) {
+			require.Equal(t, tc.public, PublicIP(net.ParseIP(tc.ip)))
+		})
+	}
+}
+
+type stubResolver map[string]string
+
+func (r stubResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ip, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return []net.IPAddr{{IP: net.ParseIP(ip)}}, nil
+}
+
+func TestCheckURL(t *testing.T) {
+	resolver := stubResolver{
+		"example.com":          "93.184.216.34",
+		"localhost":            "127.0.0.1",
+		"internal.example.com": "10.0.0.5",
+	}
+
+	testCases := []struct {
+		url string
+		err error
+	}{
+		{url: "https://example.com/hooks"},
+		{url: "https://93.184.216.34/hooks"},
+		{url: "http://example.com/hooks", err: ErrInsecureURL},
+		{url: "https://localhost/hooks", err: ErrForbiddenHost},
+		{url: "https://internal.example.com/hooks", err: ErrForbiddenHost},
+		{url: "https://169.254.169.254/latest/meta-data", err: ErrForbiddenHost},
+		{url: "https://[::1]:8443/hooks", err: ErrForbiddenHost},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			err := CheckURL(context.Background(), resolver, tc.url)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNewClientRefusesPrivateHosts(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback server")
+	}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second).Post(server.URL, "application/json", nil)
+	require.ErrorIs(t, err, ErrForbiddenHost)
+}
@@ -0,0 +1,76 @@
+// Package webhook signs the events delivered to partner endpoints, lets
+// receivers check them, and keeps deliveries away from internal hosts.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader = "Simplebank-Signature"
+	EventHeader     = "Simplebank-Event"
+	DeliveryHeader  = "Simplebank-Delivery"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredSignature = errors.New("webhook signature is too old")
+)
+
+// Sign returns the signature header value for body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Covering the
+// timestamp lets receivers reject replayed requests.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac(secret, t, body)))
+}
+
+// Verify checks a signature header against body and rejects it when it
+// was made more than tolerance before now.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var t, v1 string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return ErrInvalidSignature
+		}
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			v1 = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	got, err := hex.DecodeString(v1)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal(got, mac(secret, t, body)) {
+		return ErrInvalidSignature
+	}
+	if now.Sub(time.Unix(unix, 0)) > tolerance {
+		return ErrExpiredSignature
+	}
+	return nil
+}
+
+func mac(secret, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
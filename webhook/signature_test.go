@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := util.RandomString(32)
+	body := []byte(`{"id":1,"type":"account.credited"}`)
+	now := time.Now()
+
+	header := Sign(secret, now, body)
+	require.Regexp(t, `^t=\d+,v1=[0-9a-f]{64}$`, header)
+
+	testCases := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		err    error
+	}{
+		{name: "OK", secret: secret, header: header, body: body, now: now},
+		{name: "WrongSecret", secret: util.RandomString(32), header: header, body: body, now: now, err: ErrInvalidSignature},
+		{name: "TamperedBody", secret: secret, header: header, body: []byte(`{"id":2}`), now: now, err: ErrInvalidSignature},
+		{name: "Expired", secret: secret, header: header, body: body, now: now.Add(10 * time.Minute), err: ErrExpiredSignature},
+		{name: "Malformed", secret: secret, header: "v1", body: body, now: now, err: ErrInvalidSignature},
+		{name: "MissingTimestamp", secret: secret, header: header[len("t=1234567890,"):], body: body, now: now, err: ErrInvalidSignature},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.header, tc.body, 5*time.Minute, tc.now)
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	db "simplebank/db/sqlc"
+
+	"github.com/rs/zerolog/log"
+)
+
+const auditSealBatchSize = 100
+
+// AuditSealer chains the audit events queued by money transactions into the
+// audit log, so that those transactions never wait on the chain's lock.
+type AuditSealer struct {
+	store    db.Store
+	interval time.Duration
+}
+
+func NewAuditSealer(store db.Store, interval time.Duration) *AuditSealer {
+	return &AuditSealer{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Start seals queued events every interval until ctx is cancelled.
+func (s *AuditSealer) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.Seal(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Error().Err(err).Msg("cannot seal audit events")
+			}
+			if n > 0 {
+				log.Info().Int("count", n).Msg("sealed audit events")
+			}
+		}
+	}
+}
+
+// Seal chains queued events in batches until none are left.
+func (s *AuditSealer) Seal(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := s.store.SealAuditLogTx(ctx, auditSealBatchSize)
+		total += n
+		if err != nil || n < auditSealBatchSize {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditSealerSeal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			SealAuditLogTx(gomock.Any(), gomock.Eq(int32(auditSealBatchSize))).
+			Times(1).
+			Return(auditSealBatchSize, nil),
+		store.EXPECT().
+			SealAuditLogTx(gomock.Any(), gomock.Eq(int32(auditSealBatchSize))).
+			Times(1).
+			Return(3, nil),
+	)
+
+	sealer := NewAuditSealer(store, time.Minute)
+	n, err := sealer.Seal(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, auditSealBatchSize+3, n)
+}
+
+func TestAuditSealerSealError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		SealAuditLogTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(0, sql.ErrConnDone)
+
+	sealer := NewAuditSealer(store, time.Minute)
+	_, err := sealer.Seal(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
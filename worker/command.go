@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	db "simplebank/db/sqlc"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const commandUsage = `usage:
+  simplebank tasks stats
+  simplebank tasks list QUEUE STATUS [LIMIT]
+  simplebank tasks retry ID`
+
+// RunCommand executes the tasks subcommand described by args and writes its
+// report to w.
+func RunCommand(ctx context.Context, store db.Store, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(commandUsage)
+	}
+
+	switch args[0] {
+	case "stats":
+		if len(args) != 1 {
+			return errors.New(commandUsage)
+		}
+		return printStats(ctx, store, w)
+
+	case "list":
+		if len(args) < 3 || len(args) > 4 {
+			return errors.New(commandUsage)
+		}
+		limit := 20
+		if len(args) == 4 {
+			n, err := strconv.Atoi(args[3])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid limit %q\n%s", args[3], commandUsage)
+			}
+			limit = n
+		}
+		return printTasks(ctx, store, args[1], args[2], int32(limit), w)
+
+	case "retry":
+		if len(args) != 2 {
+			return errors.New(commandUsage)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid task id %q\n%s", args[1], commandUsage)
+		}
+		task, err := store.RequeueDeadTask(ctx, id)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				return fmt.Errorf("task %d is not dead", id)
+			}
+			return err
+		}
+		fmt.Fprintf(w, "task %d requeued on %s\n", task.ID, task.Queue)
+		return nil
+	}
+
+	return fmt.Errorf("unknown tasks command %q\n%s", args[0], commandUsage)
+}
+
+func printStats(ctx context.Context, store db.Store, w io.Writer) error {
+	counts, err := store.CountTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "QUEUE\tSTATUS\tCOUNT")
+	for _, c := range counts {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", c.Queue, c.Status, c.Count)
+	}
+	return tw.Flush()
+}
+
+func printTasks(ctx context.Context, store db.Store, queue, status string, limit int32, w io.Writer) error {
+	tasks, err := store.ListTasks(ctx, db.ListTasksParams{
+		Queue:  queue,
+		Status: status,
+		Limit:  limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTYPE\tATTEMPTS\tRUN AT\tLAST ERROR")
+	for _, t := range tasks {
+		fmt.Fprintf(tw, "%d\t%s\t%d/%d\t%s\t%s\n",
+			t.ID, t.Type, t.Attempts, t.MaxAttempts, t.RunAt.Format(time.RFC3339), t.LastError)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CountTasks(gomock.Any()).
+		Times(1).
+		Return([]db.CountTasksRow{{Queue: QueueDefault, Status: TaskStatusDead, Count: 2}}, nil)
+	store.EXPECT().
+		RequeueDeadTask(gomock.Any(), gomock.Eq(int64(9))).
+		Times(1).
+		Return(db.Task{ID: 9, Queue: QueueDefault}, nil)
+	store.EXPECT().
+		RequeueDeadTask(gomock.Any(), gomock.Eq(int64(10))).
+		Times(1).
+		Return(db.Task{}, db.ErrRecordNotFound)
+
+	var out bytes.Buffer
+	require.NoError(t, RunCommand(context.Background(), store, []string{"stats"}, &out))
+	require.Contains(t, out.String(), "default  dead    2")
+
+	out.Reset()
+	require.NoError(t, RunCommand(context.Background(), store, []string{"retry", "9"}, &out))
+	require.Equal(t, "task 9 requeued on default\n", out.String())
+
+	require.EqualError(t, RunCommand(context.Background(), store, []string{"retry", "10"}, &out), "task 10 is not dead")
+	require.Error(t, RunCommand(context.Background(), store, []string{"retry", "x"}, &out))
+	require.Error(t, RunCommand(context.Background(), store, []string{"list", "default"}, &out))
+	require.Error(t, RunCommand(context.Background(), store, nil, &out))
+}
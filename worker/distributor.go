@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"time"
+)
+
+type TaskDistributor interface {
+	// DistributeTaskSendVerifyEmail enqueues with q, which is usually bound
+	// to the caller's transaction. A nil q uses the distributor's own.
+	DistributeTaskSendVerifyEmail(ctx context.Context, q db.Querier, payload *PayloadSendVerifyEmail, opts ...Option) error
+	DistributeTaskSendPasswordReset(ctx context.Context, q db.Querier, payload *PayloadSendPasswordReset, opts ...Option) error
+	DistributeTaskDeliverWebhook(ctx context.Context, q db.Querier, payload *PayloadDeliverWebhook, opts ...Option) error
+}
+
+// PGTaskDistributor stores tasks in the tasks table, where TaskProcessor
+// picks them up.
+type PGTaskDistributor struct {
+	querier db.Querier
+}
+
+func NewTaskDistributor(querier db.Querier) TaskDistributor {
+	return &PGTaskDistributor{querier: querier}
+}
+
+func (d *PGTaskDistributor) distribute(
+	ctx context.Context,
+	q db.Querier,
+	taskType string,
+	payload interface{},
+	opts ...Option,
+) (db.Task, error) {
+	options := taskOptions{
+		queue:       QueueDefault,
+		maxAttempts: defaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return db.Task{}, fmt.Errorf("cannot marshal task payload: %w", err)
+	}
+
+	if q == nil {
+		q = d.querier
+	}
+	task, err := q.CreateTask(ctx, db.CreateTaskParams{
+		Queue:       options.queue,
+		Type:        taskType,
+		Payload:     data,
+		MaxAttempts: options.maxAttempts,
+		RunAt:       time.Now().Add(options.delay),
+	})
+	if err != nil {
+		return db.Task{}, fmt.Errorf("cannot enqueue task: %w", err)
+	}
+	return task, nil
+}
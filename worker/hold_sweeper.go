@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	db "simplebank/db/sqlc"
+
+	"github.com/rs/zerolog/log"
+)
+
+const holdSweepBatchSize = 100
+
+type HoldSweeper struct {
+	store    db.Store
+	interval time.Duration
+}
+
+func NewHoldSweeper(store db.Store, interval time.Duration) *HoldSweeper {
+	return &HoldSweeper{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Start releases expired holds every interval until ctx is cancelled.
+func (s *HoldSweeper) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.Sweep(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("cannot expire holds")
+			}
+			if n > 0 {
+				log.Info().Int("count", n).Msg("expired holds")
+			}
+		}
+	}
+}
+
+// Sweep expires holds in batches until none are left.
+func (s *HoldSweeper) Sweep(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := s.store.ExpireHoldsTx(ctx, holdSweepBatchSize)
+		total += n
+		if err != nil || n < holdSweepBatchSize {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoldSweeperSweep(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			ExpireHoldsTx(gomock.Any(), gomock.Eq(int32(holdSweepBatchSize))).
+			Times(1).
+			Return(holdSweepBatchSize, nil),
+		store.EXPECT().
+			ExpireHoldsTx(gomock.Any(), gomock.Eq(int32(holdSweepBatchSize))).
+			Times(1).
+			Return(3, nil),
+	)
+
+	sweeper := NewHoldSweeper(store, time.Minute)
+	n, err := sweeper.Sweep(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, holdSweepBatchSize+3, n)
+}
+
+func TestHoldSweeperSweepError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ExpireHoldsTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(0, sql.ErrConnDone)
+
+	sweeper := NewHoldSweeper(store, time.Minute)
+	_, err := sweeper.Sweep(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: simplebank/worker (interfaces: TaskDistributor)
+
+// Package mockwk is a generated GoMock package.
+package mockwk
+
+import (
+	context "context"
+	reflect "reflect"
+	db "simplebank/db/sqlc"
+	worker "simplebank/worker"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTaskDistributor is a mock of TaskDistributor interface.
+type MockTaskDistributor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaskDistributorMockRecorder
+}
+
+// MockTaskDistributorMockRecorder is the mock recorder for MockTaskDistributor.
+type MockTaskDistributorMockRecorder struct {
+	mock *MockTaskDistributor
+}
+
+// NewMockTaskDistributor creates a new mock instance.
+func NewMockTaskDistributor(ctrl *gomock.Controller) *MockTaskDistributor {
+	mock := &MockTaskDistributor{ctrl: ctrl}
+	mock.recorder = &MockTaskDistributorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTaskDistributor) EXPECT() *MockTaskDistributorMockRecorder {
+	return m.recorder
+}
+
+// DistributeTaskDeliverWebhook mocks base method.
+func (m *MockTaskDistributor) DistributeTaskDeliverWebhook(arg0 context.Context, arg1 db.Querier, arg2 *worker.PayloadDeliverWebhook, arg3 ...worker.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskDeliverWebhook", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskDeliverWebhook indicates an expected call of DistributeTaskDeliverWebhook.
+func (mr *MockTaskDistributorMockRecorder) DistributeTaskDeliverWebhook(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskDeliverWebhook", reflect.TypeOf((*MockTaskDistributor)(nil).DistributeTaskDeliverWebhook), varargs...)
+}
+
+// DistributeTaskSendPasswordReset mocks base method.
+func (m *MockTaskDistributor) DistributeTaskSendPasswordReset(arg0 context.Context, arg1 db.Querier, arg2 *worker.PayloadSendPasswordReset, arg3 ...worker.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskSendPasswordReset", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskSendPasswordReset indicates an expected call of DistributeTaskSendPasswordReset.
+func (mr *MockTaskDistributorMockRecorder) DistributeTaskSendPasswordReset(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskSendPasswordReset", reflect.TypeOf((*MockTaskDistributor)(nil).DistributeTaskSendPasswordReset), varargs...)
+}
+
+// DistributeTaskSendVerifyEmail mocks base method.
+func (m *MockTaskDistributor) DistributeTaskSendVerifyEmail(arg0 context.Context, arg1 db.Querier, arg2 *worker.PayloadSendVerifyEmail, arg3 ...worker.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskSendVerifyEmail", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskSendVerifyEmail indicates an expected call of DistributeTaskSendVerifyEmail.
+func (mr *MockTaskDistributorMockRecorder) DistributeTaskSendVerifyEmail(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskSendVerifyEmail", reflect.TypeOf((*MockTaskDistributor)(nil).DistributeTaskSendVerifyEmail), varargs...)
+}
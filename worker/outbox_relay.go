@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	db "simplebank/db/sqlc"
+	"simplebank/outbox"
+
+	"github.com/rs/zerolog/log"
+)
+
+const outboxRelayBatchSize = 100
+
+// OutboxRelay publishes the events written to the outbox table in id order,
+// which keeps every account's events in the order they happened.
+type OutboxRelay struct {
+	store     db.Store
+	publisher outbox.Publisher
+	interval  time.Duration
+}
+
+func NewOutboxRelay(store db.Store, publisher outbox.Publisher, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		store:     store,
+		publisher: publisher,
+		interval:  interval,
+	}
+}
+
+// Start relays new events every interval until ctx is cancelled.
+func (r *OutboxRelay) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := r.Relay(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Error().Err(err).Msg("cannot relay outbox events")
+			}
+			if n > 0 {
+				log.Info().Int("count", n).Msg("relayed outbox events")
+			}
+		}
+	}
+}
+
+// Relay publishes events in batches until none are left or one fails. A
+// failed event holds back the ones after it until it goes through.
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := r.store.RelayOutboxTx(ctx, db.RelayOutboxTxParams{
+			Limit: outboxRelayBatchSize,
+			Publish: func(event db.Outbox) error {
+				return r.publisher.Publish(ctx, outbox.Message{
+					ID:        event.ID,
+					AccountID: event.AccountID,
+					Type:      event.Type,
+					CreatedAt: event.CreatedAt,
+					Payload:   event.Payload,
+				})
+			},
+		})
+		total += n
+		if err != nil || n < outboxRelayBatchSize {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/outbox"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// relayStub feeds events to the relay's Publish callback the way
+// RelayOutboxTx does, stopping at the first error.
+func relayStub(events []db.Outbox) func(context.Context, db.RelayOutboxTxParams) (int, error) {
+	return func(_ context.Context, arg db.RelayOutboxTxParams) (int, error) {
+		for i, event := range events {
+			if err := arg.Publish(event); err != nil {
+				return i, err
+			}
+		}
+		return len(events), nil
+	}
+}
+
+func TestOutboxRelayRelay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	events := []db.Outbox{
+		{ID: 1, AccountID: 7, Type: db.EventAccountDebited, Payload: json.RawMessage(`{"amount":-10}`), CreatedAt: time.Now()},
+		{ID: 2, AccountID: 8, Type: db.EventAccountCredited, Payload: json.RawMessage(`{"amount":10}`), CreatedAt: time.Now()},
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		RelayOutboxTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(relayStub(events))
+
+	publisher := outbox.NewMemoryPublisher()
+	relay := NewOutboxRelay(store, publisher, time.Minute)
+
+	n, err := relay.Relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	messages := publisher.Messages()
+	require.Len(t, messages, 2)
+	for i, msg := range messages {
+		require.Equal(t, events[i].ID, msg.ID)
+		require.Equal(t, events[i].AccountID, msg.AccountID)
+		require.Equal(t, events[i].Type, msg.Type)
+		require.JSONEq(t, string(events[i].Payload), string(msg.Payload))
+	}
+}
+
+func TestOutboxRelayRelayBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			RelayOutboxTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(outboxRelayBatchSize, nil),
+		store.EXPECT().
+			RelayOutboxTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(1, nil),
+	)
+
+	relay := NewOutboxRelay(store, outbox.NewMemoryPublisher(), time.Minute)
+	n, err := relay.Relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, outboxRelayBatchSize+1, n)
+}
+
+type failingPublisher struct {
+	outbox.MemoryPublisher
+	failID int64
+}
+
+var errBrokerDown = errors.New("broker down")
+
+func (p *failingPublisher) Publish(ctx context.Context, msg outbox.Message) error {
+	if msg.ID == p.failID {
+		return errBrokerDown
+	}
+	return p.MemoryPublisher.Publish(ctx, msg)
+}
+
+func TestOutboxRelayPublishError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	events := []db.Outbox{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		RelayOutboxTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(relayStub(events))
+
+	publisher := &failingPublisher{failID: 2}
+	relay := NewOutboxRelay(store, publisher, time.Minute)
+
+	n, err := relay.Relay(context.Background())
+	require.ErrorIs(t, err, errBrokerDown)
+	require.Equal(t, 1, n)
+	require.Len(t, publisher.Messages(), 1)
+}
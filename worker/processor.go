@@ -0,0 +1,163 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/mail"
+	"simplebank/metrics"
+	"simplebank/util"
+	"simplebank/webhook"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type TaskHandler func(ctx context.Context, task db.Task) error
+
+// TaskProcessor claims tasks with SKIP LOCKED, so any number of processes
+// can share the queues. A claimed task is leased; when its worker dies the
+// lease runs out and the task is claimed again.
+type TaskProcessor struct {
+	store        db.Store
+	mailer       mail.Mailer
+	config       util.Config
+	handlers     map[string]TaskHandler
+	httpClient   *http.Client
+	concurrency  int
+	pollInterval time.Duration
+	lease        time.Duration
+}
+
+func NewTaskProcessor(store db.Store, mailer mail.Mailer, config util.Config) *TaskProcessor {
+	p := &TaskProcessor{
+		store:        store,
+		mailer:       mailer,
+		config:       config,
+		concurrency:  config.TaskConcurrency,
+		pollInterval: config.TaskPollInterval,
+		lease:        config.TaskLease,
+		httpClient:   webhook.NewClient(config.WebhookTimeout),
+	}
+	if p.concurrency <= 0 {
+		p.concurrency = 1
+	}
+	if p.pollInterval <= 0 {
+		p.pollInterval = time.Second
+	}
+	if p.lease <= 0 {
+		p.lease = 5 * time.Minute
+	}
+	if p.httpClient.Timeout <= 0 {
+		p.httpClient.Timeout = 10 * time.Second
+	}
+
+	p.handlers = map[string]TaskHandler{
+		TaskSendVerifyEmail:   p.ProcessTaskSendVerifyEmail,
+		TaskSendPasswordReset: p.ProcessTaskSendPasswordReset,
+		TaskDeliverWebhook:    p.ProcessTaskDeliverWebhook,
+	}
+	return p
+}
+
+// Start runs the workers until ctx is cancelled and they have finished
+// their current task.
+func (p *TaskProcessor) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.run(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *TaskProcessor) run(ctx context.Context) {
+	for {
+		processed, err := p.ProcessNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("cannot process task")
+		}
+		if processed && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+// ProcessNext runs one due task from the highest priority queue that has
+// one. It reports false when every queue is empty.
+func (p *TaskProcessor) ProcessNext(ctx context.Context) (bool, error) {
+	for _, queue := range queues {
+		tasks, err := p.store.ClaimTasks(ctx, db.ClaimTasksParams{
+			Queue:       queue,
+			LockedUntil: time.Now().Add(p.lease),
+			Limit:       1,
+		})
+		if err != nil {
+			return false, fmt.Errorf("cannot claim task: %w", err)
+		}
+		if len(tasks) > 0 {
+			return true, p.process(ctx, tasks[0])
+		}
+	}
+	return false, nil
+}
+
+func (p *TaskProcessor) process(ctx context.Context, task db.Task) error {
+	logger := log.With().
+		Int64("task_id", task.ID).
+		Str("type", task.Type).
+		Str("queue", task.Queue).
+		Int32("attempt", task.Attempts).
+		Logger()
+
+	handler, ok := p.handlers[task.Type]
+	if !ok {
+		return p.kill(ctx, task, fmt.Errorf("%w: unknown task type %q", ErrSkipRetry, task.Type))
+	}
+
+	taskCtx, cancel := context.WithTimeout(ctx, p.lease)
+	start := time.Now()
+	err := handler(taskCtx, task)
+	cancel()
+	metrics.TaskDuration.WithLabelValues(task.Type).Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		metrics.TasksProcessed.WithLabelValues(task.Type, metrics.TaskCompleted).Inc()
+		logger.Info().Msg("processed task")
+		return p.store.CompleteTask(ctx, task.ID)
+	}
+
+	if errors.Is(err, ErrSkipRetry) || task.Attempts >= task.MaxAttempts {
+		logger.Error().Err(err).Msg("task is dead")
+		return p.kill(ctx, task, err)
+	}
+
+	delay := taskRetryDelay(task.Attempts)
+	metrics.TasksProcessed.WithLabelValues(task.Type, metrics.TaskRetried).Inc()
+	logger.Warn().Err(err).Dur("retry_in", delay).Msg("task failed")
+	return p.store.RetryTask(ctx, db.RetryTaskParams{
+		ID:        task.ID,
+		RunAt:     time.Now().Add(delay),
+		LastError: err.Error(),
+	})
+}
+
+func (p *TaskProcessor) kill(ctx context.Context, task db.Task, err error) error {
+	metrics.TasksProcessed.WithLabelValues(task.Type, metrics.TaskDead).Inc()
+	return p.store.KillTask(ctx, db.KillTaskParams{
+		ID:        task.ID,
+		LastError: err.Error(),
+	})
+}
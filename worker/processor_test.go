@@ -0,0 +1,140 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProcessor(store db.Store, handler TaskHandler) *TaskProcessor {
+	p := NewTaskProcessor(store, nil, util.Config{})
+	p.handlers = map[string]TaskHandler{"test": handler}
+	return p
+}
+
+func TestProcessNext(t *testing.T) {
+	errHandler := errors.New("handler failed")
+
+	testCases := []struct {
+		name       string
+		task       db.Task
+		handlerErr error
+		buildStubs func(store *mockdb.MockStore, task db.Task)
+	}{
+		{
+			name: "Completed",
+			task: db.Task{ID: 1, Type: "test", Attempts: 1, MaxAttempts: 3},
+			buildStubs: func(store *mockdb.MockStore, task db.Task) {
+				store.EXPECT().CompleteTask(gomock.Any(), gomock.Eq(task.ID)).Times(1).Return(nil)
+			},
+		},
+		{
+			name:       "Retried",
+			task:       db.Task{ID: 2, Type: "test", Attempts: 1, MaxAttempts: 3},
+			handlerErr: errHandler,
+			buildStubs: func(store *mockdb.MockStore, task db.Task) {
+				store.EXPECT().
+					RetryTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RetryTaskParams) error {
+						require.Equal(t, task.ID, arg.ID)
+						require.Equal(t, errHandler.Error(), arg.LastError)
+						require.True(t, arg.RunAt.After(time.Now()))
+						return nil
+					})
+			},
+		},
+		{
+			name:       "AttemptsExhausted",
+			task:       db.Task{ID: 3, Type: "test", Attempts: 3, MaxAttempts: 3},
+			handlerErr: errHandler,
+			buildStubs: func(store *mockdb.MockStore, task db.Task) {
+				arg := db.KillTaskParams{ID: task.ID, LastError: errHandler.Error()}
+				store.EXPECT().KillTask(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+			},
+		},
+		{
+			name:       "SkipRetry",
+			task:       db.Task{ID: 4, Type: "test", Attempts: 1, MaxAttempts: 3},
+			handlerErr: ErrSkipRetry,
+			buildStubs: func(store *mockdb.MockStore, task db.Task) {
+				store.EXPECT().KillTask(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+		},
+		{
+			name: "UnknownType",
+			task: db.Task{ID: 5, Type: "unknown", Attempts: 1, MaxAttempts: 3},
+			buildStubs: func(store *mockdb.MockStore, task db.Task) {
+				store.EXPECT().KillTask(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				ClaimTasks(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return([]db.Task{tc.task}, nil)
+			tc.buildStubs(store, tc.task)
+
+			p := newTestProcessor(store, func(ctx context.Context, task db.Task) error {
+				require.Equal(t, tc.task.ID, task.ID)
+				return tc.handlerErr
+			})
+
+			processed, err := p.ProcessNext(context.Background())
+			require.NoError(t, err)
+			require.True(t, processed)
+		})
+	}
+}
+
+func TestProcessNextQueuePriority(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			ClaimTasks(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg db.ClaimTasksParams) ([]db.Task, error) {
+				require.Equal(t, QueueCritical, arg.Queue)
+				return []db.Task{}, nil
+			}),
+		store.EXPECT().
+			ClaimTasks(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg db.ClaimTasksParams) ([]db.Task, error) {
+				require.Equal(t, QueueDefault, arg.Queue)
+				return []db.Task{}, nil
+			}),
+	)
+
+	p := newTestProcessor(store, nil)
+	processed, err := p.ProcessNext(context.Background())
+	require.NoError(t, err)
+	require.False(t, processed)
+}
+
+func TestTaskRetryDelay(t *testing.T) {
+	for attempts := int32(1); attempts < 20; attempts++ {
+		delay := taskRetryDelay(attempts)
+		require.GreaterOrEqual(t, delay, taskRetryBackoff/2)
+		require.LessOrEqual(t, delay, taskMaxRetryBackoff)
+	}
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+const (
+	QueueCritical = "critical"
+	QueueDefault  = "default"
+)
+
+// queues lists every queue in the order the processor drains them.
+var queues = []string{QueueCritical, QueueDefault}
+
+const (
+	TaskStatusPending   = "pending"
+	TaskStatusRunning   = "running"
+	TaskStatusCompleted = "completed"
+	TaskStatusDead      = "dead"
+)
+
+const defaultMaxAttempts = 5
+
+// ErrSkipRetry tells the processor that retrying a task cannot help, so it
+// is dead-lettered straight away.
+var ErrSkipRetry = errors.New("skip retry")
+
+type taskOptions struct {
+	queue       string
+	maxAttempts int32
+	delay       time.Duration
+}
+
+type Option func(*taskOptions)
+
+func Queue(name string) Option {
+	return func(o *taskOptions) { o.queue = name }
+}
+
+// MaxAttempts caps how many times the task runs before it is dead-lettered.
+func MaxAttempts(n int32) Option {
+	return func(o *taskOptions) { o.maxAttempts = n }
+}
+
+// ProcessIn delays the first attempt.
+func ProcessIn(d time.Duration) Option {
+	return func(o *taskOptions) { o.delay = d }
+}
+
+const (
+	taskRetryBackoff    = 10 * time.Second
+	taskMaxRetryBackoff = time.Hour
+)
+
+// taskRetryDelay doubles with every attempt up to an hour, with jitter so
+// that tasks failing together do not retry together.
+func taskRetryDelay(attempts int32) time.Duration {
+	delay := taskMaxRetryBackoff
+	if attempts < 10 {
+		delay = taskRetryBackoff << (attempts - 1)
+		if delay > taskMaxRetryBackoff {
+			delay = taskMaxRetryBackoff
+		}
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
@@ -0,0 +1,150 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/webhook"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const TaskDeliverWebhook = "task:deliver_webhook"
+
+// WebhookMaxAttempts spreads the retries of a delivery over about a day
+// with the queue's backoff.
+const WebhookMaxAttempts = 12
+
+type PayloadDeliverWebhook struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+func (d *PGTaskDistributor) DistributeTaskDeliverWebhook(
+	ctx context.Context,
+	q db.Querier,
+	payload *PayloadDeliverWebhook,
+	opts ...Option,
+) error {
+	_, err := d.distribute(ctx, q, TaskDeliverWebhook, payload, opts...)
+	return err
+}
+
+// ProcessTaskDeliverWebhook POSTs the signed event to the endpoint and
+// records the attempt on the delivery. The delivery stays pending while the
+// task has attempts left and is marked failed after the last one.
+func (p *TaskProcessor) ProcessTaskDeliverWebhook(ctx context.Context, task db.Task) error {
+	var payload PayloadDeliverWebhook
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("%w: cannot unmarshal payload: %v", ErrSkipRetry, err)
+	}
+
+	delivery, err := p.store.GetWebhookDelivery(ctx, payload.DeliveryID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("%w: delivery %d does not exist", ErrSkipRetry, payload.DeliveryID)
+		}
+		return fmt.Errorf("cannot get webhook delivery: %w", err)
+	}
+	if delivery.Status == db.WebhookDeliverySucceeded {
+		return nil
+	}
+
+	endpoint, err := p.store.GetWebhookEndpoint(ctx, delivery.EndpointID)
+	if err != nil {
+		return fmt.Errorf("cannot get webhook endpoint: %w", err)
+	}
+	if !endpoint.IsActive {
+		err := fmt.Errorf("%w: endpoint %d is not active", ErrSkipRetry, endpoint.ID)
+		return p.recordWebhookAttempt(ctx, delivery, db.WebhookDeliveryFailed, 0, err)
+	}
+	if !strings.HasPrefix(endpoint.Url, "https://") {
+		err := fmt.Errorf("%w: endpoint %d: %v", ErrSkipRetry, endpoint.ID, webhook.ErrInsecureURL)
+		return p.recordWebhookAttempt(ctx, delivery, db.WebhookDeliveryFailed, 0, err)
+	}
+
+	event, err := p.store.GetOutboxEvent(ctx, delivery.EventID)
+	if err != nil {
+		return fmt.Errorf("cannot get webhook event: %w", err)
+	}
+
+	body, err := json.Marshal(webhook.Event{
+		ID:        event.ID,
+		Type:      event.Type,
+		AccountID: event.AccountID,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: cannot marshal webhook event: %v", ErrSkipRetry, err)
+	}
+
+	responseStatus, err := p.postWebhook(ctx, endpoint, delivery, event.Type, body)
+	if err == nil {
+		return p.recordWebhookAttempt(ctx, delivery, db.WebhookDeliverySucceeded, responseStatus, nil)
+	}
+
+	status := db.WebhookDeliveryPending
+	if task.Attempts >= task.MaxAttempts {
+		status = db.WebhookDeliveryFailed
+	}
+	return p.recordWebhookAttempt(ctx, delivery, status, responseStatus, err)
+}
+
+func (p *TaskProcessor) postWebhook(
+	ctx context.Context,
+	endpoint db.WebhookEndpoint,
+	delivery db.WebhookDelivery,
+	eventType string,
+	body []byte,
+) (int32, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("cannot create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(endpoint.Secret, time.Now(), body))
+	req.Header.Set(webhook.EventHeader, eventType)
+	req.Header.Set(webhook.DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return int32(resp.StatusCode), fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return int32(resp.StatusCode), nil
+}
+
+// recordWebhookAttempt logs the attempt on the delivery and returns
+// attemptErr, so that a failed attempt is retried by the queue.
+func (p *TaskProcessor) recordWebhookAttempt(
+	ctx context.Context,
+	delivery db.WebhookDelivery,
+	status string,
+	responseStatus int32,
+	attemptErr error,
+) error {
+	arg := db.RecordWebhookAttemptParams{
+		ID:             delivery.ID,
+		Status:         status,
+		ResponseStatus: responseStatus,
+	}
+	if attemptErr != nil {
+		arg.LastError = attemptErr.Error()
+	}
+
+	if _, err := p.store.RecordWebhookAttempt(ctx, arg); err != nil {
+		return fmt.Errorf("cannot record webhook attempt: %w", err)
+	}
+	return attemptErr
+}
@@ -0,0 +1,234 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"simplebank/webhook"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessTaskDeliverWebhook(t *testing.T) {
+	endpoint := db.WebhookEndpoint{
+		ID:       1,
+		Owner:    util.RandomOwner(),
+		Secret:   util.RandomString(32),
+		IsActive: true,
+	}
+	event := db.Outbox{
+		ID:        2,
+		AccountID: 3,
+		Type:      db.EventAccountCredited,
+		Payload:   json.RawMessage(`{"amount":10}`),
+		CreatedAt: time.Now().UTC(),
+	}
+	delivery := db.WebhookDelivery{
+		ID:         4,
+		EndpointID: endpoint.ID,
+		EventID:    event.ID,
+		Status:     db.WebhookDeliveryPending,
+	}
+
+	payload, err := json.Marshal(PayloadDeliverWebhook{DeliveryID: delivery.ID})
+	require.NoError(t, err)
+	task := db.Task{ID: 1, Type: TaskDeliverWebhook, Payload: payload, Attempts: 1, MaxAttempts: WebhookMaxAttempts}
+	lastAttempt := task
+	lastAttempt.Attempts = WebhookMaxAttempts
+
+	expectRecord := func(store *mockdb.MockStore, status string, responseStatus int32) {
+		store.EXPECT().
+			RecordWebhookAttempt(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg db.RecordWebhookAttemptParams) (db.WebhookDelivery, error) {
+				require.Equal(t, delivery.ID, arg.ID)
+				require.Equal(t, status, arg.Status)
+				require.Equal(t, responseStatus, arg.ResponseStatus)
+				if status == db.WebhookDeliverySucceeded {
+					require.Empty(t, arg.LastError)
+				} else {
+					require.NotEmpty(t, arg.LastError)
+				}
+				return db.WebhookDelivery{}, nil
+			})
+	}
+	expectLoad := func(store *mockdb.MockStore, endpoint db.WebhookEndpoint) {
+		store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).Times(1).Return(delivery, nil)
+		store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+		store.EXPECT().GetOutboxEvent(gomock.Any(), gomock.Eq(event.ID)).Times(1).Return(event, nil)
+	}
+
+	testCases := []struct {
+		name       string
+		task       db.Task
+		status     int
+		guarded    bool
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:   "OK",
+			task:   task,
+			status: http.StatusNoContent,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectLoad(store, endpoint)
+				expectRecord(store, db.WebhookDeliverySucceeded, http.StatusNoContent)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:    "PrivateHost",
+			task:    task,
+			status:  http.StatusNoContent,
+			guarded: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectLoad(store, endpoint)
+				expectRecord(store, db.WebhookDeliveryPending, 0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, webhook.ErrForbiddenHost)
+			},
+		},
+		{
+			name:   "EndpointError",
+			task:   task,
+			status: http.StatusServiceUnavailable,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectLoad(store, endpoint)
+				expectRecord(store, db.WebhookDeliveryPending, http.StatusServiceUnavailable)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, ErrSkipRetry)
+			},
+		},
+		{
+			name:   "LastAttempt",
+			task:   lastAttempt,
+			status: http.StatusInternalServerError,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectLoad(store, endpoint)
+				expectRecord(store, db.WebhookDeliveryFailed, http.StatusInternalServerError)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name: "AlreadySucceeded",
+			task: task,
+			buildStubs: func(store *mockdb.MockStore) {
+				succeeded := delivery
+				succeeded.Status = db.WebhookDeliverySucceeded
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(succeeded, nil)
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RecordWebhookAttempt(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "PlainHTTPEndpoint",
+			task: task,
+			buildStubs: func(store *mockdb.MockStore) {
+				plain := endpoint
+				plain.Url = "http://example.com/hooks"
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(delivery, nil)
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Any()).Times(1).Return(plain, nil)
+				store.EXPECT().GetOutboxEvent(gomock.Any(), gomock.Any()).Times(0)
+				expectRecord(store, db.WebhookDeliveryFailed, 0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrSkipRetry)
+			},
+		},
+		{
+			name: "InactiveEndpoint",
+			task: task,
+			buildStubs: func(store *mockdb.MockStore) {
+				inactive := endpoint
+				inactive.IsActive = false
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(delivery, nil)
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Any()).Times(1).Return(inactive, nil)
+				store.EXPECT().GetOutboxEvent(gomock.Any(), gomock.Any()).Times(0)
+				expectRecord(store, db.WebhookDeliveryFailed, 0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrSkipRetry)
+			},
+		},
+		{
+			name: "DeliveryNotFound",
+			task: task,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(db.WebhookDelivery{}, db.ErrRecordNotFound)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrSkipRetry)
+			},
+		},
+		{
+			name: "InvalidPayload",
+			task: db.Task{ID: 1, Type: TaskDeliverWebhook, Payload: []byte("{")},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrSkipRetry)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				signature := r.Header.Get(webhook.SignatureHeader)
+				require.NoError(t, webhook.Verify(endpoint.Secret, signature, body, time.Minute, time.Now()))
+				require.Equal(t, event.Type, r.Header.Get(webhook.EventHeader))
+				require.Equal(t, strconv.FormatInt(delivery.ID, 10), r.Header.Get(webhook.DeliveryHeader))
+
+				var got webhook.Event
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Equal(t, event.ID, got.ID)
+				require.Equal(t, event.AccountID, got.AccountID)
+				require.JSONEq(t, string(event.Payload), string(got.Data))
+
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+			endpoint.Url = server.URL
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			p := NewTaskProcessor(store, nil, util.Config{WebhookTimeout: time.Second})
+			if !tc.guarded {
+				// The test server listens on loopback, which the real
+				// client refuses to dial.
+				p.httpClient = server.Client()
+			}
+			err := p.ProcessTaskDeliverWebhook(context.Background(), tc.task)
+			tc.checkError(t, err)
+		})
+	}
+}
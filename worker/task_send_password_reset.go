@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/mail"
+	"simplebank/util"
+	"time"
+)
+
+const TaskSendPasswordReset = "task:send_password_reset"
+
+type PayloadSendPasswordReset struct {
+	Username string `json:"username"`
+}
+
+func (d *PGTaskDistributor) DistributeTaskSendPasswordReset(
+	ctx context.Context,
+	q db.Querier,
+	payload *PayloadSendPasswordReset,
+	opts ...Option,
+) error {
+	_, err := d.distribute(ctx, q, TaskSendPasswordReset, payload, opts...)
+	return err
+}
+
+// ProcessTaskSendPasswordReset issues a reset token and mails it. Only the
+// token's hash is stored, so the email is the one place it ever appears.
+func (p *TaskProcessor) ProcessTaskSendPasswordReset(ctx context.Context, task db.Task) error {
+	var payload PayloadSendPasswordReset
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("%w: cannot unmarshal payload: %v", ErrSkipRetry, err)
+	}
+
+	user, err := p.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("%w: user %q does not exist", ErrSkipRetry, payload.Username)
+		}
+		return fmt.Errorf("cannot get user: %w", err)
+	}
+
+	token, err := util.RandomSecret(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.store.CreatePasswordReset(ctx, db.CreatePasswordResetParams{
+		Username:  user.Username,
+		TokenHash: util.HashToken(token),
+		ExpiredAt: time.Now().Add(p.config.PasswordResetDuration),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create password reset: %w", err)
+	}
+
+	msg := mail.PasswordResetMessage(p.config.AppBaseURL, user.Email, user.Fullname, token)
+	return p.mailer.Send(ctx, msg)
+}
@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/mail"
+	mockmail "simplebank/mail/mock"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessTaskSendPasswordReset(t *testing.T) {
+	user := db.User{
+		Username: util.RandomOwner(),
+		Fullname: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+	}
+	payload, err := json.Marshal(PayloadSendPasswordReset{Username: user.Username})
+	require.NoError(t, err)
+	task := db.Task{ID: 1, Type: TaskSendPasswordReset, Payload: payload}
+
+	testCases := []struct {
+		name       string
+		task       db.Task
+		buildStubs func(store *mockdb.MockStore, mailer *mockmail.MockMailer)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			task: task,
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				var tokenHash string
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					CreatePasswordReset(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePasswordResetParams) (db.PasswordReset, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Len(t, arg.TokenHash, 64)
+						require.WithinDuration(t, time.Now().Add(15*time.Minute), arg.ExpiredAt, time.Second)
+						tokenHash = arg.TokenHash
+						return db.PasswordReset{ID: 1, Username: arg.Username, TokenHash: arg.TokenHash}, nil
+					})
+				mailer.EXPECT().
+					Send(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, msg mail.Message) error {
+						require.Equal(t, []string{user.Email}, msg.To)
+
+						// The mail carries the raw token, which must hash to what was stored.
+						start := strings.Index(msg.Body, "token=") + len("token=")
+						end := strings.Index(msg.Body[start:], `"`)
+						token, err := url.QueryUnescape(msg.Body[start : start+end])
+						require.NoError(t, err)
+						require.Equal(t, tokenHash, util.HashToken(token))
+						return nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "UserNotFound",
+			task: task,
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrSkipRetry)
+			},
+		},
+		{
+			name: "InvalidPayload",
+			task: db.Task{ID: 1, Type: TaskSendPasswordReset, Payload: []byte("{")},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrSkipRetry)
+			},
+		},
+		{
+			name: "MailerError",
+			task: task,
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Times(1).Return(db.PasswordReset{}, nil)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(1).Return(errors.New("connection refused"))
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, ErrSkipRetry)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			mailer := mockmail.NewMockMailer(ctrl)
+			tc.buildStubs(store, mailer)
+
+			p := NewTaskProcessor(store, mailer, util.Config{PasswordResetDuration: 15 * time.Minute})
+			err := p.ProcessTaskSendPasswordReset(context.Background(), tc.task)
+			tc.checkError(t, err)
+		})
+	}
+}
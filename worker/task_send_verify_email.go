@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/mail"
+	"simplebank/util"
+	"time"
+)
+
+const TaskSendVerifyEmail = "task:send_verify_email"
+
+type PayloadSendVerifyEmail struct {
+	Username string `json:"username"`
+}
+
+func (d *PGTaskDistributor) DistributeTaskSendVerifyEmail(
+	ctx context.Context,
+	q db.Querier,
+	payload *PayloadSendVerifyEmail,
+	opts ...Option,
+) error {
+	_, err := d.distribute(ctx, q, TaskSendVerifyEmail, payload, opts...)
+	return err
+}
+
+// ProcessTaskSendVerifyEmail issues a fresh verification code and mails it.
+// A retry issues another code; earlier ones stay valid until they expire.
+func (p *TaskProcessor) ProcessTaskSendVerifyEmail(ctx context.Context, task db.Task) error {
+	var payload PayloadSendVerifyEmail
+	if err := json.Unmarshal(task.Payload, &payload); err != nil {
+		return fmt.Errorf("%w: cannot unmarshal payload: %v", ErrSkipRetry, err)
+	}
+
+	user, err := p.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("%w: user %q does not exist", ErrSkipRetry, payload.Username)
+		}
+		return fmt.Errorf("cannot get user: %w", err)
+	}
+	if user.IsEmailVerified {
+		return nil
+	}
+
+	secretCode, err := util.RandomSecret(32)
+	if err != nil {
+		return err
+	}
+
+	verifyEmail, err := p.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: secretCode,
+		ExpiredAt:  time.Now().Add(p.config.VerifyEmailDuration),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create verify email: %w", err)
+	}
+
+	msg := mail.VerifyEmailMessage(
+		p.config.AppBaseURL,
+		verifyEmail.Email,
+		user.Fullname,
+		verifyEmail.ID,
+		verifyEmail.SecretCode,
+	)
+	return p.mailer.Send(ctx, msg)
+}
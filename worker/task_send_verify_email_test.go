@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/mail"
+	mockmail "simplebank/mail/mock"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistributeTaskSendVerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateTask(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateTaskParams) (db.Task, error) {
+			require.Equal(t, QueueCritical, arg.Queue)
+			require.Equal(t, TaskSendVerifyEmail, arg.Type)
+			require.Equal(t, int32(10), arg.MaxAttempts)
+			require.JSONEq(t, `{"username":"alice"}`, string(arg.Payload))
+			require.WithinDuration(t, time.Now().Add(5*time.Second), arg.RunAt, time.Second)
+			return db.Task{ID: 1}, nil
+		})
+
+	distributor := NewTaskDistributor(nil)
+	err := distributor.DistributeTaskSendVerifyEmail(
+		context.Background(),
+		store,
+		&PayloadSendVerifyEmail{Username: "alice"},
+		Queue(QueueCritical),
+		MaxAttempts(10),
+		ProcessIn(5*time.Second),
+	)
+	require.NoError(t, err)
+}
+
+func TestProcessTaskSendVerifyEmail(t *testing.T) {
+	user := db.User{
+		Username: util.RandomOwner(),
+		Fullname: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+	}
+	payload, err := json.Marshal(PayloadSendVerifyEmail{Username: user.Username})
+	require.NoError(t, err)
+	task := db.Task{ID: 1, Type: TaskSendVerifyEmail, Payload: payload}
+
+	testCases := []struct {
+		name       string
+		task       db.Task
+		buildStubs func(store *mockdb.MockStore, mailer *mockmail.MockMailer)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			task: task,
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					CreateVerifyEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, user.Email, arg.Email)
+						require.Len(t, arg.SecretCode, 64)
+						return db.VerifyEmail{ID: 7, Email: arg.Email, SecretCode: arg.SecretCode}, nil
+					})
+				mailer.EXPECT().
+					Send(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, msg mail.Message) error {
+						require.Equal(t, []string{user.Email}, msg.To)
+						require.Contains(t, msg.Body, "email_id=7")
+						return nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			task: task,
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				verified := user
+				verified.IsEmailVerified = true
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(verified, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(0)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "UserNotFound",
+			task: task,
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrSkipRetry)
+			},
+		},
+		{
+			name: "InvalidPayload",
+			task: db.Task{ID: 1, Type: TaskSendVerifyEmail, Payload: []byte("{")},
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrSkipRetry)
+			},
+		},
+		{
+			name: "MailerError",
+			task: task,
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockMailer) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.VerifyEmail{}, nil)
+				mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(1).Return(errors.New("connection refused"))
+			},
+			checkError: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, ErrSkipRetry)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			mailer := mockmail.NewMockMailer(ctrl)
+			tc.buildStubs(store, mailer)
+
+			p := NewTaskProcessor(store, mailer, util.Config{VerifyEmailDuration: 15 * time.Minute})
+			err := p.ProcessTaskSendVerifyEmail(context.Background(), tc.task)
+			tc.checkError(t, err)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	db "simplebank/db/sqlc"
+
+	"github.com/rs/zerolog/log"
+)
+
+const webhookDispatchBatchSize = 100
+
+// WebhookDispatcher turns the events written to the outbox into one
+// delivery task per subscribed endpoint.
+type WebhookDispatcher struct {
+	store       db.Store
+	distributor TaskDistributor
+	interval    time.Duration
+}
+
+func NewWebhookDispatcher(store db.Store, distributor TaskDistributor, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		store:       store,
+		distributor: distributor,
+		interval:    interval,
+	}
+}
+
+// Start dispatches new events every interval until ctx is cancelled.
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := d.Dispatch(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("cannot dispatch webhook events")
+			}
+			if n > 0 {
+				log.Info().Int("count", n).Msg("dispatched webhook events")
+			}
+		}
+	}
+}
+
+// Dispatch claims events in batches until none are left. A delivery's task
+// is queued in the transaction that claims its event, so an event is never
+// marked dispatched without its deliveries being sent.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := d.store.DispatchWebhookEventsTx(ctx, db.DispatchWebhookEventsTxParams{
+			Limit:       webhookDispatchBatchSize,
+			AfterCreate: d.enqueue(ctx),
+		})
+		total += n
+		if err != nil || n < webhookDispatchBatchSize {
+			return total, err
+		}
+	}
+}
+
+func (d *WebhookDispatcher) enqueue(ctx context.Context) func(q db.Querier, delivery db.WebhookDelivery) error {
+	return func(q db.Querier, delivery db.WebhookDelivery) error {
+		return d.distributor.DistributeTaskDeliverWebhook(
+			ctx,
+			q,
+			&PayloadDeliverWebhook{DeliveryID: delivery.ID},
+			MaxAttempts(WebhookMaxAttempts),
+		)
+	}
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDispatcherDispatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			DispatchWebhookEventsTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg db.DispatchWebhookEventsTxParams) (int, error) {
+				require.Equal(t, int32(webhookDispatchBatchSize), arg.Limit)
+
+				store.EXPECT().
+					CreateTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, task db.CreateTaskParams) (db.Task, error) {
+						require.Equal(t, TaskDeliverWebhook, task.Type)
+						require.Equal(t, int32(WebhookMaxAttempts), task.MaxAttempts)
+						require.JSONEq(t, `{"delivery_id":7}`, string(task.Payload))
+						return db.Task{ID: 1}, nil
+					})
+				require.NoError(t, arg.AfterCreate(store, db.WebhookDelivery{ID: 7}))
+
+				return webhookDispatchBatchSize, nil
+			}),
+		store.EXPECT().
+			DispatchWebhookEventsTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(2, nil),
+	)
+
+	dispatcher := NewWebhookDispatcher(store, NewTaskDistributor(store), time.Minute)
+	n, err := dispatcher.Dispatch(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, webhookDispatchBatchSize+2, n)
+}
+
+func TestWebhookDispatcherDispatchError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		DispatchWebhookEventsTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(0, sql.ErrConnDone)
+
+	dispatcher := NewWebhookDispatcher(store, NewTaskDistributor(store), time.Minute)
+	_, err := dispatcher.Dispatch(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}